@@ -69,5 +69,13 @@ func InitDB() *gorm.DB {
 	}
 	log.Println("Database migrated successfully")
 
+	// Tabel dan kolom baru hanya dibuat jika diminta lewat DB_AUTO_MIGRATE=true (lihat README)
+	if os.Getenv("DB_AUTO_MIGRATE") == "true" {
+		if err := MigrateSchema(db); err != nil {
+			log.Fatalf("Schema migration failed: %v", err)
+		}
+		log.Println("Schema migrated successfully")
+	}
+
 	return db
 }
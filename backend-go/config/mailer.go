@@ -1,6 +1,7 @@
 package config
 
 import (
+	"io"
 	"log"
 	"os"
 
@@ -35,3 +36,22 @@ func (m *Mailer) SendEmail(to, subject, body string) error {
 	}
 	return nil
 }
+
+// SendEmailWithAttachment mengirim email HTML dengan satu lampiran dari memori
+func (m *Mailer) SendEmailWithAttachment(to, subject, body, filename string, data []byte) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", m.dialer.Username)
+	mailer.SetHeader("To", to)
+	mailer.SetHeader("Subject", subject)
+	mailer.SetBody("text/html", body)
+	mailer.Attach(filename, gomail.SetCopyFunc(func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}))
+
+	if err := m.dialer.DialAndSend(mailer); err != nil {
+		log.Printf("Failed to send email: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+// MigrateSchema membuat tabel baru dan menambah kolom baru untuk fitur poin, afiliasi, katalog,
+// flash sale, ulasan, dan rekomendasi. AutoMigrate hanya menambah tabel, kolom, dan index yang
+// belum ada; tidak ada kolom atau data yang dihapus.
+func MigrateSchema(db *gorm.DB) error {
+	return db.AutoMigrate(
+		// Tabel lama yang mendapat kolom baru
+		&models.Product{},
+		&models.ProductItem{},
+		&models.Cart{},
+		&models.AfiliasiBonus{},
+		&models.Pesanan{},
+		&models.TopUpPoin{},
+
+		// Tabel baru
+		&models.PointLot{},
+		&models.PointHistory{},
+		&models.PointTransfer{},
+		&models.PointRate{},
+		&models.PoinPromo{},
+		&models.CommissionRule{},
+		&models.CommissionRuleLevel{},
+		&models.PayoutBatch{},
+		&models.PayoutBatchItem{},
+		&models.ReferralFraudSignal{},
+		&models.AffiliateSummary{},
+		&models.BonusConversion{},
+		&models.ReferralReward{},
+		&models.Category{},
+		&models.ProductImage{},
+		&models.SearchSynonym{},
+		&models.StockMovement{},
+		&models.PriceList{},
+		&models.PriceListItem{},
+		&models.PriceHistory{},
+		&models.FlashSale{},
+		&models.FlashSalePurchase{},
+		&models.ProductReview{},
+		&models.ReviewPhoto{},
+		&models.ProductAffinity{},
+	)
+}
@@ -3,12 +3,15 @@ package app
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"backend-go/models"
+	"backend-go/utils"
 )
 
 type AfiliasiBonusController struct {
@@ -53,6 +56,11 @@ func (ctrl *AfiliasiBonusController) ClaimBonus(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update bonus status"})
 			return
 		}
+		if err := utils.RefreshAffiliateSummariesForBonuses(tx, bonus); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update affiliate summary"})
+			return
+		}
 		tx.Commit()
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Bonus has expired and cannot be claimed"})
 		return
@@ -60,40 +68,38 @@ func (ctrl *AfiliasiBonusController) ClaimBonus(c *gin.Context) {
 
 	bonusAmount := bonus.BonusAmount
 
+	// Batas total bonus diambil dari aturan komisi yang aktif
+	commissionRule, err := utils.GetActiveCommissionRule(tx)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get commission rule"})
+		return
+	}
+
 	// Find or create total bonus
 	var totalBonus models.TotalBonus
 	if err := tx.Where("user_id = ?", bonus.UserId).First(&totalBonus).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// Create new total bonus
-			totalBonus = models.TotalBonus{
-				UserID:     bonus.UserId,
-				TotalBonus: bonusAmount,
-			}
-			if err := tx.Create(&totalBonus).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create total bonus"})
-				return
-			}
-		} else {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 			return
 		}
-	} else {
-		// Check if total bonus exceeds 500000
-		if totalBonus.TotalBonus+bonusAmount > 500000 {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Total bonus already reached 500,000"})
-			return
-		}
+		totalBonus = models.TotalBonus{UserID: bonus.UserId}
+	}
 
-		// Update total bonus
-		totalBonus.TotalBonus += bonusAmount
-		if err := tx.Save(&totalBonus).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update total bonus"})
-			return
-		}
+	// Check if total bonus exceeds the lifetime cap
+	if commissionRule.LifetimeCap > 0 && totalBonus.TotalBonus+bonusAmount > commissionRule.LifetimeCap {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Total bonus already reached " + utils.FormatRupiah(int(commissionRule.LifetimeCap))})
+		return
+	}
+
+	// Update total bonus
+	totalBonus.TotalBonus += bonusAmount
+	if err := tx.Save(&totalBonus).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update total bonus"})
+		return
 	}
 
 	// Update bonus status
@@ -137,12 +143,10 @@ func (ctrl *AfiliasiBonusController) GetTotalBonus(c *gin.Context) {
 func (ctrl *AfiliasiBonusController) GetPendingBonus(c *gin.Context) {
 	userID := c.Param("userId")
 
+	// Bonus pending hanya ada untuk pesanan yang sudah delivered
 	var pendingBonuses []models.AfiliasiBonus
-	if err := ctrl.DB.
-		Joins("JOIN pesanan ON afiliasi_bonus.pesanan_id = pesanan.id").
-		Where("afiliasi_bonus.user_id = ? AND afiliasi_bonus.status = ? AND pesanan.status = ?",
-			userID, models.BonusPending, models.PesananDelivered).
-		Order("afiliasi_bonus.expiry_date ASC").
+	if err := ctrl.DB.Where("user_id = ? AND status = ?", userID, models.BonusPending).
+		Order("expiry_date ASC").
 		Find(&pendingBonuses).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
@@ -162,6 +166,34 @@ func (ctrl *AfiliasiBonusController) GetPendingBonus(c *gin.Context) {
 	})
 }
 
+// GetAwaitingBonus handles GET /afiliasi/awaiting/:userId
+// Bonus yang belum bisa diklaim: pesanannya belum delivered atau sedang direview admin.
+func (ctrl *AfiliasiBonusController) GetAwaitingBonus(c *gin.Context) {
+	userID := c.Param("userId")
+
+	var awaitingBonuses []models.AfiliasiBonus
+	if err := ctrl.DB.Where("user_id = ? AND status IN ?", userID,
+		[]models.AfiliasiBonusStatus{models.BonusAwaitingDelivery, models.BonusOnHold}).
+		Order("bonus_received_at DESC, id DESC").
+		Find(&awaitingBonuses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if len(awaitingBonuses) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message":       "No bonus awaiting delivery",
+			"awaitingBonus": []string{},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Bonuses awaiting delivery retrieved successfully",
+		"awaitingBonus": awaitingBonuses,
+	})
+}
+
 // GetExpiredBonus handles GET /afiliasi/expired/:userId
 func (ctrl *AfiliasiBonusController) GetExpiredBonus(c *gin.Context) {
 	userID := c.Param("userId")
@@ -187,3 +219,172 @@ func (ctrl *AfiliasiBonusController) GetExpiredBonus(c *gin.Context) {
 		"expiredBonus": expiredBonuses,
 	})
 }
+
+// GetConversionQuote handles GET /afiliasi-app/convert/quote?bonusIds=1,2
+// Tanpa bonusIds semua bonus claimed yang belum masuk payout dihitung.
+func (ctrl *AfiliasiBonusController) GetConversionQuote(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var bonusIDs []uint
+	if raw := c.Query("bonusIds"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid bonusIds"})
+				return
+			}
+			bonusIDs = append(bonusIDs, uint(id))
+		}
+	}
+
+	quote, err := utils.QuoteBonusConversion(ctrl.DB, userID.(uint), bonusIDs, false)
+	if err != nil {
+		respondConversionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Conversion quote retrieved successfully",
+		"data":    quote,
+	})
+}
+
+type ConvertBonusRequest struct {
+	BonusIDs       []uint `json:"bonusIds"`
+	IdempotencyKey string `json:"idempotencyKey" binding:"required"`
+}
+
+// ConvertBonus handles POST /afiliasi-app/convert
+func (ctrl *AfiliasiBonusController) ConvertBonus(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var req ConvertBonusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	// Request yang sama dikirim ulang: kembalikan konversi yang sudah ada
+	var existing models.BonusConversion
+	if err := ctrl.DB.Preload("Bonuses").Where("idempotency_key = ?", req.IdempotencyKey).First(&existing).Error; err == nil {
+		if existing.UserID != userID.(uint) {
+			c.JSON(http.StatusConflict, gin.H{"message": "Idempotency key sudah dipakai"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Bonus sudah dikonversi sebelumnya",
+			"data":    existing,
+		})
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	var conversion *models.BonusConversion
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		conversion, err = utils.ConvertBonusesToPoints(tx, userID.(uint), req.BonusIDs, req.IdempotencyKey)
+		return err
+	})
+	if err != nil {
+		respondConversionError(c, err)
+		return
+	}
+
+	var userPoints models.UserPoints
+	ctrl.DB.Where("user_id = ?", userID).First(&userPoints)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Bonus converted to points successfully",
+		"data":         conversion,
+		"pointBalance": userPoints.Points,
+	})
+}
+
+func respondConversionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrNoConvertibleBonus), errors.Is(err, utils.ErrBonusNotConvertible),
+		errors.Is(err, utils.ErrConversionTooSmall):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, utils.ErrPointRateNotFound):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": "Point rate is not configured"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+}
+
+type LeaderboardItem struct {
+	Rank            int     `json:"rank"`
+	Name            string  `json:"name"`
+	ReferredSpend   int64   `json:"referredSpend"`
+	ReferredOrders  int     `json:"referredOrders"`
+	ActiveReferrals int     `json:"activeReferrals"`
+	BonusEarned     float64 `json:"bonusEarned"`
+	IsMe            bool    `json:"isMe"`
+}
+
+// GetLeaderboard handles GET /afiliasi-app/leaderboard?month=YYYY-MM&limit=20
+// Nama affiliate lain disamarkan; user yang login juga mendapat peringkatnya sendiri.
+func (ctrl *AfiliasiBonusController) GetLeaderboard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	month, err := utils.ParseLeaderboardMonth(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	leaderboard, err := utils.GetAffiliateLeaderboard(ctrl.DB, month, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	items := make([]LeaderboardItem, 0, limit)
+	var me *LeaderboardItem
+	for _, entry := range leaderboard.Entries {
+		isMe := entry.UserID == userID.(uint)
+		item := LeaderboardItem{
+			Rank:            entry.Rank,
+			Name:            utils.MaskName(entry.Name),
+			ReferredSpend:   entry.ReferredSpend,
+			ReferredOrders:  entry.ReferredOrders,
+			ActiveReferrals: entry.ActiveReferrals,
+			BonusEarned:     entry.BonusEarned,
+			IsMe:            isMe,
+		}
+		if isMe {
+			item.Name = entry.Name
+			me = &item
+		}
+		if len(items) < limit {
+			items = append(items, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Leaderboard retrieved successfully",
+		"month":       leaderboard.Month.Format("2006-01"),
+		"generatedAt": leaderboard.GeneratedAt,
+		"data":        items,
+		"me":          me,
+	})
+}
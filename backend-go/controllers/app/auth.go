@@ -4,6 +4,7 @@ import (
 	"backend-go/config"
 	"backend-go/middleware"
 	"backend-go/models"
+	"backend-go/utils"
 	"crypto/rand"
 	"fmt"
 	"log"
@@ -28,6 +29,7 @@ func RegisterUser(c *gin.Context) {
 		RoleName     string `json:"role_name"`
 		ReferralCode string `json:"referralCode"`
 		PhoneNumber  string `json:"phone_number"`
+		FCMToken     string `json:"fcm_token"` // Opsional, dipakai untuk deteksi perangkat ganda
 	}
 
 	var reqBody RequestBody
@@ -105,6 +107,7 @@ func RegisterUser(c *gin.Context) {
 		ReferredBy:     referredBy,
 		ReferralUsedAt: referralUsedAt,
 		IsApproved:     false,
+		FCMToken:       reqBody.FCMToken,
 	}
 
 	if err := db.Create(&newUser).Error; err != nil {
@@ -124,6 +127,11 @@ func RegisterUser(c *gin.Context) {
 		return
 	}
 
+	// Catat sinyal fraud referral; registrasi tetap berjalan, bonusnya nanti ditahan untuk review
+	if _, err := utils.DetectRegistrationFraud(db, &newUser); err != nil {
+		log.Println("Error detecting referral fraud:", err)
+	}
+
 	// Generate token
 	token, err := generateToken(newUser.ID, reqBody.Fullname, role.RoleName)
 	if err != nil {
@@ -330,11 +338,19 @@ func GetUserData(c *gin.Context) {
 		response.PhotoProfile = user.Details.PhotoProfile
 	}
 
-	// Isi data referrals
+	// Isi data referrals dari ringkasan affiliate bulan ini (satu query untuk semua referral)
 	response.Referrals = make([]ReferralItem, 0)
-	now := time.Now()
-	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	startOfMonth := utils.SummaryMonth(time.Now())
+
+	var summaries []models.AffiliateSummary
+	if err := db.Where("user_id = ? AND month = ?", user.ID, startOfMonth).Find(&summaries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to load affiliate summary"})
+		return
+	}
+	summaryByReferral := make(map[uint]models.AffiliateSummary, len(summaries))
+	for _, summary := range summaries {
+		summaryByReferral[summary.ReferralUserID] = summary
+	}
 
 	// Hitung total bonus level 1 dan level 2
 	totalBonusLevel1 := 0
@@ -346,76 +362,11 @@ func GetUserData(c *gin.Context) {
 			fullname = referral.Details.Fullname
 		}
 
-		type BonusSummary struct {
-			TotalBonus float64 `gorm:"column:total_bonus"`
-			Count      int64   `gorm:"column:transaction_count"`
-		}
-
-		var bonusSummaryLevel1 BonusSummary
-
-		// QUERY 1: Ambil data bonus LEVEL 1 dari AFILIASI_BONUS yang terkait dengan PESANAN DELIVERED
-		err := db.Model(&models.AfiliasiBonus{}).
-			Joins("JOIN pesanan ON afiliasi_bonus.pesanan_id = pesanan.id").
-			Where("afiliasi_bonus.user_id = ? AND afiliasi_bonus.referral_user_id = ? AND afiliasi_bonus.bonus_level = ? AND pesanan.status = ? AND afiliasi_bonus.status IN (?, ?) AND afiliasi_bonus.bonus_received_at BETWEEN ? AND ?",
-				user.ID,                 // User yang mendapatkan bonus
-				referral.ID,             // User yang direferral
-				1,                       // Bonus level 1
-				models.PesananDelivered, // HANYA pesanan dengan status delivered
-				models.BonusPending,
-				models.BonusClaimed,
-				startOfMonth,
-				endOfMonth).
-			Select("COALESCE(SUM(afiliasi_bonus.bonus_amount), 0) as total_bonus, COUNT(*) as transaction_count").
-			Scan(&bonusSummaryLevel1).Error
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to calculate monthly bonus level 1"})
-			return
-		}
-
-		// QUERY 2: Ambil data bonus LEVEL 2 dari AFILIASI_BONUS yang terkait dengan PESANAN DELIVERED
-		var bonusSummaryLevel2 BonusSummary
-		err = db.Model(&models.AfiliasiBonus{}).
-			Joins("JOIN pesanan ON afiliasi_bonus.pesanan_id = pesanan.id").
-			Joins("JOIN users AS level2_user ON afiliasi_bonus.referral_user_id = level2_user.id").
-			Where("afiliasi_bonus.user_id = ? AND afiliasi_bonus.bonus_level = ? AND pesanan.status = ? AND afiliasi_bonus.status IN (?, ?) AND afiliasi_bonus.bonus_received_at BETWEEN ? AND ? AND level2_user.referred_by = ?",
-				user.ID,                 // User yang mendapatkan bonus (user A)
-				2,                       // Bonus level 2
-				models.PesananDelivered, // HANYA pesanan dengan status delivered
-				models.BonusPending,
-				models.BonusClaimed,
-				startOfMonth,
-				endOfMonth,
-				referral.ID, // Harus berasal dari user yang diundang oleh referral saat ini
-			).
-			Select("COALESCE(SUM(afiliasi_bonus.bonus_amount), 0) as total_bonus, COUNT(*) as transaction_count").
-			Scan(&bonusSummaryLevel2).Error
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to calculate monthly bonus level 2"})
-			return
-		}
-
-		// QUERY 3: Ambil total belanja DELIVERED untuk referral
-		var monthlySpent int
-		err = db.Model(&models.Pesanan{}).
-			Where("user_id = ? AND status = ? AND created_at BETWEEN ? AND ?",
-				referral.ID,             // User yang direferral
-				models.PesananDelivered, // HANYA status delivered
-				startOfMonth,
-				endOfMonth).
-			Select("COALESCE(SUM(total_bayar), 0)").
-			Scan(&monthlySpent).Error
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to calculate monthly spent"})
-			return
-		}
-
-		// Tentukan eligibility berdasarkan PESANAN DELIVERED
-		monthlyBonusLevel1 := int(bonusSummaryLevel1.TotalBonus)
-		monthlyBonusLevel2 := int(bonusSummaryLevel2.TotalBonus)
-		eligibleOrdersCount := int(bonusSummaryLevel1.Count)
+		summary := summaryByReferral[referral.ID]
+		monthlySpent := int(summary.MonthlySpent)
+		monthlyBonusLevel1 := int(summary.BonusLevel1)
+		monthlyBonusLevel2 := int(summary.BonusLevel2)
+		eligibleOrdersCount := summary.BonusLevel1Count
 
 		// Eligibility: Ada bonus HANYA jika ada pesanan delivered >= 200.000
 		hasEligibleDeliveredOrder := monthlySpent >= 200000 && eligibleOrdersCount > 0
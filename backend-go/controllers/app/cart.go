@@ -75,6 +75,11 @@ func (ctrl *CartController) AddToCart(c *gin.Context) {
 		}
 
 		existingCartItem.Quantity = newQuantity
+		// Harga saat pertama dimasukkan tetap dipakai sebagai acuan, kecuali data lama yang belum punya
+		if existingCartItem.HargaRpAdded == 0 {
+			existingCartItem.HargaRpAdded = productItem.HargaRp
+			existingCartItem.HargaPoinAdded = productItem.HargaPoin
+		}
 		if err := tx.Save(&existingCartItem).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update cart"})
@@ -96,10 +101,12 @@ func (ctrl *CartController) AddToCart(c *gin.Context) {
 
 	// Add new item to cart
 	newCartItem := models.Cart{
-		UserID:        reqBody.UserID,
-		ProductItemID: reqBody.ProductItemID,
-		Quantity:      reqBody.Quantity,
-		Status:        "active",
+		UserID:         reqBody.UserID,
+		ProductItemID:  reqBody.ProductItemID,
+		Quantity:       reqBody.Quantity,
+		Status:         "active",
+		HargaRpAdded:   productItem.HargaRp,
+		HargaPoinAdded: productItem.HargaPoin,
 	}
 
 	if err := tx.Create(&newCartItem).Error; err != nil {
@@ -159,7 +166,12 @@ func (ctrl *CartController) GetCartByUser(c *gin.Context) {
 		Status        string    `json:"Status"`
 		CreatedAt     time.Time `json:"CreatedAt"`
 		UpdatedAt     time.Time `json:"UpdatedAt"`
-		ProductItem   struct {
+		// Harga saat item dimasukkan dan apakah harga sudah berubah sejak itu
+		HargaRpAdded   int  `json:"HargaRpAdded"`
+		HargaPoinAdded int  `json:"HargaPoinAdded"`
+		PriceChanged   bool `json:"PriceChanged"`
+		PriceDiffRp    int  `json:"PriceDiffRp"` // Positif = harga naik
+		ProductItem    struct {
 			ID         uint      `json:"ID"`
 			ProductID  uint      `json:"ProductID"`
 			Stok       int       `json:"Stok"`
@@ -199,6 +211,13 @@ func (ctrl *CartController) GetCartByUser(c *gin.Context) {
 			cr.ProductItem.CreatedAt = item.ProductItem.CreatedAt
 			cr.ProductItem.UpdatedAt = item.ProductItem.UpdatedAt
 
+			cr.HargaRpAdded = item.HargaRpAdded
+			cr.HargaPoinAdded = item.HargaPoinAdded
+			if item.HargaRpAdded > 0 {
+				cr.PriceDiffRp = item.ProductItem.HargaRp - item.HargaRpAdded
+				cr.PriceChanged = cr.PriceDiffRp != 0 || item.ProductItem.HargaPoin != item.HargaPoinAdded
+			}
+
 			// Ambil data dari relasi Product
 			if item.ProductItem.Product != nil {
 				cr.ProductItem.NameProduk = item.ProductItem.Product.NameProduk
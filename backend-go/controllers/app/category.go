@@ -0,0 +1,34 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/utils"
+)
+
+type CategoryController struct {
+	DB *gorm.DB
+}
+
+func NewCategoryController(db *gorm.DB) *CategoryController {
+	return &CategoryController{DB: db}
+}
+
+// GetCategoryTree handles GET /categories-app/tree
+// Hanya kategori aktif yang ditampilkan; jumlah produk parent termasuk produk sub kategori.
+func (ctrl *CategoryController) GetCategoryTree(c *gin.Context) {
+	tree, err := utils.BuildCategoryTree(ctrl.DB, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching categories",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": tree})
+}
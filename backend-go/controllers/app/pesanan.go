@@ -16,6 +16,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderController struct {
@@ -310,6 +311,14 @@ func (ctrl *OrderController) BuatPesananCOD(c *gin.Context) {
 	uniqueID := strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:8])
 	orderID := "GS" + uniqueID
 
+	// Catat versi nilai poin yang berlaku saat pesanan dibuat
+	pointRate, err := utils.GetCurrentPointRate(tx)
+	if err != nil && !errors.Is(err, utils.ErrPointRateNotFound) {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get poin value"})
+		return
+	}
+
 	// Create order
 	pesanan := models.Pesanan{
 		UserId:           req.UserID,
@@ -324,6 +333,8 @@ func (ctrl *OrderController) BuatPesananCOD(c *gin.Context) {
 		InvoiceNumber:    req.InvoiceNumber,
 	}
 
+	setPesananPointRate(&pesanan, pointRate)
+
 	if err := tx.Create(&pesanan).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create order"})
@@ -331,6 +342,7 @@ func (ctrl *OrderController) BuatPesananCOD(c *gin.Context) {
 	}
 
 	// Create order items and update stock
+	checkout := newOrderCheckout(tx, &pesanan, user.ID, false)
 	for _, item := range req.Items {
 		// Create order item
 		orderItem := models.OrderItem{
@@ -368,56 +380,33 @@ func (ctrl *OrderController) BuatPesananCOD(c *gin.Context) {
 			return
 		}
 
-		productItem.Stok -= item.Jumlah
-		if err := tx.Save(&productItem).Error; err != nil {
+		// Kurangi stok dan tetapkan harga item dari server (harga flash sale jika berlaku)
+		if status, message, err := checkout.sellItem(&orderItem, &productItem); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update product item stock"})
+			c.JSON(status, gin.H{"message": message})
 			return
 		}
 	}
 
-	// Handle affiliate bonus
-	if req.TotalBayar >= 200000 {
-		referrerID := user.ReferredBy
-		bonusLevel := 1
-
-		for bonusLevel <= 2 && referrerID != nil {
-			var referrer models.User
-			if err := tx.First(&referrer, *referrerID).Error; err != nil {
-				break
-			}
-
-			bonusPercentage := 0.0
-			switch bonusLevel {
-			case 1:
-				bonusPercentage = 0.1
-			case 2:
-				bonusPercentage = 0.05
-			}
-
-			BonusAmount := 200000 * bonusPercentage // Base tetap 200k
-
-			bonus := models.AfiliasiBonus{
-				UserId:          *referrerID,
-				ReferralUserId:  req.UserID,
-				PesananId:       pesanan.ID,
-				BonusAmount:     BonusAmount,
-				BonusLevel:      bonusLevel,
-				ExpiryDate:      time.Now().AddDate(0, 1, 0),
-				BonusReceivedAt: time.Now(),
-				Status:          "pending", // Pastikan status diset
-			}
+	// Total pesanan dihitung ulang dari harga server
+	if err := checkout.applyTotals(); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update order total"})
+		return
+	}
 
-			if err := tx.Create(&bonus).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create affiliate bonus"})
-				return
-			}
+	// Handle affiliate bonus sesuai aturan komisi yang aktif
+	commissionRule, err := utils.GetActiveCommissionRule(tx)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get commission rule"})
+		return
+	}
 
-			// Pindah ke level berikutnya (referrer dari referrer saat ini)
-			referrerID = referrer.ReferredBy
-			bonusLevel++
-		}
+	if err := utils.CreateAffiliateBonuses(tx, commissionRule, &user, pesanan.ID, float64(pesanan.TotalBayar)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create affiliate bonus"})
+		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -425,6 +414,8 @@ func (ctrl *OrderController) BuatPesananCOD(c *gin.Context) {
 		return
 	}
 
+	utils.NotifyLowStock(ctrl.DB, checkout.movements...)
+
 	// Send notifications
 	if user.FCMToken != "" {
 		isValid := utils.IsFcmTokenValid(user.FCMToken)
@@ -533,6 +524,14 @@ func (ctrl *OrderController) BuatPesananCODCart(c *gin.Context) {
 	uniqueID := strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:8])
 	orderID := "GS" + uniqueID
 
+	// Catat versi nilai poin yang berlaku saat pesanan dibuat
+	pointRate, err := utils.GetCurrentPointRate(tx)
+	if err != nil && !errors.Is(err, utils.ErrPointRateNotFound) {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get poin value"})
+		return
+	}
+
 	// Buat pesanan
 	pesanan := models.Pesanan{
 		UserId:           req.UserID,
@@ -547,6 +546,8 @@ func (ctrl *OrderController) BuatPesananCODCart(c *gin.Context) {
 		InvoiceNumber:    req.InvoiceNumber,
 	}
 
+	setPesananPointRate(&pesanan, pointRate)
+
 	if err := tx.Create(&pesanan).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create order"})
@@ -567,6 +568,7 @@ func (ctrl *OrderController) BuatPesananCODCart(c *gin.Context) {
 
 	// Buat order items dan update stok
 	// controllers/app/pesanan.go
+	checkout := newOrderCheckout(tx, &pesanan, user.ID, false)
 	for _, item := range req.Items {
 		// DAPATKAN PRODUCT ITEM LENGKAP
 		var productItem models.ProductItem
@@ -600,57 +602,33 @@ func (ctrl *OrderController) BuatPesananCODCart(c *gin.Context) {
 			return
 		}
 
-		// UPDATE STOK (sisa kode tetap sama)
-		productItem.Stok -= item.Jumlah
-		if err := tx.Save(&productItem).Error; err != nil {
+		// Kurangi stok dan tetapkan harga item dari server (harga flash sale jika berlaku)
+		if status, message, err := checkout.sellItem(&orderItem, &productItem); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update stock"})
+			c.JSON(status, gin.H{"message": message})
 			return
 		}
 	}
 
-	// Handle bonus afiliasi
-	if req.TotalBayar >= 200000 {
-		referrerID := user.ReferredBy
-		bonusLevel := 1
-
-		for bonusLevel <= 2 && referrerID != nil {
-			var referrer models.User
-			if err := tx.First(&referrer, *referrerID).Error; err != nil {
-				break
-			}
-
-			bonusPercentage := 0.0
-			switch bonusLevel {
-			case 1:
-				bonusPercentage = 0.1
-			case 2:
-				bonusPercentage = 0.05
-			}
-
-			BonusAmount := 200000 * bonusPercentage // Base tetap 200k
-
-			bonus := models.AfiliasiBonus{
-				UserId:          *referrerID,
-				ReferralUserId:  req.UserID,
-				PesananId:       pesanan.ID,
-				BonusAmount:     BonusAmount,
-				BonusLevel:      bonusLevel,
-				ExpiryDate:      time.Now().AddDate(0, 1, 0),
-				BonusReceivedAt: time.Now(),
-				Status:          "pending", // Pastikan status diset
-			}
+	// Total pesanan dihitung ulang dari harga server
+	if err := checkout.applyTotals(); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update order total"})
+		return
+	}
 
-			if err := tx.Create(&bonus).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create affiliate bonus"})
-				return
-			}
+	// Handle affiliate bonus sesuai aturan komisi yang aktif
+	commissionRule, err := utils.GetActiveCommissionRule(tx)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get commission rule"})
+		return
+	}
 
-			// Pindah ke level berikutnya (referrer dari referrer saat ini)
-			referrerID = referrer.ReferredBy
-			bonusLevel++
-		}
+	if err := utils.CreateAffiliateBonuses(tx, commissionRule, &user, pesanan.ID, float64(pesanan.TotalBayar)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create affiliate bonus"})
+		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -658,6 +636,8 @@ func (ctrl *OrderController) BuatPesananCODCart(c *gin.Context) {
 		return
 	}
 
+	utils.NotifyLowStock(ctrl.DB, checkout.movements...)
+
 	// Send notifications
 	if user.FCMToken != "" {
 		isValid := utils.IsFcmTokenValid(user.FCMToken)
@@ -770,7 +750,7 @@ func (ctrl *OrderController) BuatPesananPoin(c *gin.Context) {
 
 	// Cek poin user
 	var userPoints models.UserPoints
-	if err := tx.Where("user_id = ?", req.UserID).First(&userPoints).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", req.UserID).First(&userPoints).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusNotFound, gin.H{"message": "User points not found"})
 		return
@@ -782,23 +762,15 @@ func (ctrl *OrderController) BuatPesananPoin(c *gin.Context) {
 		return
 	}
 
-	if userPoints.Points < int(req.TotalBayar) {
-		tx.Rollback()
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": fmt.Sprintf("Poin tidak cukup. Poin Anda: %d", userPoints.Points),
-		})
-		return
-	}
-
 	// Generate order ID
 	uniqueID := strings.ToUpper(strings.Replace(uuid.New().String(), "-", "", -1)[:8])
 	orderID := "GS" + uniqueID
 
-	// Kurangi poin user
-	userPoints.Points -= int(req.TotalBayar)
-	if err := tx.Save(&userPoints).Error; err != nil {
+	// Catat versi nilai poin yang berlaku saat pesanan dibuat; tanpa nilai poin pesanan tetap dibuat
+	pointRate, err := utils.GetCurrentPointRate(tx)
+	if err != nil && !errors.Is(err, utils.ErrPointRateNotFound) {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update user points"})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get poin value"})
 		return
 	}
 
@@ -816,6 +788,8 @@ func (ctrl *OrderController) BuatPesananPoin(c *gin.Context) {
 		InvoiceNumber:    req.InvoiceNumber,
 	}
 
+	setPesananPointRate(&pesanan, pointRate)
+
 	if err := tx.Create(&pesanan).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create order"})
@@ -823,6 +797,7 @@ func (ctrl *OrderController) BuatPesananPoin(c *gin.Context) {
 	}
 
 	// Buat order items dan update stok
+	checkout := newOrderCheckout(tx, &pesanan, user.ID, true)
 	for _, item := range req.Items {
 		orderItem := models.OrderItem{
 			PesananID:     pesanan.ID,
@@ -859,78 +834,53 @@ func (ctrl *OrderController) BuatPesananPoin(c *gin.Context) {
 			return
 		}
 
-		productItem.Stok -= item.Jumlah
-		if err := tx.Save(&productItem).Error; err != nil {
+		// Kurangi stok dan tetapkan harga item dari server (harga flash sale jika berlaku)
+		if status, message, err := checkout.sellItem(&orderItem, &productItem); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update product item stock"})
+			c.JSON(status, gin.H{"message": message})
 			return
 		}
 	}
 
-	// Handle bonus afiliasi jika menggunakan poin
-	if req.TotalBayar >= 200 {
-		// Ambil nilai poin dari settings untuk konversi threshold
-		var setting models.Setting
-		if err := tx.Where("key = ?", "hargaPoin").First(&setting).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get poin value"})
-			return
-		}
+	// Total pesanan dihitung ulang dari harga server sebelum poin user dikurangi
+	if err := checkout.applyTotals(); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update order total"})
+		return
+	}
+
+	if userPoints.Points < pesanan.TotalBayar {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Poin tidak cukup. Poin Anda: %d", userPoints.Points),
+		})
+		return
+	}
 
-		nilaiPoin, err := strconv.Atoi(setting.Value)
+	// Kurangi poin user (lot yang paling cepat kedaluwarsa dipakai lebih dulu)
+	if _, err := utils.DebitPoints(tx, req.UserID, pesanan.TotalBayar, models.PointSourceOrder, orderID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update user points"})
+		return
+	}
+
+	// Handle bonus afiliasi jika menggunakan poin, sesuai aturan komisi yang aktif.
+	// Tanpa nilai poin, totalBayar tidak bisa dikonversi ke Rupiah sehingga bonus dilewati.
+	if pointRate != nil {
+		commissionRule, err := utils.GetActiveCommissionRule(tx)
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Invalid poin value"})
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get commission rule"})
 			return
 		}
 
-		// Konversi totalBayar (poin) ke Rupiah untuk pengecekan threshold
-		totalBayarRupiah := req.TotalBayar * float64(nilaiPoin)
-
-		// Trigger bonus jika mencapai Rp 200.000 (sama dengan COD)
-		if totalBayarRupiah >= 200000 {
-			// Logika bonus sama persis dengan BuatPesananCOD
-			referrerID := user.ReferredBy
-			bonusLevel := 1
-
-			for bonusLevel <= 2 && referrerID != nil {
-				var referrer models.User
-				if err := tx.First(&referrer, *referrerID).Error; err != nil {
-					break
-				}
-
-				bonusPercentage := 0.0
-				switch bonusLevel {
-				case 1:
-					bonusPercentage = 0.1
-				case 2:
-					bonusPercentage = 0.05
-				}
-
-				// Base tetap 200.000 Rupiah (sama dengan COD)
-				BonusAmount := 200000 * bonusPercentage
-
-				bonus := models.AfiliasiBonus{
-					UserId:          *referrerID,
-					ReferralUserId:  req.UserID,
-					PesananId:       pesanan.ID,
-					BonusAmount:     BonusAmount,
-					BonusLevel:      bonusLevel,
-					ExpiryDate:      time.Now().AddDate(0, 1, 0),
-					BonusReceivedAt: time.Now(),
-					Status:          "pending",
-				}
-
-				if err := tx.Create(&bonus).Error; err != nil {
-					tx.Rollback()
-					c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create affiliate bonus"})
-					return
-				}
+		// Konversi totalBayar (poin) ke Rupiah memakai nilai poin yang tercatat di pesanan
+		totalBayarRupiah := float64(pesanan.TotalBayar * pointRate.Rate)
 
-				// Pindah ke level berikutnya (referrer dari referrer saat ini)
-				referrerID = referrer.ReferredBy
-				bonusLevel++
-			}
+		if err := utils.CreateAffiliateBonuses(tx, commissionRule, &user, pesanan.ID, totalBayarRupiah); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create affiliate bonus"})
+			return
 		}
 	}
 
@@ -939,6 +889,8 @@ func (ctrl *OrderController) BuatPesananPoin(c *gin.Context) {
 		return
 	}
 
+	utils.NotifyLowStock(ctrl.DB, checkout.movements...)
+
 	// Send notifications
 	if user.FCMToken != "" {
 		isValid := utils.IsFcmTokenValid(user.FCMToken)
@@ -1051,7 +1003,7 @@ func (ctrl *OrderController) BuatPesananPoinCart(c *gin.Context) {
 
 	// Cek poin user
 	var userPoints models.UserPoints
-	if err := tx.Where("user_id = ?", req.UserID).First(&userPoints).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", req.UserID).First(&userPoints).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusNotFound, gin.H{"message": "User points not found"})
 		return
@@ -1063,26 +1015,10 @@ func (ctrl *OrderController) BuatPesananPoinCart(c *gin.Context) {
 		return
 	}
 
-	if userPoints.Points < int(req.TotalBayar) {
-		tx.Rollback()
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": fmt.Sprintf("Poin tidak cukup. Poin Anda: %d", userPoints.Points),
-		})
-		return
-	}
-
 	// Generate order ID
 	uniqueID := strings.ToUpper(strings.Replace(uuid.New().String(), "-", "", -1)[:8])
 	orderID := "GS" + uniqueID
 
-	// Kurangi poin user
-	userPoints.Points -= int(req.TotalBayar)
-	if err := tx.Save(&userPoints).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update user points"})
-		return
-	}
-
 	// Hapus item cart
 	productItemIDs := make([]uint, len(req.Items))
 	for i, item := range req.Items {
@@ -1095,6 +1031,14 @@ func (ctrl *OrderController) BuatPesananPoinCart(c *gin.Context) {
 		return
 	}
 
+	// Catat versi nilai poin yang berlaku saat pesanan dibuat; tanpa nilai poin pesanan tetap dibuat
+	pointRate, err := utils.GetCurrentPointRate(tx)
+	if err != nil && !errors.Is(err, utils.ErrPointRateNotFound) {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get poin value"})
+		return
+	}
+
 	// Buat pesanan
 	pesanan := models.Pesanan{
 		UserId:           req.UserID,
@@ -1109,6 +1053,8 @@ func (ctrl *OrderController) BuatPesananPoinCart(c *gin.Context) {
 		InvoiceNumber:    req.InvoiceNumber,
 	}
 
+	setPesananPointRate(&pesanan, pointRate)
+
 	if err := tx.Create(&pesanan).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create order"})
@@ -1116,6 +1062,7 @@ func (ctrl *OrderController) BuatPesananPoinCart(c *gin.Context) {
 	}
 
 	// Buat order items dan update stok
+	checkout := newOrderCheckout(tx, &pesanan, user.ID, true)
 	for _, item := range req.Items {
 		// DAPATKAN PRODUCT ITEM LENGKAP
 		var productItem models.ProductItem
@@ -1145,7 +1092,7 @@ func (ctrl *OrderController) BuatPesananPoinCart(c *gin.Context) {
 			return
 		}
 
-		// UPDATE STOK (sisa kode tetap sama)
+		// Kurangi stok dan catat pergerakannya
 		if productItem.Stok < item.Jumlah {
 			tx.Rollback()
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -1154,78 +1101,53 @@ func (ctrl *OrderController) BuatPesananPoinCart(c *gin.Context) {
 			return
 		}
 
-		productItem.Stok -= item.Jumlah
-		if err := tx.Save(&productItem).Error; err != nil {
+		// Kurangi stok dan tetapkan harga item dari server (harga flash sale jika berlaku)
+		if status, message, err := checkout.sellItem(&orderItem, &productItem); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update product item stock"})
+			c.JSON(status, gin.H{"message": message})
 			return
 		}
 	}
 
-	// Handle bonus afiliasi jika menggunakan poin
-	if req.TotalBayar >= 200 {
-		// Ambil nilai poin dari settings untuk konversi threshold
-		var setting models.Setting
-		if err := tx.Where("key = ?", "hargaPoin").First(&setting).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get poin value"})
-			return
-		}
+	// Total pesanan dihitung ulang dari harga server sebelum poin user dikurangi
+	if err := checkout.applyTotals(); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update order total"})
+		return
+	}
 
-		nilaiPoin, err := strconv.Atoi(setting.Value)
+	if userPoints.Points < pesanan.TotalBayar {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Poin tidak cukup. Poin Anda: %d", userPoints.Points),
+		})
+		return
+	}
+
+	// Kurangi poin user (lot yang paling cepat kedaluwarsa dipakai lebih dulu)
+	if _, err := utils.DebitPoints(tx, req.UserID, pesanan.TotalBayar, models.PointSourceOrder, orderID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update user points"})
+		return
+	}
+
+	// Handle bonus afiliasi jika menggunakan poin, sesuai aturan komisi yang aktif.
+	// Tanpa nilai poin, totalBayar tidak bisa dikonversi ke Rupiah sehingga bonus dilewati.
+	if pointRate != nil {
+		commissionRule, err := utils.GetActiveCommissionRule(tx)
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Invalid poin value"})
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get commission rule"})
 			return
 		}
 
-		// Konversi totalBayar (poin) ke Rupiah untuk pengecekan threshold
-		totalBayarRupiah := req.TotalBayar * float64(nilaiPoin)
-
-		// Trigger bonus jika mencapai Rp 200.000 (sama dengan COD)
-		if totalBayarRupiah >= 200000 {
-			// Logika bonus sama persis dengan BuatPesananCOD
-			referrerID := user.ReferredBy
-			bonusLevel := 1
-
-			for bonusLevel <= 2 && referrerID != nil {
-				var referrer models.User
-				if err := tx.First(&referrer, *referrerID).Error; err != nil {
-					break
-				}
-
-				bonusPercentage := 0.0
-				switch bonusLevel {
-				case 1:
-					bonusPercentage = 0.1
-				case 2:
-					bonusPercentage = 0.05
-				}
-
-				// Base tetap 200.000 Rupiah (sama dengan COD)
-				BonusAmount := 200000 * bonusPercentage
-
-				bonus := models.AfiliasiBonus{
-					UserId:          *referrerID,
-					ReferralUserId:  req.UserID,
-					PesananId:       pesanan.ID,
-					BonusAmount:     BonusAmount,
-					BonusLevel:      bonusLevel,
-					ExpiryDate:      time.Now().AddDate(0, 1, 0),
-					BonusReceivedAt: time.Now(),
-					Status:          "pending",
-				}
-
-				if err := tx.Create(&bonus).Error; err != nil {
-					tx.Rollback()
-					c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create affiliate bonus"})
-					return
-				}
+		// Konversi totalBayar (poin) ke Rupiah memakai nilai poin yang tercatat di pesanan
+		totalBayarRupiah := float64(pesanan.TotalBayar * pointRate.Rate)
 
-				// Pindah ke level berikutnya (referrer dari referrer saat ini)
-				referrerID = referrer.ReferredBy
-				bonusLevel++
-			}
+		if err := utils.CreateAffiliateBonuses(tx, commissionRule, &user, pesanan.ID, totalBayarRupiah); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create affiliate bonus"})
+			return
 		}
 	}
 
@@ -1234,6 +1156,8 @@ func (ctrl *OrderController) BuatPesananPoinCart(c *gin.Context) {
 		return
 	}
 
+	utils.NotifyLowStock(ctrl.DB, checkout.movements...)
+
 	// Send notifications
 	if user.FCMToken != "" {
 		isValid := utils.IsFcmTokenValid(user.FCMToken)
@@ -1278,12 +1202,144 @@ func (ctrl *OrderController) BuatPesananPoinCart(c *gin.Context) {
 	})
 }
 
+// setPesananPointRate menyimpan versi dan nilai poin yang dipakai pesanan
+func setPesananPointRate(pesanan *models.Pesanan, rate *models.PointRate) {
+	if rate == nil {
+		return
+	}
+	pesanan.PointRateValue = rate.Rate
+	if rate.ID != 0 {
+		pesanan.PointRateID = &rate.ID
+	}
+}
+
+var (
+	errOrderNotOwned     = errors.New("You are not allowed to delete this order")
+	errOrderNotDeletable = errors.New("Only pending orders can be deleted")
+)
+
+// orderCheckout mencatat penjualan item-item satu pesanan, mengumpulkan mutasi stoknya, dan
+// menghitung subtotal dari harga server
+type orderCheckout struct {
+	tx          *gorm.DB
+	pesanan     *models.Pesanan
+	userID      uint
+	payWithPoin bool
+	movements   []models.StockMovement
+	subtotal    int
+}
+
+func newOrderCheckout(tx *gorm.DB, pesanan *models.Pesanan, userID uint, payWithPoin bool) *orderCheckout {
+	return &orderCheckout{tx: tx, pesanan: pesanan, userID: userID, payWithPoin: payWithPoin}
+}
+
+// sellItem mengurangi stok item pesanan, mengambil kuota flash sale jika sale sedang berjalan, lalu
+// menyimpan harga item dari server. Harga yang dikirim aplikasi tidak dipakai, sehingga keranjang
+// dengan harga lama dihitung ulang alih-alih ditolak. Jika gagal, status HTTP dan pesan untuk
+// aplikasi ikut dikembalikan.
+func (co *orderCheckout) sellItem(orderItem *models.OrderItem, productItem *models.ProductItem) (int, string, error) {
+	movement, err := utils.RecordSale(co.tx, productItem.ID, orderItem.Jumlah, co.pesanan.ID, co.userID)
+	if err != nil {
+		if errors.Is(err, utils.ErrInsufficientStock) {
+			return http.StatusBadRequest, fmt.Sprintf("Insufficient stock for %s", orderItem.NamaProduk), err
+		}
+		return http.StatusInternalServerError, "Failed to update product item stock", err
+	}
+	co.movements = append(co.movements, *movement)
+
+	price, _, err := utils.ClaimFlashSale(co.tx, productItem, co.userID, co.pesanan.ID, orderItem.Jumlah, co.payWithPoin)
+	if err != nil {
+		return http.StatusInternalServerError, "Failed to claim flash sale", err
+	}
+
+	orderItem.Harga = price
+	orderItem.TotalHarga = price * orderItem.Jumlah
+	if err := co.tx.Model(orderItem).Updates(map[string]interface{}{
+		"harga":       orderItem.Harga,
+		"total_harga": orderItem.TotalHarga,
+	}).Error; err != nil {
+		return http.StatusInternalServerError, "Failed to update order item price", err
+	}
+	co.subtotal += orderItem.TotalHarga
+	return http.StatusOK, "", nil
+}
+
+// applyTotals menyimpan subtotal dari harga server ke pesanan dan menghitung ulang totalBayar
+// sebagai subtotal ditambah ongkir
+func (co *orderCheckout) applyTotals() error {
+	co.pesanan.TotalBayar = co.subtotal + co.pesanan.Ongkir
+	updates := map[string]interface{}{"total_bayar": co.pesanan.TotalBayar}
+	if co.payWithPoin {
+		co.pesanan.HargaPoin = co.subtotal
+		updates["harga_poin"] = co.subtotal
+	} else {
+		co.pesanan.HargaRp = co.subtotal
+		updates["harga_rp"] = co.subtotal
+	}
+	return co.tx.Model(co.pesanan).Updates(updates).Error
+}
+
 // DeletePesanan handles DELETE /orders/:id
 func (ctrl *OrderController) DeletePesanan(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := ctrl.DB.Delete(&models.Pesanan{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	pesananID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid order ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+	actorID := userID.(uint)
+
+	// Pengguna hanya boleh menghapus pesanannya sendiri yang masih pending.
+	// Bonus afiliasi yang belum diklaim ikut dibatalkan, stok, kuota flash sale, dan poin dikembalikan
+	if err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var pesanan models.Pesanan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pesanan, pesananID).Error; err != nil {
+			return err
+		}
+		if pesanan.UserId != actorID {
+			return errOrderNotOwned
+		}
+		if pesanan.Status != models.PesananPending {
+			return errOrderNotDeletable
+		}
+
+		if _, err := utils.VoidAffiliateBonuses(tx, pesanan.ID); err != nil {
+			return err
+		}
+		if utils.OrderHoldsStock(pesanan.Status) {
+			if _, err := utils.RestockOrder(tx, pesanan.ID, "Pesanan dihapus", &actorID); err != nil {
+				return err
+			}
+			if err := utils.ReleaseFlashSalePurchases(tx, pesanan.ID); err != nil {
+				return err
+			}
+			if _, err := utils.RefundOrderPoints(tx, &pesanan); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Delete(&models.Pesanan{}, pesanan.ID).Error; err != nil {
+			return err
+		}
+		return utils.RefreshAffiliateSummariesForOrder(tx, &pesanan)
+	}); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "Order not found"})
+		case errors.Is(err, errOrderNotOwned):
+			c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+		case errors.Is(err, errOrderNotDeletable):
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
 		return
 	}
 
@@ -3,11 +3,13 @@ package app
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"backend-go/models"
+	"backend-go/utils"
 )
 
 type PoinController struct {
@@ -18,10 +20,27 @@ func NewPoinController(db *gorm.DB) *PoinController {
 	return &PoinController{DB: db}
 }
 
+type PoinPromoOffer struct {
+	ID                 uint      `json:"id"`
+	Name               string    `json:"name"`
+	BonusPoints        int       `json:"bonusPoints"`
+	DiscountPercentage float64   `json:"discountPercentage"`
+	EndAt              time.Time `json:"endAt"`
+	SecondsRemaining   int64     `json:"secondsRemaining"`
+	RemainingForUser   *int      `json:"remainingForUser"` // nil berarti tanpa batas
+}
+
+type PoinWithPromo struct {
+	models.Poin
+	Promo *PoinPromoOffer `json:"promo"`
+}
+
+// GetPoins handles GET /poin-app
+// Hanya promo yang sedang berlaku dan belum habis kuotanya untuk user yang ditampilkan.
 func (ctrl *PoinController) GetPoins(c *gin.Context) {
 	var poins []models.Poin
 
-	if err := ctrl.DB.Order("poin asc").Find(&poins).Error; err != nil {
+	if err := ctrl.DB.Preload("Discount").Order("poin asc").Find(&poins).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Error fetching poins",
@@ -30,9 +49,58 @@ func (ctrl *PoinController) GetPoins(c *gin.Context) {
 		return
 	}
 
+	now := time.Now()
+	var promos []models.PoinPromo
+	if err := ctrl.DB.Where("start_at <= ? AND end_at > ?", now, now).Find(&promos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching promos",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	offers := make(map[uint]*PoinPromoOffer)
+	for _, promo := range promos {
+		offer := &PoinPromoOffer{
+			ID:                 promo.ID,
+			Name:               promo.Name,
+			BonusPoints:        promo.BonusPoints,
+			DiscountPercentage: promo.DiscountPercentage,
+			EndAt:              promo.EndAt,
+			SecondsRemaining:   int64(promo.EndAt.Sub(now).Seconds()),
+		}
+
+		if promo.MaxPerUser > 0 {
+			uid, _ := userID.(uint)
+			used, err := utils.CountPromoPurchases(ctrl.DB, promo.ID, uid)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"message": "Error fetching promos",
+					"error":   err.Error(),
+				})
+				return
+			}
+			remaining := promo.MaxPerUser - used
+			if remaining <= 0 {
+				continue
+			}
+			offer.RemainingForUser = &remaining
+		}
+
+		offers[promo.PoinID] = offer
+	}
+
+	data := make([]PoinWithPromo, len(poins))
+	for i, poin := range poins {
+		data[i] = PoinWithPromo{Poin: poin, Promo: offers[poin.ID]}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    poins,
+		"data":    data,
 	})
 }
 
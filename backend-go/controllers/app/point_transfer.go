@@ -0,0 +1,430 @@
+package app
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend-go/config"
+	"backend-go/models"
+	"backend-go/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	transferOtpTTL         = 10 * time.Minute
+	transferOtpMaxAttempts = 5
+)
+
+type PointTransferController struct {
+	DB *gorm.DB
+}
+
+func NewPointTransferController(db *gorm.DB) *PointTransferController {
+	return &PointTransferController{DB: db}
+}
+
+type RequestTransferRequest struct {
+	Recipient      string `json:"recipient" binding:"required"` // Kode referral atau nomor HP penerima
+	Points         int    `json:"points" binding:"required,gt=0"`
+	Note           string `json:"note"`
+	IdempotencyKey string `json:"idempotencyKey" binding:"required"`
+}
+
+// RequestTransfer handles POST /transfer-app/request
+func (ctrl *PointTransferController) RequestTransfer(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+	senderID := userID.(uint)
+
+	var req RequestTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	// Request yang sama dikirim ulang: kembalikan transfer yang sudah ada tanpa membuat OTP baru
+	var existing models.PointTransfer
+	if err := ctrl.DB.Preload("Receiver.Details").Where("idempotency_key = ?", req.IdempotencyKey).First(&existing).Error; err == nil {
+		if existing.SenderID != senderID {
+			c.JSON(http.StatusConflict, gin.H{"message": "Idempotency key sudah dipakai"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Transfer sudah dibuat sebelumnya",
+			"data":    transferResponse(existing, senderID),
+		})
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	receiver, err := findTransferRecipient(ctrl.DB, req.Recipient)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Penerima tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if receiver.ID == senderID {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Tidak dapat mentransfer poin ke akun sendiri"})
+		return
+	}
+	if !receiver.IsApproved {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Akun penerima belum aktif"})
+		return
+	}
+
+	var sender models.User
+	if err := ctrl.DB.Preload("Points").First(&sender, senderID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+	if sender.Points == nil || sender.Points.Points < req.Points {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Poin tidak cukup"})
+		return
+	}
+
+	if err := checkTransferDailyLimit(ctrl.DB, senderID, req.Points); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	otp, err := generateTransferOTP(6)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to generate OTP"})
+		return
+	}
+	otpExpires := time.Now().Add(transferOtpTTL)
+
+	transfer := models.PointTransfer{
+		TransferID:     "TR" + strings.ToUpper(strings.Replace(uuid.New().String(), "-", "", -1)[:10]),
+		IdempotencyKey: req.IdempotencyKey,
+		SenderID:       senderID,
+		ReceiverID:     receiver.ID,
+		Points:         req.Points,
+		Note:           req.Note,
+		Status:         models.TransferAwaitingOtp,
+		Otp:            &otp,
+		OtpExpires:     &otpExpires,
+	}
+	if err := ctrl.DB.Create(&transfer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create transfer: " + err.Error()})
+		return
+	}
+	transfer.Receiver = *receiver
+
+	mailer := config.NewMailer()
+	subject := "Kode OTP Transfer Poin"
+	body := fmt.Sprintf(`
+		<html>
+		<body style="font-family: Arial, sans-serif; color: #333;">
+			<h2>Konfirmasi Transfer Poin</h2>
+			<p>Anda akan mentransfer <b>%d poin</b> ke <b>%s</b>.</p>
+			<p>Kode OTP Anda:</p>
+			<p style="font-size: 24px; font-weight: bold; color: #007BFF;">%s</p>
+			<p>Kode ini berlaku selama 10 menit. Jangan berikan kode ini kepada siapa pun.</p>
+		</body>
+		</html>
+	`, transfer.Points, recipientName(receiver), otp)
+
+	if err := mailer.SendEmail(sender.Email, subject, body); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to send OTP email"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "OTP telah dikirim ke email Anda",
+		"data":    transferResponse(transfer, senderID),
+	})
+}
+
+type ConfirmTransferRequest struct {
+	TransferID string `json:"transferId" binding:"required"`
+	Otp        string `json:"otp" binding:"required"`
+}
+
+// ConfirmTransfer handles POST /transfer-app/confirm
+func (ctrl *PointTransferController) ConfirmTransfer(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+	senderID := userID.(uint)
+
+	var req ConfirmTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	tx := ctrl.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var transfer models.PointTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transfer_id = ? AND sender_id = ?", req.TransferID, senderID).
+		First(&transfer).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"message": "Transfer tidak ditemukan"})
+		return
+	}
+
+	// Konfirmasi ulang atas transfer yang sudah selesai tidak memindahkan poin lagi
+	if transfer.Status == models.TransferCompleted {
+		tx.Rollback()
+		ctrl.DB.Preload("Details").First(&transfer.Receiver, transfer.ReceiverID)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Transfer sudah berhasil",
+			"data":    transferResponse(transfer, senderID),
+		})
+		return
+	}
+	if transfer.Status != models.TransferAwaitingOtp {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Transfer tidak dapat dikonfirmasi"})
+		return
+	}
+
+	if transfer.OtpExpires == nil || time.Now().After(*transfer.OtpExpires) {
+		tx.Model(&transfer).Updates(map[string]interface{}{"status": models.TransferExpired, "otp": nil})
+		tx.Commit()
+		c.JSON(http.StatusBadRequest, gin.H{"message": "OTP sudah kedaluwarsa"})
+		return
+	}
+
+	if transfer.Otp == nil || *transfer.Otp != req.Otp {
+		updates := map[string]interface{}{"otp_attempts": transfer.OtpAttempts + 1}
+		if transfer.OtpAttempts+1 >= transferOtpMaxAttempts {
+			updates["status"] = models.TransferFailed
+			updates["otp"] = nil
+		}
+		tx.Model(&transfer).Updates(updates)
+		tx.Commit()
+		c.JSON(http.StatusBadRequest, gin.H{"message": "OTP tidak valid"})
+		return
+	}
+
+	// Kunci kedua saldo dengan urutan user_id yang tetap agar dua transfer berlawanan arah tidak deadlock
+	firstID, secondID := transfer.SenderID, transfer.ReceiverID
+	if firstID > secondID {
+		firstID, secondID = secondID, firstID
+	}
+	for _, id := range []uint{firstID, secondID} {
+		if _, err := utils.LockUserPoints(tx, id); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to lock user points: " + err.Error()})
+			return
+		}
+	}
+
+	// Batas harian dicek ulang setelah saldo pengirim terkunci agar konfirmasi paralel tidak lolos bersamaan
+	if err := checkTransferDailyLimit(tx, senderID, transfer.Points); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if _, err := utils.DebitPoints(tx, transfer.SenderID, transfer.Points, models.PointSourceTransferOut, transfer.TransferID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, utils.ErrInsufficientPoints) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Poin tidak cukup"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to debit points: " + err.Error()})
+		return
+	}
+
+	if _, err := utils.CreditPoints(tx, transfer.ReceiverID, transfer.Points, models.PointSourceTransferIn, transfer.TransferID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to credit points: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	if err := tx.Model(&transfer).Updates(map[string]interface{}{
+		"status":       models.TransferCompleted,
+		"otp":          nil,
+		"completed_at": now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update transfer: " + err.Error()})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to commit transaction"})
+		return
+	}
+	transfer.Status = models.TransferCompleted
+	transfer.CompletedAt = &now
+
+	var sender, receiver models.User
+	if err := ctrl.DB.Preload("Details").First(&sender, transfer.SenderID).Error; err != nil {
+		log.Printf("Failed to load transfer sender %d: %v", transfer.SenderID, err)
+	}
+	if err := ctrl.DB.Preload("Details").First(&receiver, transfer.ReceiverID).Error; err != nil {
+		log.Printf("Failed to load transfer receiver %d: %v", transfer.ReceiverID, err)
+	}
+	transfer.Receiver = receiver
+
+	go utils.SendPointTransferNotification(sender.FCMToken, transfer.TransferID, transfer.Points, recipientName(&receiver), false)
+	go utils.SendPointTransferNotification(receiver.FCMToken, transfer.TransferID, transfer.Points, recipientName(&sender), true)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Transfer poin berhasil",
+		"data":    transferResponse(transfer, senderID),
+	})
+}
+
+// GetTransfers handles GET /transfer-app
+func (ctrl *PointTransferController) GetTransfers(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+	offset := page * limit
+
+	query := ctrl.DB.Model(&models.PointTransfer{}).
+		Where("status = ?", models.TransferCompleted).
+		Where("sender_id = ? OR receiver_id = ?", userID, userID)
+
+	var totalRows int64
+	query.Count(&totalRows)
+	totalPage := (int(totalRows) + limit - 1) / limit
+
+	var transfers []models.PointTransfer
+	if err := query.Preload("Sender.Details").Preload("Receiver.Details").
+		Order("completed_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&transfers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	data := make([]gin.H, len(transfers))
+	for i, transfer := range transfers {
+		data[i] = transferResponse(transfer, userID.(uint))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      data,
+		"page":      page,
+		"limit":     limit,
+		"totalPage": totalPage,
+		"totalRows": totalRows,
+	})
+}
+
+// findTransferRecipient mencari penerima berdasarkan kode referral atau nomor HP
+func findTransferRecipient(db *gorm.DB, recipient string) (*models.User, error) {
+	recipient = strings.TrimSpace(recipient)
+
+	var user models.User
+	err := db.Preload("Details").Where("referral_code = ?", recipient).First(&user).Error
+	if err == nil {
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = db.Preload("Details").
+		Joins("JOIN details_users ON details_users.user_id = users.id").
+		Where("details_users.phone_number = ?", recipient).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// checkTransferDailyLimit memastikan total transfer keluar hari ini tidak melebihi batas harian
+func checkTransferDailyLimit(db *gorm.DB, senderID uint, points int) error {
+	limit := utils.GetPointTransferDailyLimit(db)
+
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var sentToday int64
+	if err := db.Model(&models.PointTransfer{}).
+		Where("sender_id = ? AND status = ? AND completed_at >= ?", senderID, models.TransferCompleted, startOfDay).
+		Select("COALESCE(SUM(points), 0)").
+		Scan(&sentToday).Error; err != nil {
+		return err
+	}
+
+	if int(sentToday)+points > limit {
+		return fmt.Errorf("Melebihi batas transfer harian. Sisa batas hari ini: %d poin", max(limit-int(sentToday), 0))
+	}
+	return nil
+}
+
+func recipientName(user *models.User) string {
+	if user.Details != nil && user.Details.Fullname != "" {
+		return user.Details.Fullname
+	}
+	return user.ReferralCode
+}
+
+func transferResponse(transfer models.PointTransfer, viewerID uint) gin.H {
+	direction := "out"
+	counterpart := &transfer.Receiver
+	if transfer.ReceiverID == viewerID {
+		direction = "in"
+		counterpart = &transfer.Sender
+	}
+
+	return gin.H{
+		"transferId":      transfer.TransferID,
+		"direction":       direction,
+		"points":          transfer.Points,
+		"note":            transfer.Note,
+		"status":          transfer.Status,
+		"counterpartName": recipientName(counterpart),
+		"expiresAt":       transfer.OtpExpires,
+		"completedAt":     transfer.CompletedAt,
+		"createdAt":       transfer.CreatedAt,
+	}
+}
+
+func generateTransferOTP(length int) (string, error) {
+	const charset = "0123456789"
+	result := make([]byte, length)
+	for i := range result {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		result[i] = charset[num.Int64()]
+	}
+	return string(result), nil
+}
@@ -0,0 +1,200 @@
+package app
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type ProductReviewController struct {
+	DB *gorm.DB
+}
+
+func NewProductReviewController(db *gorm.DB) *ProductReviewController {
+	return &ProductReviewController{DB: db}
+}
+
+type CreateReviewRequest struct {
+	OrderItemID uint   `form:"orderItemId" binding:"required"`
+	Rating      int    `form:"rating" binding:"required"`
+	Comment     string `form:"comment"`
+}
+
+// CreateReview handles POST /reviews
+// Foto opsional dikirim lewat field "photos" (multipart).
+func (ctrl *ProductReviewController) CreateReview(c *gin.Context) {
+	photos := c.GetStringSlice("fileNames")
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RemoveUploads(ctrl.DB, photos...)
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var req CreateReviewRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.RemoveUploads(ctrl.DB, photos...)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request: " + err.Error()})
+		return
+	}
+
+	var review *models.ProductReview
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		review, err = utils.CreateReview(tx, userID.(uint), utils.ReviewInput{
+			OrderItemID: req.OrderItemID,
+			Rating:      req.Rating,
+			Comment:     req.Comment,
+			Photos:      photos,
+		})
+		return err
+	})
+	if err != nil {
+		utils.RemoveUploads(ctrl.DB, photos...)
+		switch {
+		case errors.Is(err, utils.ErrInvalidRating):
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		case errors.Is(err, utils.ErrReviewNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"message": "Only delivered order items can be reviewed"})
+		case errors.Is(err, utils.ErrReviewAlreadyExists):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create review"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Review submitted and waiting for moderation",
+		"data":    review,
+	})
+}
+
+// GetReviewableItems handles GET /reviews/pending
+// Item dari pesanan delivered yang belum diulas user.
+func (ctrl *ProductReviewController) GetReviewableItems(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	items, err := utils.ReviewableItems(ctrl.DB, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch reviewable items"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reviewable items retrieved successfully",
+		"data":    items,
+	})
+}
+
+// GetMyReviews handles GET /reviews/mine
+func (ctrl *ProductReviewController) GetMyReviews(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var reviews []models.ProductReview
+	if err := ctrl.DB.Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Product").
+		Preload("ProductItem").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch reviews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reviews retrieved successfully",
+		"data":    reviews,
+	})
+}
+
+// GetProductReviews handles GET /reviews/product/:productId
+// Hanya ulasan yang sudah disetujui admin yang ditampilkan.
+func (ctrl *ProductReviewController) GetProductReviews(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid product ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+
+	var product models.Product
+	if err := ctrl.DB.Select("id", "rating_average", "rating_count").First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Product not found"})
+		return
+	}
+
+	baseQuery := ctrl.DB.Model(&models.ProductReview{}).
+		Where("product_id = ? AND status = ?", product.ID, models.ReviewApproved)
+
+	var distribution []struct {
+		Rating int   `json:"rating"`
+		Count  int64 `json:"count"`
+	}
+	if err := baseQuery.Session(&gorm.Session{}).
+		Select("rating, COUNT(*) AS count").
+		Group("rating").
+		Order("rating DESC").
+		Scan(&distribution).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch rating summary"})
+		return
+	}
+
+	var reviews []models.ProductReview
+	if err := baseQuery.Session(&gorm.Session{}).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("ProductItem").
+		Order("created_at DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch reviews"})
+		return
+	}
+	if err := utils.FillReviewerNames(ctrl.DB, reviews); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch reviewers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product reviews retrieved successfully",
+		"data": gin.H{
+			"data": reviews,
+			"summary": gin.H{
+				"ratingAverage": product.RatingAverage,
+				"ratingCount":   product.RatingCount,
+				"distribution":  distribution,
+			},
+			"meta": gin.H{
+				"total":      product.RatingCount,
+				"page":       page,
+				"perPage":    perPage,
+				"totalPages": int(math.Ceil(float64(product.RatingCount) / float64(perPage))),
+			},
+		},
+	})
+}
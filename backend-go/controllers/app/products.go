@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"math"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +13,7 @@ import (
 	"gorm.io/gorm"
 
 	"backend-go/models"
+	"backend-go/utils"
 )
 
 type ProductController struct {
@@ -67,7 +67,17 @@ func (ctrl *ProductController) GetProductsApp(c *gin.Context) {
 	// Build base query
 	baseQuery := ctrl.DB.Model(&models.Product{})
 	if category != "" && category != "All" {
-		baseQuery = baseQuery.Where("kategori LIKE ?", "%"+category+"%")
+		categoryIDs, err := utils.CategoryFilterIDs(ctrl.DB, category)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error fetching category",
+				"error":   err.Error(),
+			})
+			return
+		}
+		// Filter memakai kategori beserta sub kategorinya, bukan pencocokan teks
+		baseQuery = baseQuery.Where("category_id IN ?", categoryIDs)
 	}
 
 	// Get total count (without preload)
@@ -83,10 +93,10 @@ func (ctrl *ProductController) GetProductsApp(c *gin.Context) {
 
 	// Get products with preload
 	var products []models.Product
-	query := baseQuery.Preload("ProductItems") // Preload relasi disini
+	query := baseQuery.Preload("ProductItems").Preload("Images", utils.OrderProductImages) // Preload relasi disini
 	if err := query.Offset(offset).
 		Limit(perPage).
-		Order("name_produk ASC").
+		Order(utils.ProductSortOrder(c.Query("sort"))).
 		Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -96,6 +106,10 @@ func (ctrl *ProductController) GetProductsApp(c *gin.Context) {
 		return
 	}
 
+	if !ctrl.attachFlashSales(c, products) {
+		return
+	}
+
 	// Calculate total pages
 	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
 
@@ -131,21 +145,35 @@ func (ctrl *ProductController) SearchProductsApp(c *gin.Context) {
 
 	offset := (page - 1) * perPage
 
-	// Build query
-	dbQuery := ctrl.DB.Model(&models.Product{})
+	// Add category filter
+	var categoryIDs []uint
+	if category != "" && category != "All" {
+		var err error
+		categoryIDs, err = utils.CategoryFilterIDs(ctrl.DB, category)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error fetching category",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if categoryIDs == nil {
+			categoryIDs = []uint{}
+		}
+	}
 
-	// Add search conditions if query is provided
-	if queryStr != "" && strings.TrimSpace(queryStr) != "" {
-		searchTerm := "%" + queryStr + "%"
-		dbQuery = dbQuery.Where(
-			"name_produk LIKE ? OR deskripsi LIKE ?",
-			searchTerm, searchTerm,
-		)
+	// Dengan kata kunci, gunakan full-text search yang diurutkan berdasarkan relevansi
+	if strings.TrimSpace(queryStr) != "" {
+		ctrl.searchProducts(c, queryStr, categoryIDs, page, perPage, c.Query("sort") == "rating")
+		return
 	}
 
-	// Add category filter
-	if category != "" && category != "All" {
-		dbQuery = dbQuery.Where("kategori = ?", category)
+	// Build query
+	dbQuery := ctrl.DB.Model(&models.Product{})
+	if categoryIDs != nil {
+		// Filter memakai kategori beserta sub kategorinya, bukan pencocokan teks
+		dbQuery = dbQuery.Where("category_id IN ?", categoryIDs)
 	}
 
 	// Get total count (tanpa preload untuk akurasi count)
@@ -160,13 +188,13 @@ func (ctrl *ProductController) SearchProductsApp(c *gin.Context) {
 	}
 
 	// Tambahkan preload untuk relasi ProductItems
-	dbQuery = dbQuery.Preload("ProductItems")
+	dbQuery = dbQuery.Preload("ProductItems").Preload("Images", utils.OrderProductImages)
 
 	// Get products with relations
 	var products []models.Product
 	if err := dbQuery.Offset(offset).
 		Limit(perPage).
-		Order("name_produk ASC").
+		Order(utils.ProductSortOrder(c.Query("sort"))).
 		Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -176,6 +204,10 @@ func (ctrl *ProductController) SearchProductsApp(c *gin.Context) {
 		return
 	}
 
+	if !ctrl.attachFlashSales(c, products) {
+		return
+	}
+
 	// Calculate total pages
 	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
 
@@ -193,13 +225,107 @@ func (ctrl *ProductController) SearchProductsApp(c *gin.Context) {
 	})
 }
 
+// attachFlashSales mengisi harga flash sale yang sedang berjalan pada varian produk.
+// Mengembalikan false jika gagal dan response error sudah dikirim.
+func (ctrl *ProductController) attachFlashSales(c *gin.Context, products []models.Product) bool {
+	userID, _ := c.Get("userID")
+	uid, _ := userID.(uint)
+	if err := utils.AttachFlashSales(ctrl.DB, products, uid); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching flash sales",
+			"error":   err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// searchProducts menjalankan pencarian full-text dan memuat produk sesuai urutan relevansi
+func (ctrl *ProductController) searchProducts(c *gin.Context, query string, categoryIDs []uint, page, perPage int, sortByRating bool) {
+	result, err := utils.SearchProducts(ctrl.DB, utils.ProductSearchParams{
+		Query:        query,
+		CategoryIDs:  categoryIDs,
+		Offset:       (page - 1) * perPage,
+		Limit:        perPage,
+		SortByRating: sortByRating,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error searching products",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Urutan produk mengikuti relevansi dari hasil pencarian
+	products, err := utils.LoadProductsInOrder(ctrl.DB, result.ProductIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching products",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !ctrl.attachFlashSales(c, products) {
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(result.Total) / float64(perPage)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"data": products,
+			"meta": gin.H{
+				"total":      result.Total,
+				"page":       page,
+				"perPage":    perPage,
+				"totalPages": totalPages,
+			},
+			"facets": result.Facets,
+			"terms":  result.Terms,
+		},
+	})
+}
+
+// AutocompleteProducts handles GET /products/app/autocomplete
+func (ctrl *ProductController) AutocompleteProducts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
+	if limit < 1 || limit > 20 {
+		limit = 8
+	}
+
+	suggestions, err := utils.AutocompleteProducts(ctrl.DB, c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching suggestions",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    suggestions,
+	})
+}
+
 // GetProductById handles GET /products/:id
 func (ctrl *ProductController) GetProductById(c *gin.Context) {
 	id := c.Param("id")
 
 	var product models.Product
 	// Preload ProductItems untuk mendapatkan data relasi
-	if err := ctrl.DB.Preload("ProductItems").First(&product, id).Error; err != nil {
+	if err := ctrl.DB.Preload("ProductItems").
+		Preload("ProductItems.Images", utils.OrderProductImages).
+		Preload("Images", utils.OrderProductImages).
+		Preload("Category").
+		First(&product, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
 			"message": "Product not found",
@@ -207,6 +333,12 @@ func (ctrl *ProductController) GetProductById(c *gin.Context) {
 		return
 	}
 
+	products := []models.Product{product}
+	if !ctrl.attachFlashSales(c, products) {
+		return
+	}
+	product = products[0]
+
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": product})
 }
 
@@ -224,8 +356,16 @@ func (ctrl *ProductController) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	// Delete product
-	if err := ctrl.DB.Delete(&product).Error; err != nil {
+	// Delete product beserta galerinya
+	var filenames []string
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if filenames, err = utils.DeleteProductGallery(tx, &product); err != nil {
+			return err
+		}
+		return tx.Delete(&product).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Error deleting product",
@@ -234,15 +374,8 @@ func (ctrl *ProductController) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	// Delete image if exists
-	if product.Image != "" {
-		imagePath := "./uploads/" + product.Image
-		if _, err := os.Stat(imagePath); err == nil {
-			if err := os.Remove(imagePath); err != nil {
-				fmt.Printf("Failed to delete image: %v\n", err)
-			}
-		}
-	}
+	// Hapus file gambar yang sudah tidak dipakai
+	utils.RemoveUploads(ctrl.DB, filenames...)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
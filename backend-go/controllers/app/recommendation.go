@@ -0,0 +1,96 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type RecommendationController struct {
+	DB *gorm.DB
+}
+
+func NewRecommendationController(db *gorm.DB) *RecommendationController {
+	return &RecommendationController{DB: db}
+}
+
+// GetRelatedProducts handles GET /recommendations/related/:productId
+// Produk yang sering dibeli bersama, untuk halaman detail produk.
+func (ctrl *RecommendationController) GetRelatedProducts(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid product ID"})
+		return
+	}
+
+	ids, err := utils.RelatedProductIDs(ctrl.DB, uint(productID), recommendationLimit(c))
+	ctrl.respondProducts(c, ids, err)
+}
+
+// GetCartRecommendations handles GET /recommendations/cart
+// "Mungkin kamu juga butuh" berdasarkan isi keranjang aktif user.
+func (ctrl *RecommendationController) GetCartRecommendations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	ids, err := utils.CartRecommendationIDs(ctrl.DB, userID.(uint), recommendationLimit(c))
+	ctrl.respondProducts(c, ids, err)
+}
+
+// GetBuyAgain handles GET /recommendations/buy-again
+// Produk yang pernah dibeli atau difavoritkan user, untuk halaman utama.
+func (ctrl *RecommendationController) GetBuyAgain(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	ids, err := utils.BuyAgainProductIDs(ctrl.DB, userID.(uint), recommendationLimit(c))
+	ctrl.respondProducts(c, ids, err)
+}
+
+// respondProducts memuat produk sesuai urutan rekomendasi beserta harga flash sale yang berjalan
+func (ctrl *RecommendationController) respondProducts(c *gin.Context, ids []uint, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching recommendations",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var products []models.Product
+	if products, err = utils.LoadProductsInOrder(ctrl.DB, ids); err == nil {
+		userID, _ := c.Get("userID")
+		uid, _ := userID.(uint)
+		err = utils.AttachFlashSales(ctrl.DB, products, uid)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching products",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": products})
+}
+
+func recommendationLimit(c *gin.Context) int {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > utils.MaxRelatedProducts {
+		limit = 10
+	}
+	return limit
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/utils"
+)
+
+type ReferralController struct {
+	DB *gorm.DB
+}
+
+func NewReferralController(db *gorm.DB) *ReferralController {
+	return &ReferralController{DB: db}
+}
+
+// GetReferralTree handles GET /referrals-app/tree?depth=3&limit=10
+// Setiap level berisi halaman pertama; halaman berikutnya diambil lewat endpoint level.
+func (ctrl *ReferralController) GetReferralTree(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	depth, _ := strconv.Atoi(c.DefaultQuery("depth", strconv.Itoa(utils.DefaultReferralTreeDepth)))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	levels, err := utils.GetReferralTree(ctrl.DB, userID.(uint), depth, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// Aplikasi hanya menerima nama yang disamarkan, tanpa email dan nilai belanja downline
+	masked := make([]utils.ReferralTreeMemberLevel, len(levels))
+	for i := range levels {
+		masked[i] = utils.MaskReferralTreeLevel(&levels[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Referral tree retrieved successfully",
+		"depth":   utils.ClampReferralTreeDepth(depth),
+		"levels":  masked,
+	})
+}
+
+// GetReferralTreeLevel handles GET /referrals-app/tree/levels/:level?page=0&limit=10
+func (ctrl *ReferralController) GetReferralTreeLevel(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil || level < 1 || level > utils.MaxReferralTreeDepth {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Level must be between 1 and " + strconv.Itoa(utils.MaxReferralTreeDepth)})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	result, err := utils.GetReferralTreeLevel(ctrl.DB, userID.(uint), level, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Referral level retrieved successfully",
+		"data":    utils.MaskReferralTreeLevel(result),
+	})
+}
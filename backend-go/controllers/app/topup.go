@@ -163,6 +163,7 @@ func (ctrl *TopUpPoinController) PostTopUp(c *gin.Context) {
 		UserID        uint   `json:"userId" binding:"required"`
 		PurchaseID    string `json:"purchaseId" binding:"required"`
 		InvoiceNumber string `json:"invoiceNumber" binding:"required"`
+		PromoID       *uint  `json:"promoId"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -239,6 +240,23 @@ func (ctrl *TopUpPoinController) PostTopUp(c *gin.Context) {
 		return
 	}
 
+	// Pembayaran sudah terjadi di store, jadi promo yang tidak valid hanya membatalkan bonusnya, bukan top up-nya
+	var promo *models.PoinPromo
+	promoMessage := ""
+	if input.PromoID != nil {
+		var err error
+		promo, err = push.ClaimPoinPromo(tx, *input.PromoID, input.UserID, input.Points)
+		if err != nil {
+			if !errors.Is(err, push.ErrPromoNotAvailable) && !errors.Is(err, push.ErrPromoLimitReached) {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+				return
+			}
+			promo = nil
+			promoMessage = err.Error()
+		}
+	}
+
 	// Create new top-up
 	topUpData := models.TopUpPoin{
 		TopupID:       generateUniqueTopupId(),
@@ -250,6 +268,10 @@ func (ctrl *TopUpPoinController) PostTopUp(c *gin.Context) {
 		PaymentMethod: input.PaymentMethod,
 		Status:        "success",
 	}
+	if promo != nil {
+		topUpData.PromoID = &promo.ID
+		topUpData.BonusPoints = promo.BonusPoints
+	}
 
 	if err := tx.Create(&topUpData).Error; err != nil {
 		tx.Rollback()
@@ -257,27 +279,18 @@ func (ctrl *TopUpPoinController) PostTopUp(c *gin.Context) {
 		return
 	}
 
-	// Update user points
-	var userPoints models.UserPoints
-	if err := tx.Where("user_id = ?", input.UserID).First(&userPoints).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			userPoints = models.UserPoints{
-				UserID: input.UserID,
-				Points: input.Points,
-			}
-			if err := tx.Create(&userPoints).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-				return
-			}
-		} else {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-			return
-		}
-	} else {
-		userPoints.Points += input.Points
-		if err := tx.Save(&userPoints).Error; err != nil {
+	// Update user points (dicatat sebagai lot agar bisa kedaluwarsa)
+	userPoints, err := push.CreditPoints(tx, input.UserID, input.Points, models.PointSourceTopUp, topUpData.TopupID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// Bonus promo dicatat sebagai lot terpisah
+	if topUpData.BonusPoints > 0 {
+		userPoints, err = push.CreditPoints(tx, input.UserID, topUpData.BonusPoints, models.PointSourcePromoBonus, topUpData.TopupID)
+		if err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 			return
@@ -303,10 +316,12 @@ func (ctrl *TopUpPoinController) PostTopUp(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"status":     "success",
-		"message":    "Top Up successful",
-		"topUpData":  topUpData,
-		"userPoints": userPoints.Points,
+		"status":       "success",
+		"message":      "Top Up successful",
+		"topUpData":    topUpData,
+		"userPoints":   userPoints.Points,
+		"bonusPoints":  topUpData.BonusPoints,
+		"promoMessage": promoMessage,
 	})
 }
 
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type UserPointsController struct {
+	DB *gorm.DB
+}
+
+func NewUserPointsController(db *gorm.DB) *UserPointsController {
+	return &UserPointsController{DB: db}
+}
+
+// GetExpiringPoints handles GET /points-app/expiring
+func (ctrl *UserPointsController) GetExpiringPoints(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var userPoints models.UserPoints
+	if err := ctrl.DB.Where("user_id = ?", userID).First(&userPoints).Error; err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	var lots []models.PointLot
+	if err := ctrl.DB.
+		Where("user_id = ? AND remaining > 0 AND expired_at IS NULL", userID).
+		Order("expires_at ASC NULLS LAST, credited_at ASC").
+		Find(&lots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	type LotResponse struct {
+		ID         uint       `json:"id"`
+		Source     string     `json:"source"`
+		Points     int        `json:"points"`
+		Remaining  int        `json:"remaining"`
+		CreditedAt time.Time  `json:"creditedAt"`
+		ExpiresAt  *time.Time `json:"expiresAt"`
+		DaysLeft   *int       `json:"daysLeft"`
+	}
+
+	_, reminderDays := utils.GetPointExpiryConfig(ctrl.DB)
+	window := 0
+	for _, d := range reminderDays {
+		if d > window {
+			window = d
+		}
+	}
+
+	now := time.Now()
+	tracked := 0
+	expiringSoon := 0
+	response := make([]LotResponse, len(lots))
+	for i, lot := range lots {
+		tracked += lot.Remaining
+
+		var daysLeft *int
+		if lot.ExpiresAt != nil {
+			d := int(lot.ExpiresAt.Sub(now).Hours()/24) + 1
+			daysLeft = &d
+			if d <= window {
+				expiringSoon += lot.Remaining
+			}
+		}
+
+		response[i] = LotResponse{
+			ID:         lot.ID,
+			Source:     string(lot.Source),
+			Points:     lot.Points,
+			Remaining:  lot.Remaining,
+			CreditedAt: lot.CreditedAt,
+			ExpiresAt:  lot.ExpiresAt,
+			DaysLeft:   daysLeft,
+		}
+	}
+
+	// Saldo lama yang belum tercatat sebagai lot tidak pernah kedaluwarsa
+	nonExpiring := userPoints.Points - tracked
+	if nonExpiring < 0 {
+		nonExpiring = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"points":       userPoints.Points,
+		"nonExpiring":  nonExpiring,
+		"expiringSoon": expiringSoon,
+		"lots":         response,
+	})
+}
+
+// GetPointHistory handles GET /points-app/history
+func (ctrl *UserPointsController) GetPointHistory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+	offset := page * limit
+
+	query := ctrl.DB.Model(&models.PointHistory{}).Where("user_id = ?", userID)
+	if source := c.Query("source"); source != "" {
+		query = query.Where("source = ?", source)
+	}
+
+	var totalRows int64
+	query.Count(&totalRows)
+	totalPage := (int(totalRows) + limit - 1) / limit
+
+	var histories []models.PointHistory
+	if err := query.Order("created_at DESC, id DESC").
+		Offset(offset).Limit(limit).
+		Find(&histories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      histories,
+		"page":      page,
+		"limit":     limit,
+		"totalPage": totalPage,
+		"totalRows": totalRows,
+	})
+}
+
+// GetStatement handles GET /points-app/statement?month=YYYY-MM&format=pdf|csv|json
+func (ctrl *UserPointsController) GetStatement(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	month, err := utils.ParseStatementMonth(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	statement, err := utils.BuildPointStatement(ctrl.DB, userID.(uint), month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "pdf")
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{"data": statement})
+		return
+	}
+
+	data, contentType, err := utils.RenderPointStatement(statement, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+utils.StatementFileName(statement, format))
+	c.Data(http.StatusOK, contentType, data)
+}
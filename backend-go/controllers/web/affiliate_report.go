@@ -0,0 +1,78 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/utils"
+)
+
+type AffiliateReportController struct {
+	DB *gorm.DB
+}
+
+func NewAffiliateReportController(db *gorm.DB) *AffiliateReportController {
+	return &AffiliateReportController{DB: db}
+}
+
+// GetLeaderboard handles GET /affiliate-reports/leaderboard?month=YYYY-MM&limit=50&refresh=true
+func (ctrl *AffiliateReportController) GetLeaderboard(c *gin.Context) {
+	month, err := utils.ParseLeaderboardMonth(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	refresh := c.Query("refresh") == "true"
+
+	leaderboard, err := utils.GetAffiliateLeaderboard(ctrl.DB, month, refresh)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to build leaderboard: " + err.Error(),
+		})
+		return
+	}
+
+	entries := leaderboard.Entries
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"month":        leaderboard.Month.Format("2006-01"),
+			"generatedAt":  leaderboard.GeneratedAt,
+			"totalEntries": len(leaderboard.Entries),
+			"entries":      entries,
+		},
+	})
+}
+
+// GetPerformanceReport handles GET /affiliate-reports/performance?month=YYYY-MM
+func (ctrl *AffiliateReportController) GetPerformanceReport(c *gin.Context) {
+	month, err := utils.ParseLeaderboardMonth(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	report, err := utils.BuildReferrerPerformanceReport(ctrl.DB, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to build performance report: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
@@ -0,0 +1,62 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/utils"
+)
+
+type AffiliateSummaryController struct {
+	DB *gorm.DB
+}
+
+func NewAffiliateSummaryController(db *gorm.DB) *AffiliateSummaryController {
+	return &AffiliateSummaryController{DB: db}
+}
+
+type RebuildAffiliateSummaryRequest struct {
+	Month string `json:"month"` // YYYY-MM, kosong = bulan ini
+}
+
+// RebuildAffiliateSummaries handles POST /affiliate-summaries/rebuild
+func (ctrl *AffiliateSummaryController) RebuildAffiliateSummaries(c *gin.Context) {
+	var req RebuildAffiliateSummaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	month := utils.SummaryMonth(time.Now())
+	if req.Month != "" {
+		parsed, err := utils.ParseStatementMonth(req.Month)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		month = parsed
+	}
+
+	rows, err := utils.RebuildAffiliateSummaries(ctrl.DB, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to rebuild affiliate summaries: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Affiliate summaries rebuilt successfully",
+		"data": gin.H{
+			"month": month.Format("2006-01"),
+			"rows":  rows,
+		},
+	})
+}
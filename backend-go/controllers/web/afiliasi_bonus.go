@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"backend-go/models"
+	"backend-go/utils"
 )
 
 type AfiliasiBonusController struct {
@@ -283,12 +284,24 @@ func (ctrl *AfiliasiBonusController) TransferBonus(c *gin.Context) {
 		return
 	}
 
+	// Bonus yang sudah masuk payout batch dibayar lewat batch tersebut
+	if bonus.PayoutBatchID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bonus is part of a payout batch"})
+		return
+	}
+
 	// Update status menjadi transferred dan set transferred_at
 	now := time.Now()
 	bonus.Status = models.BonusTransferred
 	bonus.TransferredAt = &now
 
-	if err := ctrl.DB.Save(&bonus).Error; err != nil {
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&bonus).Error; err != nil {
+			return err
+		}
+		return utils.RefreshAffiliateSummariesForBonuses(tx, bonus)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,307 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type CategoryController struct {
+	DB *gorm.DB
+}
+
+func NewCategoryController(db *gorm.DB) *CategoryController {
+	return &CategoryController{DB: db}
+}
+
+type CategoryRequest struct {
+	Name         string `form:"name" json:"name"`
+	ParentID     *uint  `form:"parentId" json:"parentId"` // 0 = kategori utama
+	DisplayOrder *int   `form:"displayOrder" json:"displayOrder"`
+	IsActive     *bool  `form:"isActive" json:"isActive"`
+}
+
+// GetCategories handles GET /categories
+// Admin melihat seluruh tree termasuk kategori nonaktif.
+func (ctrl *CategoryController) GetCategories(c *gin.Context) {
+	tree, err := utils.BuildCategoryTree(ctrl.DB, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching categories",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": tree})
+}
+
+// GetCategoryById handles GET /categories/:id
+func (ctrl *CategoryController) GetCategoryById(c *gin.Context) {
+	var category models.Category
+	if err := ctrl.DB.Preload("Parent").
+		Preload("Children", func(db *gorm.DB) *gorm.DB {
+			return db.Order("display_order ASC, name ASC")
+		}).
+		First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Category not found"})
+		return
+	}
+
+	var productCount int64
+	ctrl.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&productCount)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"data":         category,
+		"productCount": productCount,
+	})
+}
+
+// CreateCategory handles POST /categories
+func (ctrl *CategoryController) CreateCategory(c *gin.Context) {
+	iconFilename := c.GetString("fileName")
+
+	var req CategoryRequest
+	if err := c.ShouldBind(&req); err != nil {
+		removeUpload(iconFilename)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) < 2 || len(req.Name) > 100 {
+		removeUpload(iconFilename)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Name must be between 2 and 100 characters"})
+		return
+	}
+
+	category := models.Category{
+		Name:     req.Name,
+		Icon:     iconFilename,
+		IsActive: true,
+	}
+	if req.ParentID != nil && *req.ParentID != 0 {
+		category.ParentID = req.ParentID
+	}
+	if req.DisplayOrder != nil {
+		category.DisplayOrder = *req.DisplayOrder
+	}
+	if req.IsActive != nil {
+		category.IsActive = *req.IsActive
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.ValidateCategoryParent(tx, 0, category.ParentID); err != nil {
+			return err
+		}
+
+		slug, err := utils.UniqueCategorySlug(tx, category.Name, 0)
+		if err != nil {
+			return err
+		}
+		category.Slug = slug
+
+		// GORM melewati nilai false untuk kolom dengan default, jadi status disimpan eksplisit
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		return tx.Model(&category).Update("is_active", category.IsActive).Error
+	})
+	if err != nil {
+		removeUpload(iconFilename)
+		respondCategoryError(c, err, "Error creating category")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Category created successfully",
+		"data":    category,
+	})
+}
+
+// UpdateCategory handles PATCH /categories/:id
+func (ctrl *CategoryController) UpdateCategory(c *gin.Context) {
+	iconFilename := c.GetString("fileName")
+
+	var req CategoryRequest
+	if err := c.ShouldBind(&req); err != nil {
+		removeUpload(iconFilename)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var category models.Category
+	if err := ctrl.DB.First(&category, c.Param("id")).Error; err != nil {
+		removeUpload(iconFilename)
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Category not found"})
+		return
+	}
+
+	oldIcon := category.Icon
+	renamed := false
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name != "" && req.Name != category.Name {
+		if len(req.Name) < 2 || len(req.Name) > 100 {
+			removeUpload(iconFilename)
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Name must be between 2 and 100 characters"})
+			return
+		}
+		category.Name = req.Name
+		renamed = true
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			category.ParentID = req.ParentID
+		}
+	}
+	if req.DisplayOrder != nil {
+		category.DisplayOrder = *req.DisplayOrder
+	}
+	if req.IsActive != nil {
+		category.IsActive = *req.IsActive
+	}
+	if iconFilename != "" {
+		category.Icon = iconFilename
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.ValidateCategoryParent(tx, category.ID, category.ParentID); err != nil {
+			return err
+		}
+
+		if renamed {
+			slug, err := utils.UniqueCategorySlug(tx, category.Name, category.ID)
+			if err != nil {
+				return err
+			}
+			category.Slug = slug
+		}
+
+		if err := tx.Save(&category).Error; err != nil {
+			return err
+		}
+
+		// Nama kategori di produk ikut diperbarui agar kolom Kategori lama tetap konsisten
+		if renamed {
+			return tx.Model(&models.Product{}).
+				Where("category_id = ?", category.ID).
+				Update("kategori", category.Name).Error
+		}
+		return nil
+	})
+	if err != nil {
+		removeUpload(iconFilename)
+		respondCategoryError(c, err, "Error updating category")
+		return
+	}
+
+	if iconFilename != "" && oldIcon != "" {
+		removeUpload(oldIcon)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Category updated successfully",
+		"data":    category,
+	})
+}
+
+type ReorderCategoriesRequest struct {
+	Items []struct {
+		ID           uint `json:"id" binding:"required"`
+		DisplayOrder int  `json:"displayOrder"`
+	} `json:"items" binding:"required,min=1"`
+}
+
+// ReorderCategories handles POST /categories/reorder
+func (ctrl *CategoryController) ReorderCategories(c *gin.Context) {
+	var req ReorderCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		for _, item := range req.Items {
+			if err := tx.Model(&models.Category{}).Where("id = ?", item.ID).
+				Update("display_order", item.DisplayOrder).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error reordering categories",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Categories reordered successfully"})
+}
+
+// DeleteCategory handles DELETE /categories/:id
+// Kategori yang masih punya sub kategori atau produk tidak bisa dihapus.
+func (ctrl *CategoryController) DeleteCategory(c *gin.Context) {
+	var category models.Category
+	if err := ctrl.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Category not found"})
+		return
+	}
+
+	var children, products int64
+	ctrl.DB.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children)
+	ctrl.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&products)
+	if children > 0 || products > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "Category still has sub categories or products",
+		})
+		return
+	}
+
+	if err := ctrl.DB.Delete(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error deleting category",
+			"error":   err.Error(),
+		})
+		return
+	}
+	removeUpload(category.Icon)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Category deleted successfully"})
+}
+
+func respondCategoryError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, utils.ErrCategoryNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Parent category not found"})
+	case errors.Is(err, utils.ErrCategoryCycle):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": message, "error": err.Error()})
+	}
+}
+
+// removeUpload menghapus file upload yang tidak jadi dipakai
+func removeUpload(filename string) {
+	if filename != "" {
+		os.Remove("./uploads/" + filename)
+		utils.RemoveImageRenditions(filename)
+	}
+}
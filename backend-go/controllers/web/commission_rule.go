@@ -0,0 +1,220 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type CommissionRuleController struct {
+	DB *gorm.DB
+}
+
+func NewCommissionRuleController(db *gorm.DB) *CommissionRuleController {
+	return &CommissionRuleController{DB: db}
+}
+
+// GetCommissionRules handles GET /commission-rules
+func (ctrl *CommissionRuleController) GetCommissionRules(c *gin.Context) {
+	var rules []models.CommissionRule
+	if err := ctrl.DB.Preload("Levels", func(db *gorm.DB) *gorm.DB {
+		return db.Order("level ASC")
+	}).Order("version DESC").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Database error: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// GetActiveCommissionRule handles GET /commission-rules/active
+func (ctrl *CommissionRuleController) GetActiveCommissionRule(c *gin.Context) {
+	rule, err := utils.GetActiveCommissionRule(ctrl.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Database error: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"isDefault": rule.ID == 0,
+		"data":      rule,
+	})
+}
+
+type CommissionRuleRequest struct {
+	Levels           []float64                 `json:"levels" binding:"required,min=1"` // Persentase per level, index 0 = level 1
+	BaseType         models.CommissionBaseType `json:"baseType" binding:"required,oneof=fixed order"`
+	BaseAmount       float64                   `json:"baseAmount"`
+	BasePercentage   float64                   `json:"basePercentage"`
+	Threshold        float64                   `json:"threshold"`
+	ExpiryMonths     int                       `json:"expiryMonths"`
+	ExpiryDays       int                       `json:"expiryDays"`
+	MaxBonusPerOrder float64                   `json:"maxBonusPerOrder"`
+	LifetimeCap      float64                   `json:"lifetimeCap"`
+	Note             string                    `json:"note"`
+	Activate         bool                      `json:"activate"`
+}
+
+// CreateCommissionRule handles POST /commission-rules
+// Setiap perubahan aturan disimpan sebagai versi baru.
+func (ctrl *CommissionRuleController) CreateCommissionRule(c *gin.Context) {
+	var req CommissionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	if message := validateCommissionRule(&req); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	rule := models.CommissionRule{
+		BaseType:         req.BaseType,
+		BaseAmount:       req.BaseAmount,
+		BasePercentage:   req.BasePercentage,
+		Threshold:        req.Threshold,
+		ExpiryMonths:     req.ExpiryMonths,
+		ExpiryDays:       req.ExpiryDays,
+		MaxBonusPerOrder: req.MaxBonusPerOrder,
+		LifetimeCap:      req.LifetimeCap,
+		Note:             req.Note,
+	}
+	for i, percentage := range req.Levels {
+		rule.Levels = append(rule.Levels, models.CommissionRuleLevel{Level: i + 1, Percentage: percentage})
+	}
+	if userID, ok := c.Get("userId"); ok {
+		id := userID.(uint)
+		rule.CreatedBy = &id
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var lastVersion int
+		if err := tx.Model(&models.CommissionRule{}).Select("COALESCE(MAX(version), 0)").Scan(&lastVersion).Error; err != nil {
+			return err
+		}
+		rule.Version = lastVersion + 1
+
+		if err := tx.Create(&rule).Error; err != nil {
+			return err
+		}
+
+		if req.Activate {
+			return activateCommissionRule(tx, &rule)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to create commission rule: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Commission rule created successfully",
+		"data":    rule,
+	})
+}
+
+// ActivateCommissionRule handles POST /commission-rules/:id/activate
+func (ctrl *CommissionRuleController) ActivateCommissionRule(c *gin.Context) {
+	id := c.Param("id")
+
+	var rule models.CommissionRule
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&rule, id).Error; err != nil {
+			return err
+		}
+		return activateCommissionRule(tx, &rule)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Commission rule not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to activate commission rule: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Commission rule version activated successfully",
+		"data":    rule,
+	})
+}
+
+// activateCommissionRule menonaktifkan versi lain sehingga hanya satu versi yang aktif
+func activateCommissionRule(tx *gorm.DB, rule *models.CommissionRule) error {
+	if err := tx.Model(&models.CommissionRule{}).
+		Where("is_active = ? AND id <> ?", true, rule.ID).
+		Update("is_active", false).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	rule.IsActive = true
+	rule.ActivatedAt = &now
+	return tx.Model(rule).Updates(map[string]interface{}{
+		"is_active":    true,
+		"activated_at": now,
+	}).Error
+}
+
+func validateCommissionRule(req *CommissionRuleRequest) string {
+	for _, percentage := range req.Levels {
+		if percentage < 0 || percentage > 100 {
+			return "Level percentage must be between 0 and 100"
+		}
+	}
+
+	switch req.BaseType {
+	case models.CommissionBaseFixed:
+		if req.BaseAmount <= 0 {
+			return "Base amount must be a positive number"
+		}
+	case models.CommissionBaseOrder:
+		if req.BasePercentage <= 0 || req.BasePercentage > 100 {
+			return "Base percentage must be between 0 and 100"
+		}
+	}
+
+	if req.Threshold < 0 || req.MaxBonusPerOrder < 0 || req.LifetimeCap < 0 {
+		return "Threshold and caps cannot be negative"
+	}
+	if req.ExpiryMonths < 0 || req.ExpiryDays < 0 || req.ExpiryMonths+req.ExpiryDays == 0 {
+		return "Expiry must be at least one day"
+	}
+
+	return ""
+}
@@ -0,0 +1,253 @@
+package web
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type FlashSaleController struct {
+	DB *gorm.DB
+}
+
+func NewFlashSaleController(db *gorm.DB) *FlashSaleController {
+	return &FlashSaleController{DB: db}
+}
+
+type FlashSaleRequest struct {
+	Name          string    `json:"name" binding:"required"`
+	ProductItemID uint      `json:"productItemId" binding:"required"`
+	HargaRp       int       `json:"hargaRp" binding:"required"`
+	HargaPoin     int       `json:"hargaPoin"` // 0 = dihitung dari nilai poin yang berlaku
+	Quota         int       `json:"quota" binding:"required"`
+	MaxPerUser    int       `json:"maxPerUser"`
+	StartAt       time.Time `json:"startAt" binding:"required"`
+	EndAt         time.Time `json:"endAt" binding:"required"`
+}
+
+// GetFlashSales handles GET /flash-sales
+func (ctrl *FlashSaleController) GetFlashSales(c *gin.Context) {
+	var sales []models.FlashSale
+	if err := ctrl.DB.Preload("ProductItem.Product").Order("start_at DESC").Find(&sales).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching flash sales",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sales,
+	})
+}
+
+// CreateFlashSale handles POST /flash-sales
+func (ctrl *FlashSaleController) CreateFlashSale(c *gin.Context) {
+	var req FlashSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if status, message := ctrl.validateFlashSale(&req, 0); message != "" {
+		c.JSON(status, gin.H{"success": false, "message": message})
+		return
+	}
+
+	sale := models.FlashSale{
+		Name:          req.Name,
+		ProductItemID: req.ProductItemID,
+		HargaRp:       req.HargaRp,
+		HargaPoin:     req.HargaPoin,
+		Quota:         req.Quota,
+		MaxPerUser:    req.MaxPerUser,
+		StartAt:       req.StartAt,
+		EndAt:         req.EndAt,
+	}
+	if err := ctrl.DB.Create(&sale).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error creating flash sale",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Flash sale yang periodenya sudah berjalan langsung diaktifkan tanpa menunggu scheduler
+	if _, _, err := utils.SyncFlashSales(ctrl.DB); err != nil {
+		log.Println("Error syncing flash sales:", err)
+	}
+	ctrl.DB.First(&sale, sale.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Flash sale created successfully",
+		"data":    sale,
+	})
+}
+
+// UpdateFlashSale handles PATCH /flash-sales/:id
+func (ctrl *FlashSaleController) UpdateFlashSale(c *gin.Context) {
+	id := c.Param("id")
+
+	var sale models.FlashSale
+	if err := ctrl.DB.First(&sale, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Flash sale not found"})
+		return
+	}
+
+	var req FlashSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	// Varian tidak boleh diganti setelah ada pembelian karena kuota terjual milik varian lama
+	if sale.SoldQuantity > 0 && req.ProductItemID != sale.ProductItemID {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Cannot change product item of a flash sale with purchases"})
+		return
+	}
+	if req.Quota < sale.SoldQuantity {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Quota cannot be lower than the quantity already sold"})
+		return
+	}
+
+	if status, message := ctrl.validateFlashSale(&req, sale.ID); message != "" {
+		c.JSON(status, gin.H{"success": false, "message": message})
+		return
+	}
+
+	sale.Name = req.Name
+	sale.ProductItemID = req.ProductItemID
+	sale.HargaRp = req.HargaRp
+	sale.HargaPoin = req.HargaPoin
+	sale.Quota = req.Quota
+	sale.MaxPerUser = req.MaxPerUser
+	sale.StartAt = req.StartAt
+	sale.EndAt = req.EndAt
+
+	if err := ctrl.DB.Save(&sale).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error updating flash sale",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if _, _, err := utils.SyncFlashSales(ctrl.DB); err != nil {
+		log.Println("Error syncing flash sales:", err)
+	}
+	ctrl.DB.First(&sale, sale.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Flash sale updated successfully",
+		"data":    sale,
+	})
+}
+
+// DeleteFlashSale handles DELETE /flash-sales/:id
+func (ctrl *FlashSaleController) DeleteFlashSale(c *gin.Context) {
+	id := c.Param("id")
+
+	var sale models.FlashSale
+	if err := ctrl.DB.First(&sale, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Flash sale not found"})
+		return
+	}
+
+	// Flash sale yang sudah ada pembeliannya hanya diakhiri agar riwayat kuota pesanan tetap ada
+	if sale.SoldQuantity > 0 {
+		updates := map[string]interface{}{"is_active": false}
+		if sale.EndAt.After(time.Now()) {
+			updates["end_at"] = time.Now()
+		}
+		if err := ctrl.DB.Model(&sale).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error ending flash sale",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Flash sale has purchases and was ended instead of deleted",
+		})
+		return
+	}
+
+	if err := ctrl.DB.Delete(&sale).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error deleting flash sale",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Flash sale deleted successfully",
+	})
+}
+
+// validateFlashSale mengembalikan status dan pesan error jika request flash sale tidak valid.
+// HargaPoin yang kosong diisi dari nilai poin yang berlaku.
+func (ctrl *FlashSaleController) validateFlashSale(req *FlashSaleRequest, flashSaleID uint) (int, string) {
+	if !req.EndAt.After(req.StartAt) {
+		return http.StatusBadRequest, "End time must be after start time"
+	}
+	if req.Quota <= 0 {
+		return http.StatusBadRequest, "Quota must be greater than zero"
+	}
+	if req.MaxPerUser < 0 || req.HargaPoin < 0 {
+		return http.StatusBadRequest, "Harga poin and max per user cannot be negative"
+	}
+
+	var item models.ProductItem
+	if err := ctrl.DB.First(&item, req.ProductItemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return http.StatusNotFound, "Product item not found"
+		}
+		return http.StatusInternalServerError, err.Error()
+	}
+	if req.HargaRp <= 0 || req.HargaRp >= item.HargaRp {
+		return http.StatusBadRequest, "Flash sale harga Rp must be positive and lower than the regular price"
+	}
+
+	if req.HargaPoin == 0 {
+		rate, err := utils.GetCurrentPointRate(ctrl.DB)
+		if err != nil {
+			return http.StatusInternalServerError, err.Error()
+		}
+		req.HargaPoin = utils.HargaPoinFor(req.HargaRp, rate.Rate)
+	}
+	if req.HargaPoin <= 0 || req.HargaPoin >= item.HargaPoin {
+		return http.StatusBadRequest, "Flash sale harga poin must be positive and lower than the regular price"
+	}
+
+	// Satu varian hanya boleh punya satu flash sale pada periode yang sama
+	var overlapping int64
+	if err := ctrl.DB.Model(&models.FlashSale{}).
+		Where("product_item_id = ? AND id <> ? AND start_at < ? AND end_at > ?", req.ProductItemID, flashSaleID, req.EndAt, req.StartAt).
+		Count(&overlapping).Error; err != nil {
+		return http.StatusInternalServerError, err.Error()
+	}
+	if overlapping > 0 {
+		return http.StatusBadRequest, "Another flash sale for this product item overlaps the selected period"
+	}
+
+	return 0, ""
+}
@@ -0,0 +1,190 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type InventoryController struct {
+	DB *gorm.DB
+}
+
+func NewInventoryController(db *gorm.DB) *InventoryController {
+	return &InventoryController{DB: db}
+}
+
+type StockAdjustmentRequest struct {
+	ProductItemID uint   `json:"productItemId" binding:"required"`
+	Type          string `json:"type" binding:"required"` // adjustment, receiving atau spoilage
+	Quantity      *int   `json:"quantity"`                // Selisih (adjustment) atau jumlah barang (receiving/spoilage)
+	Stock         *int   `json:"stock"`                   // Alternatif untuk adjustment: stok hasil stock opname
+	Reason        string `json:"reason" binding:"required"`
+}
+
+type ReorderThresholdRequest struct {
+	ReorderThreshold *int `json:"reorderThreshold" binding:"required"`
+}
+
+// GetStockMovements handles GET /inventory/movements?productItemId=&productId=&type=&page=0&limit=20
+func (ctrl *InventoryController) GetStockMovements(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset := page * limit
+
+	query := ctrl.DB.Model(&models.StockMovement{})
+	if productItemID := c.Query("productItemId"); productItemID != "" {
+		query = query.Where("product_item_id = ?", productItemID)
+	}
+	if productID := c.Query("productId"); productID != "" {
+		query = query.Where("product_item_id IN (?)",
+			ctrl.DB.Model(&models.ProductItem{}).Select("id").Where("product_id = ?", productID))
+	}
+	if movementType := c.Query("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+
+	var totalRows int64
+	if err := query.Count(&totalRows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var movements []models.StockMovement
+	if err := query.Preload("ProductItem.Product").Preload("Actor", selectUserSummary).
+		Order("created_at DESC, id DESC").
+		Offset(offset).Limit(limit).
+		Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	totalPage := 0
+	if limit > 0 {
+		totalPage = (int(totalRows) + limit - 1) / limit
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      movements,
+		"page":      page,
+		"limit":     limit,
+		"totalPage": totalPage,
+		"totalRows": totalRows,
+	})
+}
+
+// CreateStockAdjustment handles POST /inventory/adjustments
+// Setiap perubahan stok manual wajib menyertakan alasan.
+func (ctrl *InventoryController) CreateStockAdjustment(c *gin.Context) {
+	var req StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	movementType := models.StockMovementType(req.Type)
+	if req.Quantity == nil && (req.Stock == nil || movementType != models.StockAdjustment) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "quantity is required (or stock for adjustment)"})
+		return
+	}
+
+	actorID := adminActorID(c)
+	var movement *models.StockMovement
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if req.Quantity == nil {
+			movement, err = utils.SetStockLevel(tx, req.ProductItemID, *req.Stock, req.Reason, actorID)
+		} else {
+			movement, err = utils.AdjustStock(tx, req.ProductItemID, movementType, *req.Quantity, req.Reason, actorID)
+		}
+		return err
+	})
+	if err != nil {
+		respondStockError(c, err)
+		return
+	}
+	if movement == nil {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Stock unchanged"})
+		return
+	}
+
+	utils.NotifyLowStock(ctrl.DB, *movement)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Stock adjusted successfully",
+		"data":    movement,
+	})
+}
+
+// GetLowStockItems handles GET /inventory/low-stock
+func (ctrl *InventoryController) GetLowStockItems(c *gin.Context) {
+	var items []models.ProductItem
+	if err := ctrl.DB.Preload("Product").
+		Where("reorder_threshold > 0 AND stok < reorder_threshold").
+		Order("stok ASC, id ASC").
+		Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": items})
+}
+
+// UpdateReorderThreshold handles PATCH /inventory/items/:id/threshold
+func (ctrl *InventoryController) UpdateReorderThreshold(c *gin.Context) {
+	itemID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid product item ID"})
+		return
+	}
+
+	var req ReorderThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	item, err := utils.SetReorderThreshold(ctrl.DB, uint(itemID), *req.ReorderThreshold)
+	if err != nil {
+		respondStockError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Reorder threshold updated successfully",
+		"data":    item,
+	})
+}
+
+// adminActorID mengambil ID admin yang sedang login untuk dicatat sebagai pelaku
+func adminActorID(c *gin.Context) *uint {
+	if userID, ok := c.Get("userId"); ok {
+		id := userID.(uint)
+		return &id
+	}
+	return nil
+}
+
+func respondStockError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrStockItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+	case errors.Is(err, utils.ErrInsufficientStock),
+		errors.Is(err, utils.ErrStockReasonRequired),
+		errors.Is(err, utils.ErrInvalidStockQuantity),
+		errors.Is(err, utils.ErrInvalidStockAdjustment),
+		errors.Is(err, utils.ErrInvalidReorderThreshold):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to update stock: " + err.Error()})
+	}
+}
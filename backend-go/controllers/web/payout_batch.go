@@ -0,0 +1,257 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type PayoutBatchController struct {
+	DB *gorm.DB
+}
+
+func NewPayoutBatchController(db *gorm.DB) *PayoutBatchController {
+	return &PayoutBatchController{DB: db}
+}
+
+type PayoutBatchRequest struct {
+	StartDate string `json:"startDate"` // YYYY-MM-DD, opsional
+	EndDate   string `json:"endDate"`   // YYYY-MM-DD inklusif, opsional
+}
+
+// GetPayoutBatches handles GET /payout-batches
+func (ctrl *PayoutBatchController) GetPayoutBatches(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	status := c.Query("status")
+	offset := page * limit
+
+	query := ctrl.DB.Model(&models.PayoutBatch{})
+	if status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var totalRows int64
+	if err := query.Count(&totalRows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var batches []models.PayoutBatch
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	totalPage := 0
+	if limit > 0 {
+		totalPage = (int(totalRows) + limit - 1) / limit
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      batches,
+		"page":      page,
+		"limit":     limit,
+		"totalPage": totalPage,
+		"totalRows": totalRows,
+	})
+}
+
+// GetPayoutBatch handles GET /payout-batches/:id
+func (ctrl *PayoutBatchController) GetPayoutBatch(c *gin.Context) {
+	batch, ok := ctrl.findBatch(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": batch})
+}
+
+// CreatePayoutBatch handles POST /payout-batches
+// Semua bonus claimed (atau yang diklaim dalam periode) dikunci ke batch draft baru.
+func (ctrl *PayoutBatchController) CreatePayoutBatch(c *gin.Context) {
+	var req PayoutBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var periodStart, periodEnd *time.Time
+	if req.StartDate != "" {
+		start, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid startDate format, use YYYY-MM-DD"})
+			return
+		}
+		periodStart = &start
+	}
+	if req.EndDate != "" {
+		end, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid endDate format, use YYYY-MM-DD"})
+			return
+		}
+		end = end.AddDate(0, 0, 1)
+		periodEnd = &end
+	}
+	if periodStart != nil && periodEnd != nil && !periodEnd.After(*periodStart) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "End date must not be before start date"})
+		return
+	}
+
+	var createdBy *uint
+	if userID, ok := c.Get("userId"); ok {
+		id := userID.(uint)
+		createdBy = &id
+	}
+
+	var batch *models.PayoutBatch
+	var skipped []utils.PayoutSkippedUser
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		batch, skipped, err = utils.CreatePayoutBatch(tx, periodStart, periodEnd, createdBy)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrNoBonusesToPay) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "No claimed bonuses with a bank account to pay out",
+				"skipped": skipped,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to create payout batch: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Payout batch created successfully",
+		"data":    batch,
+		"skipped": skipped, // Affiliate tanpa rekening bank, bonusnya tetap claimed
+	})
+}
+
+// ExportPayoutBatch handles GET /payout-batches/:id/export
+func (ctrl *PayoutBatchController) ExportPayoutBatch(c *gin.Context) {
+	batch, ok := ctrl.findBatch(c)
+	if !ok {
+		return
+	}
+	if batch.Status == models.PayoutCancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Cannot export a cancelled payout batch"})
+		return
+	}
+
+	data, err := utils.RenderPayoutBatchCSV(batch)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+utils.PayoutBatchFileName(batch))
+	c.Data(http.StatusOK, "text/csv", data)
+}
+
+// MarkPayoutBatchPaid handles POST /payout-batches/:id/paid
+func (ctrl *PayoutBatchController) MarkPayoutBatchPaid(c *gin.Context) {
+	batchID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid batch ID"})
+		return
+	}
+
+	var paidBy *uint
+	if userID, ok := c.Get("userId"); ok {
+		id := userID.(uint)
+		paidBy = &id
+	}
+
+	var batch *models.PayoutBatch
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		batch, err = utils.MarkPayoutBatchPaid(tx, uint(batchID), paidBy)
+		return err
+	})
+	if err != nil {
+		ctrl.respondBatchError(c, err, "Failed to mark payout batch as paid: ")
+		return
+	}
+
+	// Notifikasi dikirim setelah commit agar affiliate tidak menerima kabar untuk transaksi yang gagal
+	for _, item := range batch.Items {
+		if item.User != nil {
+			go utils.SendAffiliatePayoutNotification(item.User.FCMToken, batch.BatchNumber, item.Amount, item.BonusCount)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Payout batch marked as paid",
+		"data":    batch,
+	})
+}
+
+// CancelPayoutBatch handles DELETE /payout-batches/:id
+func (ctrl *PayoutBatchController) CancelPayoutBatch(c *gin.Context) {
+	batchID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid batch ID"})
+		return
+	}
+
+	var batch *models.PayoutBatch
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		batch, err = utils.CancelPayoutBatch(tx, uint(batchID))
+		return err
+	})
+	if err != nil {
+		ctrl.respondBatchError(c, err, "Failed to cancel payout batch: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Payout batch cancelled, bonuses released",
+		"data":    batch,
+	})
+}
+
+func (ctrl *PayoutBatchController) findBatch(c *gin.Context) (*models.PayoutBatch, bool) {
+	var batch models.PayoutBatch
+	if err := ctrl.DB.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("Items.User", selectUserSummary).First(&batch, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Payout batch not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return nil, false
+	}
+	return &batch, true
+}
+
+func (ctrl *PayoutBatchController) respondBatchError(c *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Payout batch not found"})
+	case errors.Is(err, utils.ErrPayoutBatchNotDraft):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Payout batch is not in draft status"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": prefix + err.Error()})
+	}
+}
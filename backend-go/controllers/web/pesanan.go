@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderController struct {
@@ -270,6 +271,7 @@ func (ctrl *OrderController) UpdatePesananStatus(c *gin.Context) {
 		return
 	}
 
+	previousStatus := pesanan.Status
 	pesanan.Status = models.PesananStatus(req.Status)
 	if req.Status == "delivered" {
 		pesanan.PaymentStatus = models.PaymentPaid // Fixed: use constant
@@ -281,8 +283,66 @@ func (ctrl *OrderController) UpdatePesananStatus(c *gin.Context) {
 		return
 	}
 
+	// Bonus afiliasi baru bisa diklaim setelah delivered, dan batal jika pesanan dibatalkan
+	var referralReward *models.ReferralReward
+	switch pesanan.Status {
+	case models.PesananDelivered:
+		// Bonus dan hadiah referral hanya diproses saat pesanan pertama kali delivered
+		if previousStatus != models.PesananDelivered {
+			if _, err := utils.ConfirmAffiliateBonuses(tx, pesanan.ID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to confirm affiliate bonus: " + err.Error()})
+				return
+			}
+			if referralReward, err = utils.GrantFirstOrderReward(tx, &pesanan); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to grant referral reward: " + err.Error()})
+				return
+			}
+		}
+	case models.PesananCancelled:
+		if _, err := utils.VoidAffiliateBonuses(tx, pesanan.ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to void affiliate bonus: " + err.Error()})
+			return
+		}
+		// Stok, kuota flash sale, dan poin pesanan dikembalikan sekali saat pesanan pertama kali dibatalkan
+		if previousStatus != models.PesananCancelled {
+			var actorID *uint
+			if userID, ok := c.Get("userId"); ok {
+				id := userID.(uint)
+				actorID = &id
+			}
+			if _, err := utils.RestockCancelledOrder(tx, pesanan.ID, actorID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to restock order items: " + err.Error()})
+				return
+			}
+			if err := utils.ReleaseFlashSalePurchases(tx, pesanan.ID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to release flash sale quota: " + err.Error()})
+				return
+			}
+			if _, err := utils.RefundOrderPoints(tx, &pesanan); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to refund order points: " + err.Error()})
+				return
+			}
+		}
+	}
+
+	// Simpan ulang dengan status yang sama tidak mengulang ringkasan affiliate maupun notifikasi
+	statusChanged := previousStatus != pesanan.Status
+	if statusChanged {
+		if err := utils.RefreshAffiliateSummariesForOrder(tx, &pesanan); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update affiliate summary: " + err.Error()})
+			return
+		}
+	}
+
 	// Send push notification
-	if pesanan.User.FCMToken != "" {
+	if statusChanged && pesanan.User.FCMToken != "" {
 		isValid := utils.IsFcmTokenValid(pesanan.User.FCMToken) // Fixed: single return value
 		if isValid {
 			firstName := ""
@@ -311,6 +371,10 @@ func (ctrl *OrderController) UpdatePesananStatus(c *gin.Context) {
 		return
 	}
 
+	if referralReward != nil {
+		notifyReferralReward(ctrl.DB, referralReward)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
 }
 
@@ -326,6 +390,43 @@ func (ctrl *OrderController) DeletePesanan(c *gin.Context) {
 		}
 	}()
 
+	pesananID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid order ID"})
+		return
+	}
+
+	var pesanan models.Pesanan
+	pesananFound := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", pesananID).Limit(1).Find(&pesanan).RowsAffected > 0
+
+	// Batalkan bonus afiliasi yang belum diklaim dari pesanan ini
+	if _, err := utils.VoidAffiliateBonuses(tx, uint(pesananID)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to void affiliate bonus: " + err.Error()})
+		return
+	}
+
+	// Stok, kuota flash sale, dan poin hanya dikembalikan untuk pesanan yang belum dikirim, sebelum item dihapus
+	if pesananFound && utils.OrderHoldsStock(pesanan.Status) {
+		if _, err := utils.RestockOrder(tx, pesanan.ID, "Pesanan dihapus", adminActorID(c)); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to restock order items: " + err.Error()})
+			return
+		}
+		if err := utils.ReleaseFlashSalePurchases(tx, pesanan.ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to release flash sale quota: " + err.Error()})
+			return
+		}
+		if _, err := utils.RefundOrderPoints(tx, &pesanan); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to refund order points: " + err.Error()})
+			return
+		}
+	}
+
 	// 1. Hapus semua OrderItem terkait
 	if err := tx.Where("pesanan_id = ?", id).Delete(&models.OrderItem{}).Error; err != nil {
 		tx.Rollback()
@@ -340,6 +441,15 @@ func (ctrl *OrderController) DeletePesanan(c *gin.Context) {
 		return
 	}
 
+	// Belanja pesanan yang dihapus tidak lagi dihitung di ringkasan affiliate
+	if pesananFound {
+		if err := utils.RefreshAffiliateSummariesForOrder(tx, &pesanan); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update affiliate summary: " + err.Error()})
+			return
+		}
+	}
+
 	// Commit transaksi jika berhasil
 	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Transaction failed: " + err.Error()})
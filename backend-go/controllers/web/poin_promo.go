@@ -0,0 +1,236 @@
+package web
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type PoinPromoController struct {
+	DB *gorm.DB
+}
+
+func NewPoinPromoController(db *gorm.DB) *PoinPromoController {
+	return &PoinPromoController{DB: db}
+}
+
+type PoinPromoRequest struct {
+	Name               string    `json:"name" binding:"required"`
+	PoinID             uint      `json:"poinId" binding:"required"`
+	BonusPoints        int       `json:"bonusPoints"`
+	DiscountPercentage float64   `json:"discountPercentage"`
+	MaxPerUser         int       `json:"maxPerUser"`
+	StartAt            time.Time `json:"startAt" binding:"required"`
+	EndAt              time.Time `json:"endAt" binding:"required"`
+}
+
+// GetPoinPromos handles GET /poin-promos
+func (ctrl *PoinPromoController) GetPoinPromos(c *gin.Context) {
+	var promos []models.PoinPromo
+	if err := ctrl.DB.Preload("Poin").Order("start_at DESC").Find(&promos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching promos",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    promos,
+	})
+}
+
+// CreatePoinPromo handles POST /poin-promos
+func (ctrl *PoinPromoController) CreatePoinPromo(c *gin.Context) {
+	var req PoinPromoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if status, message := ctrl.validatePromo(&req, 0); message != "" {
+		c.JSON(status, gin.H{"success": false, "message": message})
+		return
+	}
+
+	promo := models.PoinPromo{
+		Name:               req.Name,
+		PoinID:             req.PoinID,
+		BonusPoints:        req.BonusPoints,
+		DiscountPercentage: req.DiscountPercentage,
+		MaxPerUser:         req.MaxPerUser,
+		StartAt:            req.StartAt,
+		EndAt:              req.EndAt,
+	}
+	if err := ctrl.DB.Create(&promo).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error creating promo",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Promo yang periodenya sudah berjalan langsung diaktifkan tanpa menunggu scheduler
+	if _, _, err := utils.SyncPoinPromos(ctrl.DB); err != nil {
+		log.Println("Error syncing poin promos:", err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Promo created successfully",
+		"data":    promo,
+	})
+}
+
+// UpdatePoinPromo handles PATCH /poin-promos/:id
+func (ctrl *PoinPromoController) UpdatePoinPromo(c *gin.Context) {
+	id := c.Param("id")
+
+	var promo models.PoinPromo
+	if err := ctrl.DB.First(&promo, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Promo not found"})
+		return
+	}
+
+	var req PoinPromoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	// Paket poin tidak boleh diganti selama promo aktif karena diskonnya sedang terpasang
+	if promo.IsActive && req.PoinID != promo.PoinID {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Cannot change package of an active promo"})
+		return
+	}
+
+	if status, message := ctrl.validatePromo(&req, promo.ID); message != "" {
+		c.JSON(status, gin.H{"success": false, "message": message})
+		return
+	}
+
+	promo.Name = req.Name
+	promo.PoinID = req.PoinID
+	promo.BonusPoints = req.BonusPoints
+	promo.DiscountPercentage = req.DiscountPercentage
+	promo.MaxPerUser = req.MaxPerUser
+	promo.StartAt = req.StartAt
+	promo.EndAt = req.EndAt
+
+	// Promo yang sedang aktif dipasang ulang agar perubahan diskon langsung berlaku pada paket
+	if err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&promo).Error; err != nil {
+			return err
+		}
+		return utils.ReapplyPoinPromo(tx, &promo)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error updating promo",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if _, _, err := utils.SyncPoinPromos(ctrl.DB); err != nil {
+		log.Println("Error syncing poin promos:", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promo updated successfully",
+		"data":    promo,
+	})
+}
+
+// DeletePoinPromo handles DELETE /poin-promos/:id
+func (ctrl *PoinPromoController) DeletePoinPromo(c *gin.Context) {
+	id := c.Param("id")
+
+	var promo models.PoinPromo
+	if err := ctrl.DB.First(&promo, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Promo not found"})
+		return
+	}
+
+	// Promo yang sedang aktif diakhiri sekarang agar diskonnya dilepas oleh sync
+	if promo.IsActive {
+		if err := ctrl.DB.Model(&promo).Update("end_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error ending promo",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if _, _, err := utils.SyncPoinPromos(ctrl.DB); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error deactivating promo",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := ctrl.DB.Delete(&promo).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error deleting promo",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promo deleted successfully",
+	})
+}
+
+// validatePromo mengembalikan status dan pesan error jika request promo tidak valid
+func (ctrl *PoinPromoController) validatePromo(req *PoinPromoRequest, promoID uint) (int, string) {
+	if !req.EndAt.After(req.StartAt) {
+		return http.StatusBadRequest, "End time must be after start time"
+	}
+	if req.BonusPoints < 0 || req.MaxPerUser < 0 {
+		return http.StatusBadRequest, "Bonus points and max per user cannot be negative"
+	}
+	if req.DiscountPercentage < 0 || req.DiscountPercentage > 100 {
+		return http.StatusBadRequest, "Discount percentage must be between 0 and 100"
+	}
+	if req.BonusPoints == 0 && req.DiscountPercentage == 0 {
+		return http.StatusBadRequest, "Promo must give bonus points or a discount"
+	}
+
+	var poin models.Poin
+	if err := ctrl.DB.First(&poin, req.PoinID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return http.StatusNotFound, "Poin not found"
+		}
+		return http.StatusInternalServerError, err.Error()
+	}
+
+	// Satu paket hanya boleh punya satu promo pada periode yang sama
+	var overlapping int64
+	if err := ctrl.DB.Model(&models.PoinPromo{}).
+		Where("poin_id = ? AND id <> ? AND start_at < ? AND end_at > ?", req.PoinID, promoID, req.EndAt, req.StartAt).
+		Count(&overlapping).Error; err != nil {
+		return http.StatusInternalServerError, err.Error()
+	}
+	if overlapping > 0 {
+		return http.StatusBadRequest, "Another promo for this package overlaps the selected period"
+	}
+
+	return 0, ""
+}
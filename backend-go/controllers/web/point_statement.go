@@ -0,0 +1,125 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/tasks"
+	"backend-go/utils"
+)
+
+type PointStatementController struct {
+	DB *gorm.DB
+}
+
+func NewPointStatementController(db *gorm.DB) *PointStatementController {
+	return &PointStatementController{DB: db}
+}
+
+// GetUserStatement handles GET /point-statements/:userId?month=YYYY-MM&format=pdf|csv|json
+func (ctrl *PointStatementController) GetUserStatement(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid user ID"})
+		return
+	}
+
+	month, err := utils.ParseStatementMonth(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	statement, err := utils.BuildPointStatement(ctrl.DB, uint(userID), month)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "pdf")
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": statement})
+		return
+	}
+
+	data, contentType, err := utils.RenderPointStatement(statement, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+utils.StatementFileName(statement, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+type EmailStatementsRequest struct {
+	Month string `json:"month"` // YYYY-MM, kosong berarti bulan lalu
+}
+
+// EmailStatements handles POST /point-statements/email
+func (ctrl *PointStatementController) EmailStatements(c *gin.Context) {
+	// Body kosong berarti memakai default (bulan lalu)
+	var req EmailStatementsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	month, err := utils.ParseStatementMonth(req.Month)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	// Pengiriman ke semua user bisa lama, jadi dijalankan di background
+	go tasks.SendMonthlyPointStatements(ctrl.DB, month)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Point statements for " + month.Format("01-2006") + " are being sent",
+	})
+}
+
+// GetAutoEmail handles GET /point-statements/auto-email
+func (ctrl *PointStatementController) GetAutoEmail(c *gin.Context) {
+	var setting models.Setting
+	enabled := ctrl.DB.Where("key = ?", utils.SettingPoinStatementAutoEmail).First(&setting).Error == nil &&
+		setting.Value == "true"
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "enabled": enabled})
+}
+
+type SetAutoEmailRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// SetAutoEmail handles POST /point-statements/auto-email
+func (ctrl *PointStatementController) SetAutoEmail(c *gin.Context) {
+	var req SetAutoEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	setting := models.Setting{Key: utils.SettingPoinStatementAutoEmail, Value: strconv.FormatBool(*req.Enabled)}
+	if err := ctrl.DB.Save(&setting).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to update setting: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Point statement auto email updated successfully",
+		"enabled": *req.Enabled,
+	})
+}
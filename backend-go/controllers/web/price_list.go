@@ -0,0 +1,290 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type PriceListController struct {
+	DB *gorm.DB
+}
+
+func NewPriceListController(db *gorm.DB) *PriceListController {
+	return &PriceListController{DB: db}
+}
+
+type CreatePriceListRequest struct {
+	Name        string                 `json:"name" form:"name"`
+	EffectiveAt *time.Time             `json:"effectiveAt" form:"effectiveAt" time_format:"2006-01-02T15:04:05Z07:00"` // Kosong = besok pagi
+	Items       []utils.PriceListInput `json:"items"`
+}
+
+// GetPriceLists handles GET /price-lists?status=scheduled|applied
+func (ctrl *PriceListController) GetPriceLists(c *gin.Context) {
+	type priceListSummary struct {
+		models.PriceList
+		ItemCount int64 `json:"itemCount"`
+	}
+
+	query := ctrl.DB.Model(&models.PriceList{})
+	switch c.Query("status") {
+	case "scheduled":
+		query = query.Where("applied_at IS NULL")
+	case "applied":
+		query = query.Where("applied_at IS NOT NULL")
+	}
+
+	var priceLists []models.PriceList
+	if err := query.Order("effective_at DESC, id DESC").Limit(100).Find(&priceLists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	summaries := make([]priceListSummary, len(priceLists))
+	for i, priceList := range priceLists {
+		summaries[i].PriceList = priceList
+		ctrl.DB.Model(&models.PriceListItem{}).Where("price_list_id = ?", priceList.ID).Count(&summaries[i].ItemCount)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": summaries})
+}
+
+// GetPriceListById handles GET /price-lists/:id
+// Item ditampilkan sebagai diff harga lama dan baru dengan nilai poin yang berlaku saat ini.
+func (ctrl *PriceListController) GetPriceListById(c *gin.Context) {
+	var priceList models.PriceList
+	if err := ctrl.DB.First(&priceList, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Price list not found"})
+		return
+	}
+
+	previews, err := utils.PreviewPriceList(ctrl.DB, priceList.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"priceList": priceList,
+			"items":     previews,
+		},
+	})
+}
+
+// CreatePriceList handles POST /price-lists
+// Harga bisa dikirim sebagai JSON "items" atau diunggah sebagai spreadsheet pada field "file"
+// dengan kolom variant_id dan harga_rp (format file export katalog).
+func (ctrl *PriceListController) CreatePriceList(c *gin.Context) {
+	var req CreatePriceListRequest
+	var rowErrors []utils.ImportError
+
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		if err := c.ShouldBind(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Failed to read file"})
+			return
+		}
+		defer file.Close()
+
+		req.Items, rowErrors, err = utils.ReadPriceListSheet(file, fileHeader.Filename)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	effectiveAt := utils.DefaultPriceListEffectiveAt(now)
+	if req.EffectiveAt != nil {
+		if !req.EffectiveAt.After(now) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "effectiveAt must be in the future, use apply to change prices now"})
+			return
+		}
+		effectiveAt = *req.EffectiveAt
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = "Harga " + effectiveAt.Format("02 Jan 2006")
+	}
+
+	validationErrors, err := utils.ValidatePriceListInputs(ctrl.DB, req.Items)
+	if err != nil && !errors.Is(err, utils.ErrPriceListEmpty) {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	rowErrors = append(rowErrors, validationErrors...)
+	if err != nil || len(rowErrors) > 0 {
+		message := utils.ErrInvalidPriceList.Error()
+		if err != nil {
+			message = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": message, "errors": rowErrors})
+		return
+	}
+
+	var priceList *models.PriceList
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		priceList, err = utils.CreatePriceList(tx, req.Name, effectiveAt, req.Items, adminActorID(c))
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to create price list: " + err.Error()})
+		return
+	}
+
+	previews, err := utils.PreviewPriceList(ctrl.DB, priceList.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	priceList.Items = nil
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Price list scheduled successfully",
+		"data": gin.H{
+			"priceList": priceList,
+			"items":     previews,
+		},
+	})
+}
+
+// ApplyPriceList handles POST /price-lists/:id/apply
+// Memberlakukan daftar harga sekarang tanpa menunggu jadwal.
+func (ctrl *PriceListController) ApplyPriceList(c *gin.Context) {
+	priceListID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid price list ID"})
+		return
+	}
+
+	var changed int
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		changed, err = utils.ApplyPriceList(tx, uint(priceListID), adminActorID(c))
+		return err
+	})
+	if err != nil {
+		respondPriceListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"message":      "Price list applied successfully",
+		"updatedItems": changed,
+	})
+}
+
+// CancelPriceList handles DELETE /price-lists/:id
+func (ctrl *PriceListController) CancelPriceList(c *gin.Context) {
+	var priceList models.PriceList
+	if err := ctrl.DB.First(&priceList, c.Param("id")).Error; err != nil {
+		respondPriceListError(c, utils.ErrPriceListNotFound)
+		return
+	}
+
+	// Daftar harga yang sudah berlaku tetap disimpan sebagai riwayat
+	if priceList.AppliedAt != nil {
+		respondPriceListError(c, utils.ErrPriceListApplied)
+		return
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("price_list_id = ?", priceList.ID).Delete(&models.PriceListItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&priceList).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to cancel price list: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Price list cancelled successfully"})
+}
+
+// GetPriceHistory handles GET /price-lists/history?productItemId=&productId=&from=2025-01-01&to=2025-01-31&page=0&limit=50
+func (ctrl *PriceListController) GetPriceHistory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := page * limit
+
+	query := ctrl.DB.Model(&models.PriceHistory{})
+	if productItemID := c.Query("productItemId"); productItemID != "" {
+		query = query.Where("product_item_id = ?", productItemID)
+	}
+	if productID := c.Query("productId"); productID != "" {
+		query = query.Where("product_item_id IN (?)",
+			ctrl.DB.Model(&models.ProductItem{}).Select("id").Where("product_id = ?", productID))
+	}
+	if source := c.Query("source"); source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if from, err := time.ParseInLocation("2006-01-02", c.Query("from"), time.Local); err == nil {
+		query = query.Where("created_at >= ?", from)
+	}
+	if to, err := time.ParseInLocation("2006-01-02", c.Query("to"), time.Local); err == nil {
+		query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var totalRows int64
+	if err := query.Count(&totalRows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var history []models.PriceHistory
+	if err := query.Preload("ProductItem.Product").
+		Order("created_at DESC, id DESC").
+		Offset(offset).Limit(limit).
+		Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	totalPage := 0
+	if limit > 0 {
+		totalPage = (int(totalRows) + limit - 1) / limit
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      history,
+		"page":      page,
+		"limit":     limit,
+		"totalPage": totalPage,
+		"totalRows": totalRows,
+	})
+}
+
+func respondPriceListError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrPriceListNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+	case errors.Is(err, utils.ErrPriceListApplied):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to apply price list: " + err.Error()})
+	}
+}
@@ -0,0 +1,244 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+// GetProductImages handles GET /products/:id/images
+func (ctrl *ProductController) GetProductImages(c *gin.Context) {
+	var images []models.ProductImage
+	if err := utils.OrderProductImages(ctrl.DB).
+		Where("product_id = ?", c.Param("id")).
+		Find(&images).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching product images",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": images})
+}
+
+type UploadProductImagesRequest struct {
+	AltText       string `form:"altText"`
+	ProductItemID *uint  `form:"productItemId"` // Kosong = gambar produk, terisi = gambar varian
+}
+
+// UploadProductImages handles POST /products/:id/images
+// Beberapa file dikirim lewat field "images" dan ditambahkan di akhir galeri.
+func (ctrl *ProductController) UploadProductImages(c *gin.Context) {
+	uploaded := c.GetStringSlice("fileNames")
+	if len(uploaded) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "No images uploaded"})
+		return
+	}
+
+	var req UploadProductImagesRequest
+	if err := c.ShouldBind(&req); err != nil {
+		removeUploads(uploaded)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	product, ok := ctrl.findProduct(c)
+	if !ok {
+		removeUploads(uploaded)
+		return
+	}
+
+	var images []models.ProductImage
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		images, err = utils.AddProductImages(tx, product.ID, uploaded, req.AltText, req.ProductItemID)
+		return err
+	})
+	if err != nil {
+		removeUploads(uploaded)
+		respondProductImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Product images uploaded successfully",
+		"data":    images,
+	})
+}
+
+type UpdateProductImageRequest struct {
+	AltText       *string `json:"altText"`
+	ProductItemID *uint   `json:"productItemId"` // 0 = lepas dari varian
+	IsPrimary     *bool   `json:"isPrimary"`
+}
+
+// UpdateProductImage handles PATCH /products/:id/images/:imageId
+func (ctrl *ProductController) UpdateProductImage(c *gin.Context) {
+	var req UpdateProductImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	product, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+	imageID, err := strconv.ParseUint(c.Param("imageId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid image ID"})
+		return
+	}
+
+	var image models.ProductImage
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND product_id = ?", imageID, product.ID).First(&image).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.ErrProductImageNotFound
+			}
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if req.AltText != nil {
+			updates["alt_text"] = *req.AltText
+		}
+		if req.ProductItemID != nil {
+			if *req.ProductItemID == 0 {
+				updates["product_item_id"] = nil
+			} else {
+				if err := utils.ValidateProductItem(tx, product.ID, req.ProductItemID); err != nil {
+					return err
+				}
+				updates["product_item_id"] = *req.ProductItemID
+			}
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&image).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if req.IsPrimary != nil && *req.IsPrimary {
+			if err := utils.SetPrimaryProductImage(tx, product.ID, image.ID); err != nil {
+				return err
+			}
+		}
+		return tx.First(&image, image.ID).Error
+	})
+	if err != nil {
+		respondProductImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Product image updated successfully",
+		"data":    image,
+	})
+}
+
+type ReorderProductImagesRequest struct {
+	ImageIDs []uint `json:"imageIds" binding:"required,min=1"`
+}
+
+// ReorderProductImages handles POST /products/:id/images/reorder
+func (ctrl *ProductController) ReorderProductImages(c *gin.Context) {
+	var req ReorderProductImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	product, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		return utils.ReorderProductImages(tx, product.ID, req.ImageIDs)
+	})
+	if err != nil {
+		respondProductImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Product images reordered successfully"})
+}
+
+// DeleteProductImage handles DELETE /products/:id/images/:imageId
+func (ctrl *ProductController) DeleteProductImage(c *gin.Context) {
+	product, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+	imageID, err := strconv.ParseUint(c.Param("imageId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid image ID"})
+		return
+	}
+
+	var image *models.ProductImage
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		image, err = utils.DeleteProductImage(tx, product.ID, uint(imageID))
+		return err
+	})
+	if err != nil {
+		respondProductImageError(c, err)
+		return
+	}
+
+	utils.RemoveUploads(ctrl.DB, image.Filename)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Product image deleted successfully"})
+}
+
+func (ctrl *ProductController) findProduct(c *gin.Context) (*models.Product, bool) {
+	var product models.Product
+	if err := ctrl.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Product not found"})
+		return nil, false
+	}
+	return &product, true
+}
+
+// uploadedProductImages menggabungkan file dari field "image" (gambar utama) dan "images" (galeri)
+func uploadedProductImages(c *gin.Context) []string {
+	var filenames []string
+	if filename := c.GetString("fileName"); filename != "" {
+		filenames = append(filenames, filename)
+	}
+	return append(filenames, c.GetStringSlice("fileNames")...)
+}
+
+func removeUploads(filenames []string) {
+	for _, filename := range filenames {
+		removeUpload(filename)
+	}
+}
+
+func respondProductImageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrProductImageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+	case errors.Is(err, utils.ErrProductImageLimit), errors.Is(err, utils.ErrInvalidImageOrder),
+		errors.Is(err, utils.ErrVariantNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error updating product images",
+			"error":   err.Error(),
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+const maxImportFileSize = 5 << 20 // 5MB
+
+// ImportProducts handles POST /products/import?dryRun=true
+// Spreadsheet (.csv/.xlsx) diunggah pada field "file". Secara default hanya dry-run yang
+// mengembalikan diff dan laporan kesalahan per baris; kirim dryRun=false untuk menyimpan
+// seluruh perubahan dalam satu transaksi. Field "reason" menjadi alasan perubahan stok.
+func (ctrl *ProductController) ImportProducts(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "File is required"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "File size must not exceed 5MB"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	rows, err := utils.ReadProductSheet(file, fileHeader.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if c.DefaultQuery("dryRun", "true") != "false" {
+		imp, err := utils.PlanProductImport(ctrl.DB, rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to validate import: " + err.Error()})
+			return
+		}
+		ctrl.respondImport(c, imp, "Dry run completed, no changes saved")
+		return
+	}
+
+	reason := strings.TrimSpace(c.PostForm("reason"))
+	if reason == "" {
+		reason = "Import katalog " + fileHeader.Filename
+	}
+
+	// Validasi diulang di dalam transaksi agar diff sesuai dengan data yang disimpan
+	var imp *utils.ProductImport
+	var movements []models.StockMovement
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if imp, err = utils.PlanProductImport(tx, rows); err != nil {
+			return err
+		}
+		movements, err = utils.ApplyProductImport(tx, imp, reason, adminActorID(c))
+		return err
+	})
+	if err != nil && !errors.Is(err, utils.ErrInvalidImport) {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to import products: " + err.Error()})
+		return
+	}
+	if err == nil {
+		utils.NotifyLowStock(ctrl.DB, movements...)
+	}
+
+	ctrl.respondImport(c, imp, "Products imported successfully")
+}
+
+func (ctrl *ProductController) respondImport(c *gin.Context, imp *utils.ProductImport, message string) {
+	if !imp.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Import contains invalid rows, no changes saved",
+			"data":    imp,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    imp,
+	})
+}
+
+// ExportProducts handles GET /products/export?format=xlsx
+func (ctrl *ProductController) ExportProducts(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "xlsx"))
+	contentType := map[string]string{
+		"csv":  "text/csv",
+		"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	}[format]
+	if contentType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format must be csv or xlsx"})
+		return
+	}
+
+	data, err := utils.ExportProductSheet(ctrl.DB, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to export products: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+utils.ProductSheetFileName(format))
+	c.Data(http.StatusOK, contentType, data)
+}
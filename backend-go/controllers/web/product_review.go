@@ -0,0 +1,166 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type ProductReviewController struct {
+	DB *gorm.DB
+}
+
+func NewProductReviewController(db *gorm.DB) *ProductReviewController {
+	return &ProductReviewController{DB: db}
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status" binding:"required"` // approved atau hidden
+}
+
+type ReplyReviewRequest struct {
+	Reply string `json:"reply" binding:"required"`
+}
+
+// GetReviews handles GET /reviews
+// Antrian moderasi; default menampilkan ulasan pending lebih dulu.
+func (ctrl *ProductReviewController) GetReviews(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset := page * limit
+
+	query := ctrl.DB.Model(&models.ProductReview{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if productID := c.Query("productId"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if rating := c.Query("rating"); rating != "" {
+		query = query.Where("rating = ?", rating)
+	}
+
+	var totalRows int64
+	if err := query.Count(&totalRows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var reviews []models.ProductReview
+	if err := query.Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Product").
+		Preload("ProductItem").
+		Preload("User", selectUserSummary).
+		Order("CASE WHEN status = 'pending' THEN 0 ELSE 1 END, created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err := utils.FillReviewerNames(ctrl.DB, reviews); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	totalPage := 0
+	if limit > 0 {
+		totalPage = (int(totalRows) + limit - 1) / limit
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      reviews,
+		"page":      page,
+		"limit":     limit,
+		"totalPage": totalPage,
+		"totalRows": totalRows,
+	})
+}
+
+// ModerateReview handles PATCH /reviews/:id/status
+// Ulasan yang disetujui tampil di aplikasi dan ikut dihitung ke rating produk.
+func (ctrl *ProductReviewController) ModerateReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid review ID"})
+		return
+	}
+
+	var req ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	actorID := adminActorID(c)
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := utils.ModerateReview(tx, uint(id), models.ReviewStatus(req.Status), actorID)
+		return err
+	})
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	ctrl.respondReview(c, uint(id), "Review "+req.Status+" successfully")
+}
+
+// ReplyReview handles PATCH /reviews/:id/reply
+func (ctrl *ProductReviewController) ReplyReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid review ID"})
+		return
+	}
+
+	var req ReplyReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	actorID := adminActorID(c)
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := utils.ReplyReview(tx, uint(id), req.Reply, actorID)
+		return err
+	})
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	ctrl.respondReview(c, uint(id), "Reply saved successfully")
+}
+
+func (ctrl *ProductReviewController) respondReview(c *gin.Context, id uint, message string) {
+	var review models.ProductReview
+	if err := ctrl.DB.Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		First(&review, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    review,
+	})
+}
+
+func respondReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrReviewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+	case errors.Is(err, utils.ErrInvalidReviewStatus),
+		errors.Is(err, utils.ErrReviewReplyRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+	}
+}
@@ -3,10 +3,10 @@ package web
 import (
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +15,7 @@ import (
 	"gorm.io/gorm"
 
 	"backend-go/models"
+	"backend-go/utils"
 )
 
 type ProductController struct {
@@ -106,6 +107,7 @@ func (ctrl *ProductController) GetProducts(c *gin.Context) {
 		NameProduk   string                `json:"nameProduk"`
 		Deskripsi    string                `json:"deskripsi"`
 		Kategori     string                `json:"kategori"`
+		CategoryID   *uint                 `json:"categoryId"`
 		Image        string                `json:"image"`
 		CreatedAt    time.Time             `json:"createdAt"`
 		UpdatedAt    time.Time             `json:"updatedAt"`
@@ -132,6 +134,7 @@ func (ctrl *ProductController) GetProducts(c *gin.Context) {
 			NameProduk:   p.NameProduk,
 			Deskripsi:    p.Deskripsi,
 			Kategori:     p.Kategori,
+			CategoryID:   p.CategoryID,
 			Image:        p.Image,
 			CreatedAt:    p.CreatedAt,
 			UpdatedAt:    p.UpdatedAt,
@@ -169,7 +172,17 @@ func (ctrl *ProductController) GetProductsApp(c *gin.Context) {
 	// Build query
 	query := ctrl.DB.Model(&models.Product{})
 	if category != "" && category != "All" {
-		query = query.Where("kategori LIKE ?", "%"+category+"%")
+		categoryIDs, err := utils.CategoryFilterIDs(ctrl.DB, category)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error fetching category",
+				"error":   err.Error(),
+			})
+			return
+		}
+		// Filter memakai kategori beserta sub kategorinya, bukan pencocokan teks
+		query = query.Where("category_id IN ?", categoryIDs)
 	}
 
 	// Get total count
@@ -243,7 +256,17 @@ func (ctrl *ProductController) SearchProductsApp(c *gin.Context) {
 
 	// Add category filter
 	if category != "" && category != "All" {
-		dbQuery = dbQuery.Where("kategori = ?", category)
+		categoryIDs, err := utils.CategoryFilterIDs(ctrl.DB, category)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error fetching category",
+				"error":   err.Error(),
+			})
+			return
+		}
+		// Filter memakai kategori beserta sub kategorinya, bukan pencocokan teks
+		dbQuery = dbQuery.Where("category_id IN ?", categoryIDs)
 	}
 
 	// Get total count
@@ -292,6 +315,9 @@ func (ctrl *ProductController) GetProductById(c *gin.Context) {
 	var product models.Product
 	if err := ctrl.DB.
 		Preload("ProductItems").
+		Preload("ProductItems.Images", utils.OrderProductImages).
+		Preload("Images", utils.OrderProductImages).
+		Preload("Category").
 		First(&product, id).
 		Error; err != nil {
 
@@ -305,15 +331,8 @@ func (ctrl *ProductController) GetProductById(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": product})
 }
 
-// Valid units for product variants
-var validUnits = map[string]bool{
-	"gram":     true,
-	"kilogram": true,
-	"ikat":     true,
-	"biji":     true,
-	"buah":     true,
-	"pcs":      true,
-}
+// Valid units for product variants, dipakai bersama oleh import spreadsheet
+var validUnits = utils.ValidProductUnits
 
 type ProductVariantRequest struct {
 	Stok         string `form:"stok" json:"stok" binding:"required"`
@@ -327,7 +346,8 @@ type ProductVariantRequest struct {
 type CreateProductRequest struct {
 	NameProduk string `form:"nameProduk" binding:"required"`
 	Deskripsi  string `form:"deskripsi" binding:"required"`
-	Kategori   string `form:"kategori" binding:"required"`
+	Kategori   string `form:"kategori"` // Nama atau slug kategori, dipakai jika categoryId kosong
+	CategoryID string `form:"categoryId"`
 	Variants   string `form:"variants"`
 	Stok       string `form:"stok"`
 	HargaRp    string `form:"hargaRp"`
@@ -346,6 +366,15 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	category, err := ctrl.resolveProductCategory(req.CategoryID, req.Kategori)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	var variants []ProductVariantRequest
 
 	// Parse variants if provided as JSON string
@@ -386,10 +415,10 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 	}
 
 	// Limit number of variants
-	if len(variants) > 10 {
+	if len(variants) > utils.MaxProductVariants {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "Maximum 10 variants per product allowed",
+			"message": fmt.Sprintf("Maximum %d variants per product allowed", utils.MaxProductVariants),
 		})
 		return
 	}
@@ -420,33 +449,26 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 		seenVariants[key] = true
 	}
 
-	// Get point conversion setting
-	var setting models.Setting
-	if err := ctrl.DB.Where("key = ?", "hargaPoin").First(&setting).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Harga Poin setting not found",
-		})
-		return
-	}
-
-	poinValue, err := strconv.ParseFloat(setting.Value, 64)
+	// Nilai poin diambil dari versi nilai poin yang sedang berlaku
+	pointRate, err := utils.GetCurrentPointRate(ctrl.DB)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"message": "Invalid hargaPoin value",
+			"message": "Harga Poin setting not found",
 		})
 		return
 	}
 
-	// Get uploaded filename
+	// Get uploaded filename; "image" menjadi gambar utama, "images" ditambahkan ke galeri
 	imageFilename := c.GetString("fileName")
+	uploaded := uploadedProductImages(c)
 
 	// Create main product
 	product := models.Product{
 		NameProduk: req.NameProduk,
 		Deskripsi:  req.Deskripsi,
-		Kategori:   req.Kategori,
+		Kategori:   category.Name,
+		CategoryID: &category.ID,
 		Image:      imageFilename,
 	}
 
@@ -462,9 +484,7 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 	if err := tx.Create(&product).Error; err != nil {
 		tx.Rollback()
 		// Clean up uploaded file
-		if imageFilename != "" {
-			os.Remove("./uploads/" + imageFilename)
-		}
+		removeUploads(uploaded)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Error creating main product",
@@ -507,8 +527,8 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 		}
 
 		// Calculate point price
-		hargaPoin := int(math.Round(hargaRp / poinValue))
 		hargaRpInt := int(math.Round(hargaRp))
+		hargaPoin := utils.HargaPoinFor(hargaRpInt, pointRate.Rate)
 
 		// Create variant
 		productItem := models.ProductItem{
@@ -523,9 +543,7 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 		if err := tx.Create(&productItem).Error; err != nil {
 			tx.Rollback()
 			// Clean up uploaded file
-			if imageFilename != "" {
-				os.Remove("./uploads/" + imageFilename)
-			}
+			removeUploads(uploaded)
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
 				"message": "Error creating product variant",
@@ -533,6 +551,36 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 			})
 			return
 		}
+
+		if err := utils.RecordInitialStock(tx, &productItem, adminActorID(c)); err != nil {
+			tx.Rollback()
+			removeUploads(uploaded)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error recording initial stock",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		if err := utils.RecordPriceChange(tx, productItem.ID, 0, 0, productItem.HargaRp, productItem.HargaPoin,
+			models.PriceSourceManual, nil, adminActorID(c)); err != nil {
+			tx.Rollback()
+			removeUploads(uploaded)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error recording price history",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	if _, err := utils.AddProductImages(tx, product.ID, uploaded, req.NameProduk, nil); err != nil {
+		tx.Rollback()
+		removeUploads(uploaded)
+		respondProductImageError(c, err)
+		return
 	}
 
 	// Commit transaction
@@ -547,7 +595,7 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 	}
 
 	// Reload product with variants
-	if err := ctrl.DB.Preload("ProductItems").First(&product, product.ID).Error; err != nil {
+	if err := ctrl.DB.Preload("ProductItems").Preload("Images", utils.OrderProductImages).First(&product, product.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Error fetching created product",
@@ -572,10 +620,12 @@ type ProductVariantUpdateRequest struct {
 }
 
 type UpdateProductRequest struct {
-	NameProduk string `form:"nameProduk"`
-	Deskripsi  string `form:"deskripsi"`
-	Kategori   string `form:"kategori"`
-	Variants   string `form:"variants"` // Ubah menjadi string untuk JSON
+	NameProduk  string `form:"nameProduk"`
+	Deskripsi   string `form:"deskripsi"`
+	Kategori    string `form:"kategori"`
+	CategoryID  string `form:"categoryId"`
+	Variants    string `form:"variants"`    // Ubah menjadi string untuk JSON
+	StockReason string `form:"stockReason"` // Wajib diisi jika stok varian yang sudah ada diubah
 }
 
 func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
@@ -621,14 +671,9 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	// Get uploaded filename from middleware context
+	// Gambar baru tidak lagi menimpa gambar lama; "image" ditambahkan ke galeri sebagai gambar utama
 	newImageFilename := c.GetString("fileName")
-	var oldImageFilename string
-
-	if newImageFilename != "" {
-		// Save old image for cleanup
-		oldImageFilename = product.Image
-	}
+	uploaded := uploadedProductImages(c)
 
 	// Update product fields
 	updateFields := false
@@ -640,15 +685,19 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 		product.Deskripsi = req.Deskripsi
 		updateFields = true
 	}
-	if req.Kategori != "" {
-		product.Kategori = req.Kategori
-		updateFields = true
-	}
-	if newImageFilename != "" {
-		product.Image = newImageFilename
+	if req.CategoryID != "" || req.Kategori != "" {
+		category, err := ctrl.resolveProductCategory(req.CategoryID, req.Kategori)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+		product.Kategori = category.Name
+		product.CategoryID = &category.ID
 		updateFields = true
 	}
-
 	// Start database transaction
 	tx := ctrl.DB.Begin()
 	defer func() {
@@ -662,9 +711,7 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 		if err := tx.Save(&product).Error; err != nil {
 			tx.Rollback()
 			// Clean up new file if database error
-			if newImageFilename != "" {
-				os.Remove("./uploads/" + newImageFilename)
-			}
+			removeUploads(uploaded)
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
 				"message": "Error updating product",
@@ -674,28 +721,19 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 		}
 	}
 
-	// Get point conversion setting
-	var setting models.Setting
-	if err := tx.Where("key = ?", "hargaPoin").First(&setting).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Harga Poin setting not found",
-		})
-		return
-	}
-
-	poinValue, err := strconv.ParseFloat(setting.Value, 64)
+	// Nilai poin diambil dari versi nilai poin yang sedang berlaku
+	pointRate, err := utils.GetCurrentPointRate(tx)
 	if err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"message": "Invalid hargaPoin value",
+			"message": "Harga Poin setting not found",
 		})
 		return
 	}
 
 	// Update variants
+	var stockMovements []models.StockMovement
 	for _, variantReq := range variantReqs {
 		var productItem models.ProductItem
 
@@ -726,7 +764,11 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 			}
 		}
 
+		oldHargaRp, oldHargaPoin := productItem.HargaRp, productItem.HargaPoin
+
 		// Update fields jika diberikan
+		// Perubahan stok varian lama dicatat sebagai adjustment dan wajib disertai alasan
+		newStock := -1
 		if variantReq.Stok != "" {
 			stok, err := strconv.Atoi(variantReq.Stok)
 			if err != nil {
@@ -737,7 +779,19 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 				})
 				return
 			}
-			productItem.Stok = stok
+			if productItem.ID == 0 {
+				productItem.Stok = stok
+			} else if stok != productItem.Stok {
+				if strings.TrimSpace(req.StockReason) == "" {
+					tx.Rollback()
+					c.JSON(http.StatusBadRequest, gin.H{
+						"success": false,
+						"message": "stockReason is required when changing variant stock",
+					})
+					return
+				}
+				newStock = stok
+			}
 		}
 
 		if variantReq.HargaRp != "" {
@@ -751,7 +805,7 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 				return
 			}
 			productItem.HargaRp = int(math.Round(hargaRp))
-			productItem.HargaPoin = int(math.Round(hargaRp / poinValue))
+			productItem.HargaPoin = utils.HargaPoinFor(productItem.HargaRp, pointRate.Rate)
 		}
 
 		if variantReq.Jumlah != "" {
@@ -781,8 +835,13 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 			productItem.Satuan = variantReq.Satuan
 		}
 
-		// Save variant
-		if err := tx.Save(&productItem).Error; err != nil {
+		// Save variant. Stok varian lama tidak ikut disimpan agar tidak menimpa checkout yang berjalan bersamaan.
+		isNew := productItem.ID == 0
+		saveQuery := tx
+		if !isNew {
+			saveQuery = tx.Omit("Stok")
+		}
+		if err := saveQuery.Save(&productItem).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
@@ -791,11 +850,51 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 			})
 			return
 		}
+
+		if err := utils.RecordPriceChange(tx, productItem.ID, oldHargaRp, oldHargaPoin, productItem.HargaRp, productItem.HargaPoin,
+			models.PriceSourceManual, nil, adminActorID(c)); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Error recording price history",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		if isNew {
+			err = utils.RecordInitialStock(tx, &productItem, adminActorID(c))
+		} else if newStock >= 0 {
+			var movement *models.StockMovement
+			movement, err = utils.SetStockLevel(tx, productItem.ID, newStock, req.StockReason, adminActorID(c))
+			if movement != nil {
+				stockMovements = append(stockMovements, *movement)
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			respondStockError(c, err)
+			return
+		}
+	}
+
+	if len(uploaded) > 0 {
+		images, err := utils.AddProductImages(tx, product.ID, uploaded, product.NameProduk, nil)
+		if err == nil && newImageFilename != "" {
+			err = utils.SetPrimaryProductImage(tx, product.ID, images[0].ID)
+		}
+		if err != nil {
+			tx.Rollback()
+			removeUploads(uploaded)
+			respondProductImageError(c, err)
+			return
+		}
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
+		removeUploads(uploaded)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Transaction commit failed",
@@ -804,13 +903,10 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	// Clean up old image if new one was uploaded
-	if oldImageFilename != "" && newImageFilename != "" {
-		os.Remove("./uploads/" + oldImageFilename)
-	}
+	utils.NotifyLowStock(ctrl.DB, stockMovements...)
 
 	// Reload product with variants
-	if err := ctrl.DB.Preload("ProductItems").First(&product, product.ID).Error; err != nil {
+	if err := ctrl.DB.Preload("ProductItems").Preload("Images", utils.OrderProductImages).First(&product, product.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Error fetching updated product",
@@ -840,8 +936,21 @@ func (ctrl *ProductController) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	// Delete product
-	if err := ctrl.DB.Delete(&product).Error; err != nil {
+	// Delete product beserta galeri dan ulasannya
+	var filenames []string
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if filenames, err = utils.DeleteProductGallery(tx, &product); err != nil {
+			return err
+		}
+		reviewPhotos, err := utils.DeleteProductReviews(tx, product.ID)
+		if err != nil {
+			return err
+		}
+		filenames = append(filenames, reviewPhotos...)
+		return tx.Delete(&product).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Error deleting product",
@@ -850,18 +959,32 @@ func (ctrl *ProductController) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	// Delete image if exists
-	if product.Image != "" {
-		imagePath := "./uploads/" + product.Image
-		if _, err := os.Stat(imagePath); err == nil {
-			if err := os.Remove(imagePath); err != nil {
-				fmt.Printf("Failed to delete image: %v\n", err)
-			}
-		}
-	}
+	// Hapus file gambar yang sudah tidak dipakai
+	utils.RemoveUploads(ctrl.DB, filenames...)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Product deleted successfully",
 	})
 }
+
+// resolveProductCategory mencari kategori produk dari categoryId atau nama/slug kategori.
+// Kategori harus sudah dibuat admin agar salah ketik tidak menghasilkan kategori baru.
+func (ctrl *ProductController) resolveProductCategory(categoryID, kategori string) (*models.Category, error) {
+	if categoryID != "" {
+		var category models.Category
+		if err := ctrl.DB.First(&category, "id = ?", categoryID).Error; err != nil {
+			return nil, errors.New("Category not found")
+		}
+		return &category, nil
+	}
+
+	if kategori == "" {
+		return nil, errors.New("Category is required")
+	}
+	category, err := utils.FindCategory(ctrl.DB, kategori)
+	if err != nil {
+		return nil, errors.New("Category not found: " + kategori)
+	}
+	return category, nil
+}
@@ -0,0 +1,164 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type ReferralController struct {
+	DB *gorm.DB
+}
+
+func NewReferralController(db *gorm.DB) *ReferralController {
+	return &ReferralController{DB: db}
+}
+
+type ReferralSearchResult struct {
+	ID               uint       `json:"id"`
+	Email            string     `json:"email"`
+	Fullname         string     `json:"fullname"`
+	ReferralCode     string     `json:"referralCode"`
+	IsApproved       bool       `json:"isApproved"`
+	JoinedAt         time.Time  `json:"joinedAt"`
+	ReferralUsedAt   *time.Time `json:"referralUsedAt,omitempty"`
+	ReferrerID       *uint      `json:"referrerId"`
+	ReferrerEmail    string     `json:"referrerEmail"`
+	ReferrerFullname string     `json:"referrerFullname"`
+	ReferrerCode     string     `json:"referrerCode"`
+}
+
+// GetUserReferralTree handles GET /referrals/:userId/tree?depth=3&limit=10
+func (ctrl *ReferralController) GetUserReferralTree(c *gin.Context) {
+	user, ok := ctrl.findUser(c)
+	if !ok {
+		return
+	}
+
+	depth, _ := strconv.Atoi(c.DefaultQuery("depth", strconv.Itoa(utils.DefaultReferralTreeDepth)))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	levels, err := utils.GetReferralTree(ctrl.DB, user.ID, depth, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"userId": user.ID,
+			"email":  user.Email,
+			"depth":  utils.ClampReferralTreeDepth(depth),
+			"levels": levels,
+		},
+	})
+}
+
+// GetUserReferralTreeLevel handles GET /referrals/:userId/tree/levels/:level?page=0&limit=10
+func (ctrl *ReferralController) GetUserReferralTreeLevel(c *gin.Context) {
+	user, ok := ctrl.findUser(c)
+	if !ok {
+		return
+	}
+
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil || level < 1 || level > utils.MaxReferralTreeDepth {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Level must be between 1 and " + strconv.Itoa(utils.MaxReferralTreeDepth),
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	result, err := utils.GetReferralTreeLevel(ctrl.DB, user.ID, level, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
+
+// SearchReferrals handles GET /referrals/search?search=...&page=0&limit=10
+// Pencarian cocok ke user yang direferensikan maupun referrer-nya (nama, email, kode referral).
+func (ctrl *ReferralController) SearchReferrals(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	search := strings.ToLower(strings.TrimSpace(c.Query("search")))
+	offset := page * limit
+
+	buildQuery := func() *gorm.DB {
+		query := ctrl.DB.Table("users AS u").
+			Joins("LEFT JOIN details_users d ON d.user_id = u.id").
+			Joins("JOIN users r ON r.id = u.referred_by").
+			Joins("LEFT JOIN details_users rd ON rd.user_id = r.id").
+			Where("u.deleted_at IS NULL")
+
+		if search != "" {
+			like := "%" + search + "%"
+			query = query.Where(`LOWER(u.email) LIKE ? OR LOWER(COALESCE(d.fullname, '')) LIKE ? OR LOWER(u.referral_code) LIKE ?
+				OR LOWER(r.email) LIKE ? OR LOWER(COALESCE(rd.fullname, '')) LIKE ? OR LOWER(r.referral_code) LIKE ?`,
+				like, like, like, like, like, like)
+		}
+		return query
+	}
+
+	var totalRows int64
+	if err := buildQuery().Count(&totalRows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var results []ReferralSearchResult
+	if err := buildQuery().Select(`u.id, u.email, COALESCE(d.fullname, '') AS fullname, u.referral_code, u.is_approved,
+		u.created_at AS joined_at, u.referral_used_at, r.id AS referrer_id, r.email AS referrer_email,
+		COALESCE(rd.fullname, '') AS referrer_fullname, r.referral_code AS referrer_code`).
+		Order("u.created_at DESC").
+		Offset(offset).Limit(limit).
+		Scan(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	totalPage := 0
+	if limit > 0 {
+		totalPage = (int(totalRows) + limit - 1) / limit
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      results,
+		"page":      page,
+		"limit":     limit,
+		"totalPage": totalPage,
+		"totalRows": totalRows,
+	})
+}
+
+func (ctrl *ReferralController) findUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	if err := ctrl.DB.First(&user, c.Param("userId")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "User not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return nil, false
+	}
+	return &user, true
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type ReferralFraudController struct {
+	DB *gorm.DB
+}
+
+func NewReferralFraudController(db *gorm.DB) *ReferralFraudController {
+	return &ReferralFraudController{DB: db}
+}
+
+// GetFraudSignals handles GET /referral-fraud/signals?userId=&type=&page=0&limit=10
+func (ctrl *ReferralFraudController) GetFraudSignals(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := page * limit
+
+	query := ctrl.DB.Model(&models.ReferralFraudSignal{})
+	if userID := c.Query("userId"); userID != "" {
+		query = query.Where("user_id = ? OR related_user_id = ?", userID, userID)
+	}
+	if signalType := c.Query("type"); signalType != "" {
+		query = query.Where("type = ?", signalType)
+	}
+
+	var totalRows int64
+	if err := query.Count(&totalRows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var signals []models.ReferralFraudSignal
+	if err := query.Preload("User", selectUserSummary).Preload("RelatedUser", selectUserSummary).
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&signals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	totalPage := 0
+	if limit > 0 {
+		totalPage = (int(totalRows) + limit - 1) / limit
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      signals,
+		"page":      page,
+		"limit":     limit,
+		"totalPage": totalPage,
+		"totalRows": totalRows,
+	})
+}
+
+// GetHeldBonuses handles GET /referral-fraud/bonuses
+func (ctrl *ReferralFraudController) GetHeldBonuses(c *gin.Context) {
+	var bonuses []models.AfiliasiBonus
+	if err := ctrl.DB.
+		Preload("User", selectUserSummary).
+		Preload("ReferralUser", selectUserSummary).
+		Preload("Pesanan").
+		Where("status = ?", models.BonusOnHold).
+		Order("bonus_received_at ASC").
+		Find(&bonuses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    bonuses,
+	})
+}
+
+// ApproveHeldBonus handles POST /referral-fraud/bonuses/:id/approve
+// Bonus menjadi pending dan masa berlakunya dihitung sejak disetujui.
+func (ctrl *ReferralFraudController) ApproveHeldBonus(c *gin.Context) {
+	ctrl.reviewHeldBonus(c, true)
+}
+
+// RejectHeldBonus handles POST /referral-fraud/bonuses/:id/reject
+func (ctrl *ReferralFraudController) RejectHeldBonus(c *gin.Context) {
+	ctrl.reviewHeldBonus(c, false)
+}
+
+func (ctrl *ReferralFraudController) reviewHeldBonus(c *gin.Context, approve bool) {
+	var reviewerID *uint
+	if userID, ok := c.Get("userId"); ok {
+		id := userID.(uint)
+		reviewerID = &id
+	}
+
+	var bonus models.AfiliasiBonus
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bonus, c.Param("id")).Error; err != nil {
+			return err
+		}
+		if bonus.Status != models.BonusOnHold {
+			return errBonusNotOnHold
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"reviewed_at": now,
+			"reviewed_by": reviewerID,
+		}
+		if approve {
+			rule, err := utils.CommissionRuleForBonus(tx, &bonus, nil)
+			if err != nil {
+				return err
+			}
+			updates["status"] = models.BonusPending
+			updates["bonus_received_at"] = now
+			updates["expiry_date"] = utils.CommissionExpiry(rule, now)
+		} else {
+			updates["status"] = models.BonusVoided
+			updates["voided_at"] = now
+		}
+
+		if err := tx.Model(&bonus).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&bonus, bonus.ID).Error; err != nil {
+			return err
+		}
+		return utils.RefreshAffiliateSummariesForBonuses(tx, bonus)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Bonus not found"})
+		case errors.Is(err, errBonusNotOnHold):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to review bonus: " + err.Error()})
+		}
+		return
+	}
+
+	message := "Bonus approved and can now be claimed"
+	if !approve {
+		message = "Bonus rejected and voided"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    bonus,
+	})
+}
+
+var errBonusNotOnHold = errors.New("bonus is not on hold")
+
+// selectUserSummary membatasi kolom user yang di-preload agar password dan token tidak ikut terkirim
+func selectUserSummary(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "email", "referral_code", "referred_by", "is_approved", "created_at")
+}
@@ -0,0 +1,166 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+type SearchSynonymController struct {
+	DB *gorm.DB
+}
+
+func NewSearchSynonymController(db *gorm.DB) *SearchSynonymController {
+	return &SearchSynonymController{DB: db}
+}
+
+type SearchSynonymRequest struct {
+	Term     string `json:"term" binding:"required"`
+	Synonyms string `json:"synonyms" binding:"required"` // Dipisah koma, mis. "cabe, lombok"
+}
+
+// normalize merapikan istilah dan daftar sinonim agar sama dengan token pencarian
+func (req *SearchSynonymRequest) normalize() (string, string, bool) {
+	term := strings.Join(utils.NormalizeSearchQuery(req.Term), " ")
+	var synonyms []string
+	for _, word := range utils.ParseSynonymList(req.Synonyms) {
+		if word != term {
+			synonyms = append(synonyms, word)
+		}
+	}
+	if term == "" || len(synonyms) == 0 {
+		return "", "", false
+	}
+	return term, strings.Join(synonyms, ","), true
+}
+
+// GetSearchSynonyms handles GET /search-synonyms
+func (ctrl *SearchSynonymController) GetSearchSynonyms(c *gin.Context) {
+	query := ctrl.DB.Order("term ASC")
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		like := "%" + strings.ToLower(search) + "%"
+		query = query.Where("term LIKE ? OR synonyms LIKE ?", like, like)
+	}
+
+	var synonyms []models.SearchSynonym
+	if err := query.Find(&synonyms).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error fetching search synonyms",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": synonyms})
+}
+
+// CreateSearchSynonym handles POST /search-synonyms
+func (ctrl *SearchSynonymController) CreateSearchSynonym(c *gin.Context) {
+	var req SearchSynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	term, synonyms, ok := req.normalize()
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Term and at least one different synonym are required"})
+		return
+	}
+
+	var count int64
+	ctrl.DB.Model(&models.SearchSynonym{}).Where("term = ?", term).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Synonym for this term already exists"})
+		return
+	}
+
+	synonym := models.SearchSynonym{Term: term, Synonyms: synonyms}
+	if err := ctrl.DB.Create(&synonym).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error creating search synonym",
+			"error":   err.Error(),
+		})
+		return
+	}
+	utils.InvalidateSearchSynonyms()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Search synonym created successfully",
+		"data":    synonym,
+	})
+}
+
+// UpdateSearchSynonym handles PATCH /search-synonyms/:id
+func (ctrl *SearchSynonymController) UpdateSearchSynonym(c *gin.Context) {
+	var synonym models.SearchSynonym
+	if err := ctrl.DB.First(&synonym, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Search synonym not found"})
+		return
+	}
+
+	var req SearchSynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	term, synonyms, ok := req.normalize()
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Term and at least one different synonym are required"})
+		return
+	}
+
+	var count int64
+	ctrl.DB.Model(&models.SearchSynonym{}).Where("term = ? AND id <> ?", term, synonym.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Synonym for this term already exists"})
+		return
+	}
+
+	synonym.Term = term
+	synonym.Synonyms = synonyms
+	if err := ctrl.DB.Save(&synonym).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error updating search synonym",
+			"error":   err.Error(),
+		})
+		return
+	}
+	utils.InvalidateSearchSynonyms()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Search synonym updated successfully",
+		"data":    synonym,
+	})
+}
+
+// DeleteSearchSynonym handles DELETE /search-synonyms/:id
+func (ctrl *SearchSynonymController) DeleteSearchSynonym(c *gin.Context) {
+	result := ctrl.DB.Delete(&models.SearchSynonym{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Error deleting search synonym",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Search synonym not found"})
+		return
+	}
+	utils.InvalidateSearchSynonyms()
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Search synonym deleted successfully"})
+}
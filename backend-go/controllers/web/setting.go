@@ -4,11 +4,14 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"backend-go/models"
+	"backend-go/utils"
 )
 
 type SettingController struct {
@@ -56,9 +59,13 @@ func (ctrl *SettingController) GetHargaPoin(c *gin.Context) {
 }
 
 type SetHargaPoinRequest struct {
-	HargaPoin any `json:"hargaPoin" binding:"required"`
+	HargaPoin   any        `json:"hargaPoin" binding:"required"`
+	EffectiveAt *time.Time `json:"effectiveAt"` // Kosong berarti langsung berlaku
+	DryRun      bool       `json:"dryRun"`
+	Note        string     `json:"note"`
 }
 
+// SetHargaPoin handles POST /api/settings/harga-poin
 func (ctrl *SettingController) SetHargaPoin(c *gin.Context) {
 	var req SetHargaPoinRequest
 
@@ -105,77 +112,451 @@ func (ctrl *SettingController) SetHargaPoin(c *gin.Context) {
 		return
 	}
 
-	var setting models.Setting
+	// Dry-run: tampilkan harga lama dan baru per item tanpa menyimpan perubahan
+	if req.DryRun {
+		previews, err := utils.PreviewPointRate(ctrl.DB, hargaPoinInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to fetch product items: " + err.Error(),
+			})
+			return
+		}
 
-	// Cari atau buat setting hargaPoin
-	result := ctrl.DB.Where("key = ?", "hargaPoin").First(&setting)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			// Buat baru jika tidak ditemukan
-			setting = models.Setting{
-				Key:   "hargaPoin",
-				Value: strconv.Itoa(hargaPoinInt),
-			}
-			if err := ctrl.DB.Create(&setting).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "Failed to create setting: " + err.Error(),
-				})
-				return
+		changed := 0
+		for _, p := range previews {
+			if p.OldHargaRp != p.NewHargaRp {
+				changed++
 			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Dry run, no changes saved",
+			"data": gin.H{
+				"hargaPoin":     hargaPoinInt,
+				"total_items":   len(previews),
+				"changed_items": changed,
+				"items":         previews,
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	effectiveAt := now
+	if req.EffectiveAt != nil && req.EffectiveAt.After(now) {
+		effectiveAt = *req.EffectiveAt
+	}
+
+	rate := models.PointRate{
+		Rate:        hargaPoinInt,
+		EffectiveAt: effectiveAt,
+		Note:        req.Note,
+	}
+	if userID, ok := c.Get("userId"); ok {
+		id := userID.(uint)
+		rate.CreatedBy = &id
+	}
+
+	var updatedItems int64
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rate).Error; err != nil {
+			return err
+		}
+
+		// Rate terjadwal akan diberlakukan oleh cron saat effectiveAt tercapai
+		if effectiveAt.After(now) {
+			return nil
+		}
+
+		var err error
+		updatedItems, err = utils.ApplyPointRate(tx, rate.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update harga poin: " + err.Error(),
+		})
+		return
+	}
+
+	if effectiveAt.After(now) {
+		c.JSON(http.StatusCreated, gin.H{
+			"success": true,
+			"message": "Harga Poin scheduled successfully",
+			"data": gin.H{
+				"id":          rate.ID,
+				"hargaPoin":   hargaPoinInt,
+				"effectiveAt": effectiveAt,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Harga Poin updated successfully",
+		"data": gin.H{
+			"id":            rate.ID,
+			"hargaPoin":     hargaPoinInt,
+			"updated_items": updatedItems,
+		},
+	})
+}
+
+// GetHargaPoinHistory handles GET /api/settings/harga-poin/history
+func (ctrl *SettingController) GetHargaPoinHistory(c *gin.Context) {
+	var rates []models.PointRate
+	if err := ctrl.DB.Order("effective_at DESC, id DESC").Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Database error: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rates,
+	})
+}
+
+// CancelHargaPoinSchedule handles DELETE /api/settings/harga-poin/history/:id
+func (ctrl *SettingController) CancelHargaPoinSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	var rate models.PointRate
+	if err := ctrl.DB.First(&rate, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Harga Poin schedule not found",
+			})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
-				"message": "Database error: " + result.Error.Error(),
+				"message": "Database error: " + err.Error(),
 			})
-			return
 		}
-	} else {
-		// Update jika sudah ada
-		setting.Value = strconv.Itoa(hargaPoinInt)
-		if err := ctrl.DB.Save(&setting).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+		return
+	}
+
+	// Versi yang sudah berlaku tetap disimpan sebagai riwayat
+	if rate.AppliedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Harga Poin has already been applied",
+		})
+		return
+	}
+
+	if err := ctrl.DB.Delete(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to cancel schedule: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Harga Poin schedule cancelled successfully",
+	})
+}
+
+// GetPoinExpiry handles GET /api/settings/poin-expiry
+func (ctrl *SettingController) GetPoinExpiry(c *gin.Context) {
+	months, reminderDays := utils.GetPointExpiryConfig(ctrl.DB)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"expiryMonths": months,
+		"reminderDays": reminderDays,
+	})
+}
+
+type SetPoinExpiryRequest struct {
+	ExpiryMonths *int  `json:"expiryMonths" binding:"required"`
+	ReminderDays []int `json:"reminderDays"`
+}
+
+// SetPoinExpiry handles POST /api/settings/poin-expiry
+func (ctrl *SettingController) SetPoinExpiry(c *gin.Context) {
+	var req SetPoinExpiryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	// 0 berarti poin tidak pernah kedaluwarsa
+	if *req.ExpiryMonths < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Expiry months cannot be negative",
+		})
+		return
+	}
+
+	reminderParts := make([]string, 0, len(req.ReminderDays))
+	for _, d := range req.ReminderDays {
+		if d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
 				"success": false,
-				"message": "Failed to update setting: " + err.Error(),
+				"message": "Reminder days must be positive numbers",
 			})
 			return
 		}
+		reminderParts = append(reminderParts, strconv.Itoa(d))
+	}
+
+	settings := []models.Setting{
+		{Key: utils.SettingPoinExpiryMonths, Value: strconv.Itoa(*req.ExpiryMonths)},
+	}
+	if len(reminderParts) > 0 {
+		settings = append(settings, models.Setting{Key: utils.SettingPoinExpiryReminderDays, Value: strings.Join(reminderParts, ",")})
 	}
 
-	// Perbarui hargaRp di semua product items (bukan di product)
-	var productItems []models.ProductItem
-	if err := ctrl.DB.Find(&productItems).Error; err != nil {
+	if err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		for _, setting := range settings {
+			if err := tx.Save(&setting).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"message": "Failed to fetch product items: " + err.Error(),
+			"message": "Failed to update setting: " + err.Error(),
 		})
 		return
 	}
 
-	// Update hargaRp untuk semua product items
-	for _, item := range productItems {
-		// Skip jika hargaPoin item 0
-		if item.HargaPoin == 0 {
-			continue
-		}
+	months, reminderDays := utils.GetPointExpiryConfig(ctrl.DB)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Poin expiry updated successfully",
+		"data": gin.H{
+			"expiryMonths": months,
+			"reminderDays": reminderDays,
+		},
+	})
+}
 
-		// Hitung hargaRp baru
-		item.HargaRp = item.HargaPoin * hargaPoinInt
-		if err := ctrl.DB.Save(&item).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+// GetPoinTransferLimit handles GET /api/settings/poin-transfer-limit
+func (ctrl *SettingController) GetPoinTransferLimit(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"dailyLimit": utils.GetPointTransferDailyLimit(ctrl.DB),
+	})
+}
+
+type SetPoinTransferLimitRequest struct {
+	DailyLimit *int `json:"dailyLimit" binding:"required"`
+}
+
+// SetPoinTransferLimit handles POST /api/settings/poin-transfer-limit
+func (ctrl *SettingController) SetPoinTransferLimit(c *gin.Context) {
+	var req SetPoinTransferLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	// 0 berarti transfer poin dinonaktifkan
+	if *req.DailyLimit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Daily limit cannot be negative",
+		})
+		return
+	}
+
+	setting := models.Setting{Key: utils.SettingPoinTransferDailyLimit, Value: strconv.Itoa(*req.DailyLimit)}
+	if err := ctrl.DB.Save(&setting).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update setting: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Poin transfer limit updated successfully",
+		"data": gin.H{
+			"dailyLimit": *req.DailyLimit,
+		},
+	})
+}
+
+// GetBonusConversionMultiplier handles GET /api/settings/bonus-conversion-multiplier
+func (ctrl *SettingController) GetBonusConversionMultiplier(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"multiplier": utils.GetBonusConversionMultiplier(ctrl.DB),
+	})
+}
+
+type SetBonusConversionMultiplierRequest struct {
+	Multiplier *float64 `json:"multiplier" binding:"required"`
+}
+
+// SetBonusConversionMultiplier handles POST /api/settings/bonus-conversion-multiplier
+func (ctrl *SettingController) SetBonusConversionMultiplier(c *gin.Context) {
+	var req SetBonusConversionMultiplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	// 1 berarti tanpa bonus, 1.1 berarti tambahan 10% poin
+	if *req.Multiplier < 1 || *req.Multiplier > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Multiplier must be between 1 and 5",
+		})
+		return
+	}
+
+	setting := models.Setting{
+		Key:   utils.SettingBonusConversionMultiplier,
+		Value: strconv.FormatFloat(*req.Multiplier, 'f', -1, 64),
+	}
+	if err := ctrl.DB.Save(&setting).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update setting: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Bonus conversion multiplier updated successfully",
+		"data": gin.H{
+			"multiplier": *req.Multiplier,
+		},
+	})
+}
+
+// GetBonusExpiryReminder handles GET /api/settings/bonus-expiry-reminder
+func (ctrl *SettingController) GetBonusExpiryReminder(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"reminderDays": utils.GetBonusExpiryReminderDays(ctrl.DB),
+	})
+}
+
+type SetBonusExpiryReminderRequest struct {
+	ReminderDays []int `json:"reminderDays" binding:"required,min=1"`
+}
+
+// SetBonusExpiryReminder handles POST /api/settings/bonus-expiry-reminder
+func (ctrl *SettingController) SetBonusExpiryReminder(c *gin.Context) {
+	var req SetBonusExpiryReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	reminderParts := make([]string, 0, len(req.ReminderDays))
+	for _, d := range req.ReminderDays {
+		if d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
 				"success": false,
-				"message": "Failed to update product item: " + err.Error(),
+				"message": "Reminder days must be positive numbers",
 			})
 			return
 		}
+		reminderParts = append(reminderParts, strconv.Itoa(d))
+	}
+
+	setting := models.Setting{Key: utils.SettingBonusExpiryReminderDays, Value: strings.Join(reminderParts, ",")}
+	if err := ctrl.DB.Save(&setting).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update setting: " + err.Error(),
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Harga Poin updated successfully",
+		"message": "Bonus expiry reminder updated successfully",
 		"data": gin.H{
-			"hargaPoin":     hargaPoinInt,
-			"updated_items": len(productItems),
+			"reminderDays": utils.GetBonusExpiryReminderDays(ctrl.DB),
+		},
+	})
+}
+
+// GetReferralRewards handles GET /api/settings/referral-rewards
+func (ctrl *SettingController) GetReferralRewards(c *gin.Context) {
+	signupPoints, firstOrderPoints := utils.GetReferralRewardConfig(ctrl.DB)
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"signupPoints":     signupPoints,
+		"firstOrderPoints": firstOrderPoints,
+	})
+}
+
+type SetReferralRewardsRequest struct {
+	SignupPoints     *int `json:"signupPoints" binding:"required"`     // 0 = nonaktif
+	FirstOrderPoints *int `json:"firstOrderPoints" binding:"required"` // 0 = nonaktif
+}
+
+// SetReferralRewards handles POST /api/settings/referral-rewards
+func (ctrl *SettingController) SetReferralRewards(c *gin.Context) {
+	var req SetReferralRewardsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid input: " + err.Error(),
+		})
+		return
+	}
+
+	if *req.SignupPoints < 0 || *req.FirstOrderPoints < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Reward points cannot be negative",
+		})
+		return
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&models.Setting{Key: utils.SettingReferralSignupRewardPoints, Value: strconv.Itoa(*req.SignupPoints)}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&models.Setting{Key: utils.SettingReferralFirstOrderRewardPoints, Value: strconv.Itoa(*req.FirstOrderPoints)}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update setting: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Referral rewards updated successfully",
+		"data": gin.H{
+			"signupPoints":     *req.SignupPoints,
+			"firstOrderPoints": *req.FirstOrderPoints,
 		},
 	})
 }
@@ -234,31 +234,12 @@ func (ctrl *TopUpPoinController) PostTopUp(c *gin.Context) {
 		return
 	}
 
-	// Update user points
-	var userPoints models.UserPoints
-	if err := tx.Where("user_id = ?", input.UserID).First(&userPoints).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			userPoints = models.UserPoints{
-				UserID: input.UserID,
-				Points: input.Points,
-			}
-			if err := tx.Create(&userPoints).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-				return
-			}
-		} else {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-			return
-		}
-	} else {
-		userPoints.Points += input.Points
-		if err := tx.Save(&userPoints).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-			return
-		}
+	// Update user points (dicatat sebagai lot agar bisa kedaluwarsa)
+	userPoints, err := push.CreditPoints(tx, input.UserID, input.Points, models.PointSourceTopUp, topUpData.TopupID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
 	}
 
 	// Commit transaction
@@ -309,34 +290,30 @@ func (ctrl *TopUpPoinController) UpdateTopUp(c *gin.Context) {
 		return
 	}
 
+	tx := ctrl.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
 	// Only process if status changed to "approved"
 	if input.Status == "approved" && topUp.Status != "approved" {
-		var userPoints models.UserPoints
-		if err := ctrl.DB.Where("user_id = ?", topUp.UserID).First(&userPoints).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				userPoints = models.UserPoints{
-					UserID: topUp.UserID,
-					Points: topUp.Points,
-				}
-				if err := ctrl.DB.Create(&userPoints).Error; err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-					return
-				}
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-				return
-			}
-		} else {
-			userPoints.Points += topUp.Points
-			if err := ctrl.DB.Save(&userPoints).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-				return
-			}
+		if _, err := push.CreditPoints(tx, topUp.UserID, topUp.Points, models.PointSourceTopUp, topUp.TopupID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
 		}
 	}
 
 	topUp.Status = input.Status
-	if err := ctrl.DB.Save(&topUp).Error; err != nil {
+	if err := tx.Save(&topUp).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
@@ -14,6 +14,7 @@ import (
 	"gorm.io/gorm"
 
 	"backend-go/models"
+	"backend-go/utils"
 )
 
 type UserController struct {
@@ -194,18 +195,45 @@ func (ctrl *UserController) ApproveUsers(c *gin.Context) {
 		return
 	}
 
-	result := ctrl.DB.Model(&models.User{}).
-		Where("id IN ?", request.UserIds).
-		Update("is_approved", true)
+	var rewards []*models.ReferralReward
+	var updatedCount int64
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id IN ?", request.UserIds).
+			Update("is_approved", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		updatedCount = result.RowsAffected
 
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		var users []models.User
+		if err := tx.Where("id IN ?", request.UserIds).Find(&users).Error; err != nil {
+			return err
+		}
+		for i := range users {
+			reward, err := utils.GrantSignupReward(tx, &users[i])
+			if err != nil {
+				return err
+			}
+			if reward != nil {
+				rewards = append(rewards, reward)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	for _, reward := range rewards {
+		notifyReferralReward(ctrl.DB, reward)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message":      "Users approved successfully",
-		"updatedCount": result.RowsAffected,
+		"message":       "Users approved successfully",
+		"updatedCount":  updatedCount,
+		"rewardedCount": len(rewards),
 	})
 }
 
@@ -226,12 +254,27 @@ func (ctrl *UserController) ApproveUser(c *gin.Context) {
 		return
 	}
 
-	user.IsApproved = true
-	if err := ctrl.DB.Save(&user).Error; err != nil {
+	var reward *models.ReferralReward
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		user.IsApproved = true
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+
+		// Approval berulang aman karena hadiah signup hanya tercatat sekali per user
+		var err error
+		reward, err = utils.GrantSignupReward(tx, &user)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if reward != nil {
+		notifyReferralReward(ctrl.DB, reward)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "User approved successfully"})
 }
 
@@ -341,18 +384,43 @@ func (ctrl *UserController) UpdateUserPoints(c *gin.Context) {
 		return
 	}
 
-	points := models.UserPoints{
-		UserID: user.ID,
-		Points: request.Points,
+	if request.Points < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Points cannot be negative"})
+		return
+	}
+
+	tx := ctrl.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	points, err := utils.LockUserPoints(tx, user.ID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := ctrl.DB.Where("user_id = ?", user.ID).
-		Assign(points).
-		FirstOrCreate(&points).Error; err != nil {
+	// Selisih dicatat sebagai penyesuaian agar lot poin tetap sinkron dengan saldo
+	diff := request.Points - points.Points
+	if diff > 0 {
+		points, err = utils.CreditPoints(tx, user.ID, diff, models.PointSourceAdjustment, "admin")
+	} else if diff < 0 {
+		points, err = utils.DebitPoints(tx, user.ID, -diff, models.PointSourceAdjustment, "admin")
+	}
+	if err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Points updated successfully",
@@ -751,6 +819,16 @@ func (ctrl *UserController) PermanentDeleteUser(c *gin.Context) {
 		return
 	}
 
+	// Ulasan user ikut dihapus sebelum pesanannya, rating produk dihitung ulang
+	reviewPhotos, err := utils.DeleteUserReviews(tx, uint(userID))
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to delete product reviews: %v", err.Error()),
+		})
+		return
+	}
+
 	// 2. Sekarang hapus pesanan (setelah order_items dihapus)
 	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Pesanan{}).Error; err != nil {
 		tx.Rollback()
@@ -771,6 +849,14 @@ func (ctrl *UserController) PermanentDeleteUser(c *gin.Context) {
 		&models.UserPoints{},
 		&models.UserStats{},
 		&models.TotalBonus{},
+		&models.PointLot{},
+		&models.PointHistory{},
+		&models.ReferralReward{},
+		&models.AffiliateSummary{},
+		&models.BonusConversion{},
+		&models.FlashSalePurchase{},
+		&models.ReferralFraudSignal{},
+		&models.PayoutBatchItem{},
 	}
 
 	for _, table := range tablesToDelete {
@@ -783,6 +869,44 @@ func (ctrl *UserController) PermanentDeleteUser(c *gin.Context) {
 		}
 	}
 
+	// Data yang mencatat user ini sebagai pihak lain: transfer poin (kedua sisi), hadiah dan
+	// ringkasan referral sebagai referral, sinyal fraud yang terkait, dan pelaku mutasi stok
+	relatedDeletes := []struct {
+		model interface{}
+		query string
+	}{
+		{&models.PointTransfer{}, "? IN (sender_id, receiver_id)"},
+		{&models.ReferralReward{}, "referral_user_id = ?"},
+		{&models.AffiliateSummary{}, "referral_user_id = ?"},
+	}
+	for _, related := range relatedDeletes {
+		if err := tx.Unscoped().Where(related.query, userID).Delete(related.model).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("Failed to delete related data: %v", err.Error()),
+			})
+			return
+		}
+	}
+
+	relatedUpdates := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.ReferralFraudSignal{}, "related_user_id"},
+		{&models.ReferralFraudSignal{}, "referrer_id"},
+		{&models.StockMovement{}, "actor_id"},
+	}
+	for _, related := range relatedUpdates {
+		if err := tx.Unscoped().Model(related.model).Where(related.column+" = ?", userID).Update(related.column, nil).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("Failed to anonymize related data: %v", err.Error()),
+			})
+			return
+		}
+	}
+
 	// Handle self-referential constraints (Referrals)
 	if err := tx.Unscoped().Model(&models.User{}).Where("referred_by = ?", userID).Update("referred_by", nil).Error; err != nil {
 		tx.Rollback()
@@ -805,5 +929,20 @@ func (ctrl *UserController) PermanentDeleteUser(c *gin.Context) {
 	}
 
 	tx.Commit()
+
+	// Hapus foto ulasan yang sudah tidak dipakai
+	utils.RemoveUploads(ctrl.DB, reviewPhotos...)
+
 	c.JSON(http.StatusOK, gin.H{"message": "User permanently deleted"})
 }
+
+// notifyReferralReward mengirim notifikasi hadiah referral ke penerima setelah transaksi commit
+func notifyReferralReward(db *gorm.DB, reward *models.ReferralReward) {
+	var user models.User
+	if err := db.Select("id", "fcm_token").First(&user, reward.UserID).Error; err != nil {
+		return
+	}
+	if user.FCMToken != "" && utils.IsFcmTokenValid(user.FCMToken) {
+		utils.SendReferralRewardNotification(user.FCMToken, reward.Points, string(reward.Type))
+	}
+}
@@ -23,6 +23,7 @@ import (
 	approutes "backend-go/routes/app"
 	webroutes "backend-go/routes/web"
 	"backend-go/tasks"
+	"backend-go/utils"
 )
 
 const (
@@ -57,11 +58,21 @@ func sendTelegramMessage(chatID int64, message string) error {
 	return nil
 }
 
-// CustomStaticMiddleware adalah handler untuk static files dengan header khusus
+// CustomStaticMiddleware adalah handler untuk static files dengan header khusus.
+// Gambar bisa diminta dalam ukuran lain dengan ?size=thumb|medium|large, dan versi WebP
+// dikirim jika klien mengirim Accept: image/webp (atau ?format=webp).
 func CustomStaticMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Dapatkan hanya nama file dari URL
 		fileName := strings.TrimPrefix(c.Request.URL.Path, "/uploads/")
+
+		// Pilih rendition hanya untuk file upload utama, bukan path di sub folder
+		if !strings.Contains(fileName, "/") {
+			size := utils.ParseImageSize(c.Query("size"))
+			acceptWebP := strings.Contains(c.GetHeader("Accept"), "image/webp") || c.Query("format") == "webp"
+			fileName = utils.ResolveImagePath(fileName, size, acceptWebP)
+			c.Header("Vary", "Accept")
+		}
 		filePath := filepath.Join("./uploads", fileName)
 
 		c.Header("Cache-Control", "public, max-age=31536000, immutable")
@@ -156,6 +167,22 @@ func main() {
 	webroutes.SetupWebRoutes(app, db)
 	approutes.SetupAppRoutes(app, db)
 
+	// Sesuaikan status bonus afiliasi lama sebelum cron berjalan (sekali saja)
+	tasks.MigrateLegacyAffiliateBonuses(db)
+
+	// Kategori teks bebas dan gambar tunggal produk lama dipindahkan ke tabel baru
+	tasks.MigrateProductCategories(db)
+	tasks.MigrateProductImages(db)
+
+	// Index pencarian produk (pg_trgm + tsvector) dan sinonim awal
+	tasks.EnsureSearchIndexes(db)
+
+	// Rendition gambar lama dibuat di background agar start server tidak tertahan
+	go tasks.GenerateMissingRenditions(db)
+
+	// Ringkasan affiliate dibangun saat start agar profil tidak kosong sebelum job malam berjalan
+	go tasks.RebuildAffiliateSummaries(db)
+
 	// Start cron jobs
 	startCronJobs(db)
 
@@ -205,5 +232,104 @@ func startCronJobs(db *gorm.DB) {
 		log.Fatal("Error scheduling cron job:", err)
 	}
 
+	// Schedule bonus expiry reminders and weekly digest (Senin pagi)
+	_, err = c.AddFunc("0 9 * * *", func() {
+		tasks.SendBonusExpiryReminders(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	_, err = c.AddFunc("0 10 * * 1", func() {
+		tasks.SendWeeklyBonusDigest(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	// Schedule affiliate summary rebuild
+	_, err = c.AddFunc("30 0 * * *", func() {
+		tasks.RebuildAffiliateSummaries(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	// Schedule point expiry and reminders
+	_, err = c.AddFunc("0 1 * * *", func() {
+		tasks.ExpirePointLots(db)
+		tasks.SendPointExpiryReminders(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	// Schedule point rate changes
+	_, err = c.AddFunc("*/5 * * * *", func() {
+		tasks.ApplyScheduledPointRates(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	// Schedule daily price lists
+	_, err = c.AddFunc("* * * * *", func() {
+		tasks.ApplyScheduledPriceLists(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	// Schedule poin promo activation
+	_, err = c.AddFunc("* * * * *", func() {
+		tasks.SyncPoinPromos(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	// Schedule flash sale activation and expiry
+	_, err = c.AddFunc("* * * * *", func() {
+		tasks.SyncFlashSales(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	// Schedule nightly product recommendation refresh
+	_, err = c.AddFunc("0 2 * * *", func() {
+		tasks.ComputeProductAffinities(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	// Schedule orphaned upload cleanup
+	_, err = c.AddFunc("0 3 * * *", func() {
+		tasks.CleanupOrphanedUploads(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
+	// Schedule monthly point statement emails
+	_, err = c.AddFunc("0 6 1 * *", func() {
+		tasks.SendLastMonthPointStatements(db)
+	})
+
+	if err != nil {
+		log.Fatal("Error scheduling cron job:", err)
+	}
+
 	c.Start()
 }
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,6 +15,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"backend-go/utils"
 )
 
 // UploadConfig konfigurasi untuk middleware upload
@@ -21,15 +25,32 @@ type UploadConfig struct {
 	Destination string
 	AllowedMIME []string
 	MaxSize     int64
+	MaxFiles    int  // Lebih dari 1 berarti field menerima beberapa file sekaligus
+	Renditions  bool // Buang metadata EXIF dan buat thumbnail/medium/large serta WebP
 }
 
+var imageMIMETypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
 // UploadFile is a simplified wrapper for common image uploads
 func UploadFile(fieldName string) gin.HandlerFunc {
 	return UploadMiddleware(UploadConfig{
 		FieldName:   fieldName,
 		Destination: "./uploads",
-		AllowedMIME: []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+		AllowedMIME: imageMIMETypes,
 		MaxSize:     5 << 20, // 5 MB
+		Renditions:  true,
+	})
+}
+
+// UploadFiles menerima beberapa gambar pada satu field, mis. galeri produk
+func UploadFiles(fieldName string, maxFiles int) gin.HandlerFunc {
+	return UploadMiddleware(UploadConfig{
+		FieldName:   fieldName,
+		Destination: "./uploads",
+		AllowedMIME: imageMIMETypes,
+		MaxSize:     5 << 20, // 5 MB per file
+		MaxFiles:    maxFiles,
+		Renditions:  true,
 	})
 }
 
@@ -42,6 +63,16 @@ func GenerateUniqueFilename() string {
 	return fmt.Sprintf("%d_%s", time.Now().UnixNano(), hex.EncodeToString(b))
 }
 
+// uploadError membawa status HTTP untuk kegagalan upload
+type uploadError struct {
+	status  int
+	message string
+}
+
+func (e *uploadError) Error() string {
+	return e.message
+}
+
 // UploadMiddleware membuat middleware untuk menangani file upload
 func UploadMiddleware(config UploadConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -60,8 +91,13 @@ func UploadMiddleware(config UploadConfig) gin.HandlerFunc {
 			return
 		}
 
+		if config.MaxFiles > 1 {
+			handleMultipleUploads(c, config)
+			return
+		}
+
 		// Dapatkan file dari form
-		file, header, err := c.Request.FormFile(config.FieldName)
+		_, header, err := c.Request.FormFile(config.FieldName)
 		if err != nil {
 			// Handle case where no file is uploaded
 			if errors.Is(err, http.ErrMissingFile) {
@@ -74,85 +110,142 @@ func UploadMiddleware(config UploadConfig) gin.HandlerFunc {
 			})
 			return
 		}
-		defer file.Close()
 
-		// Validasi tipe file
-		buffer := make([]byte, 512)
-		if _, err := file.Read(buffer); err != nil && err != io.EOF {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to read file: " + err.Error(),
-			})
+		newFilename, filePath, mimeType, err := saveUploadedFile(config, header)
+		if err != nil {
+			abortUpload(c, err)
 			return
 		}
 
-		mimeType := http.DetectContentType(buffer)
-		valid := false
-		for _, allowed := range config.AllowedMIME {
-			if mimeType == allowed {
-				valid = true
-				break
-			}
-		}
+		// Tambahkan info file ke context
+		c.Set("filePath", filePath)
+		c.Set("fileName", newFilename)
+		c.Set("fileSize", header.Size)
+		c.Set("fileType", mimeType)
 
-		if !valid {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Invalid file type. Allowed types: " + strings.Join(config.AllowedMIME, ", "),
-			})
-			return
-		}
+		c.Next()
+	}
+}
 
-		// Reset file reader
-		if _, err := file.Seek(0, 0); err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to reset file reader: " + err.Error(),
-			})
+// handleMultipleUploads menyimpan semua file pada field dan menaruh nama-namanya di context "fileNames".
+// Jika satu file gagal, file yang sudah tersimpan dihapus kembali.
+func handleMultipleUploads(c *gin.Context, config UploadConfig) {
+	headers := c.Request.MultipartForm.File[config.FieldName]
+	if len(headers) == 0 {
+		c.Next()
+		return
+	}
+	if len(headers) > config.MaxFiles {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Maximum %d files per upload", config.MaxFiles),
+		})
+		return
+	}
+
+	fileNames := make([]string, 0, len(headers))
+	for _, header := range headers {
+		newFilename, _, _, err := saveUploadedFile(config, header)
+		if err != nil {
+			for _, saved := range fileNames {
+				os.Remove(filepath.Join(config.Destination, saved))
+			}
+			abortUpload(c, err)
 			return
 		}
+		fileNames = append(fileNames, newFilename)
+	}
 
-		// Generate nama file unik
-		ext := filepath.Ext(header.Filename)
-		newFilename := GenerateUniqueFilename() + ext
-		filePath := filepath.Join(config.Destination, newFilename)
-
-		// Buat folder jika belum ada
-		if _, err := os.Stat(config.Destination); os.IsNotExist(err) {
-			if err := os.MkdirAll(config.Destination, 0755); err != nil {
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"error":   "Failed to create upload directory: " + err.Error(),
-				})
-				return
-			}
+	c.Set("fileNames", fileNames)
+	c.Next()
+}
+
+// saveUploadedFile memvalidasi tipe dan ukuran file lalu menyimpannya dengan nama unik
+func saveUploadedFile(config UploadConfig, header *multipart.FileHeader) (string, string, string, error) {
+	if config.MaxSize > 0 && header.Size > config.MaxSize {
+		return "", "", "", &uploadError{http.StatusBadRequest, "File " + header.Filename + " is too large"}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return "", "", "", &uploadError{http.StatusBadRequest, "Failed to open file: " + err.Error()}
+	}
+	defer file.Close()
+
+	// Validasi tipe file
+	buffer := make([]byte, 512)
+	if _, err := file.Read(buffer); err != nil && err != io.EOF {
+		return "", "", "", &uploadError{http.StatusInternalServerError, "Failed to read file: " + err.Error()}
+	}
+
+	mimeType := http.DetectContentType(buffer)
+	valid := false
+	for _, allowed := range config.AllowedMIME {
+		if mimeType == allowed {
+			valid = true
+			break
 		}
+	}
 
-		// Simpan file
-		out, err := os.Create(filePath)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to create file: " + err.Error(),
-			})
-			return
+	if !valid {
+		return "", "", "", &uploadError{http.StatusBadRequest, "Invalid file type. Allowed types: " + strings.Join(config.AllowedMIME, ", ")}
+	}
+
+	// Reset file reader
+	if _, err := file.Seek(0, 0); err != nil {
+		return "", "", "", &uploadError{http.StatusInternalServerError, "Failed to reset file reader: " + err.Error()}
+	}
+
+	// Generate nama file unik
+	ext := filepath.Ext(header.Filename)
+	newFilename := GenerateUniqueFilename() + ext
+	filePath := filepath.Join(config.Destination, newFilename)
+
+	// Buat folder jika belum ada
+	if _, err := os.Stat(config.Destination); os.IsNotExist(err) {
+		if err := os.MkdirAll(config.Destination, 0755); err != nil {
+			return "", "", "", &uploadError{http.StatusInternalServerError, "Failed to create upload directory: " + err.Error()}
 		}
-		defer out.Close()
+	}
 
-		if _, err := io.Copy(out, file); err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to save file: " + err.Error(),
-			})
-			return
+	// Simpan file
+	out, err := os.Create(filePath)
+	if err != nil {
+		return "", "", "", &uploadError{http.StatusInternalServerError, "Failed to create file: " + err.Error()}
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, file); err != nil {
+		os.Remove(filePath)
+		return "", "", "", &uploadError{http.StatusInternalServerError, "Failed to save file: " + err.Error()}
+	}
+	out.Close()
+
+	if config.Renditions {
+		// Metadata dibuang sebelum file bisa diakses; rendition dibuat di background
+		// dan file asli tetap dilayani sampai rendition siap
+		if err := utils.StripImageMetadata(filePath); err != nil {
+			os.Remove(filePath)
+			return "", "", "", &uploadError{http.StatusBadRequest, "Invalid image file: " + err.Error()}
 		}
+		go func() {
+			if err := utils.GenerateImageRenditions(filePath); err != nil {
+				log.Println("Error generating image renditions:", err)
+			}
+		}()
+	}
 
-		// Tambahkan info file ke context
-		c.Set("filePath", filePath)
-		c.Set("fileName", newFilename)
-		c.Set("fileSize", header.Size)
-		c.Set("fileType", mimeType)
+	return newFilename, filePath, mimeType, nil
+}
 
-		c.Next()
+func abortUpload(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	var uploadErr *uploadError
+	if errors.As(err, &uploadErr) {
+		status = uploadErr.status
 	}
+	c.AbortWithStatusJSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// AffiliateSummary adalah ringkasan bulanan affiliate (UserID) untuk satu referral langsung.
+// Diperbarui saat pesanan/bonus berubah status dan dibangun ulang oleh job harian.
+type AffiliateSummary struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement"`
+	UserID           uint      `gorm:"not null;uniqueIndex:idx_affiliate_summary"`
+	ReferralUserID   uint      `gorm:"not null;uniqueIndex:idx_affiliate_summary"`
+	Month            time.Time `gorm:"type:date;not null;uniqueIndex:idx_affiliate_summary"` // Tanggal 1 bulan tersebut
+	MonthlySpent     int64     `gorm:"not null;default:0"`                                   // Belanja delivered referral
+	BonusLevel1      float64   `gorm:"type:decimal(12,2);not null;default:0"`
+	BonusLevel1Count int       `gorm:"not null;default:0"`
+	BonusLevel2      float64   `gorm:"type:decimal(12,2);not null;default:0"` // Bonus dari downline referral ini
+	BonusLevel2Count int       `gorm:"not null;default:0"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
+}
+
+func (AffiliateSummary) TableName() string {
+	return "affiliate_summaries"
+}
@@ -7,24 +7,37 @@ import (
 type AfiliasiBonusStatus string
 
 const (
-	BonusPending     AfiliasiBonusStatus = "pending"
-	BonusClaimed     AfiliasiBonusStatus = "claimed"
-	BonusExpired     AfiliasiBonusStatus = "expired"
-	BonusTransferred AfiliasiBonusStatus = "transferred"
+	BonusAwaitingDelivery AfiliasiBonusStatus = "awaiting_delivery" // Provisional, menunggu pesanan delivered
+	BonusPending          AfiliasiBonusStatus = "pending"           // Bisa diklaim
+	BonusClaimed          AfiliasiBonusStatus = "claimed"
+	BonusExpired          AfiliasiBonusStatus = "expired"
+	BonusTransferred      AfiliasiBonusStatus = "transferred"
+	BonusVoided           AfiliasiBonusStatus = "voided"    // Pesanan dibatalkan atau dihapus
+	BonusOnHold           AfiliasiBonusStatus = "on_hold"   // Terindikasi fraud, menunggu review admin
+	BonusConverted        AfiliasiBonusStatus = "converted" // Ditukar menjadi poin, tidak ikut payout
 )
 
 type AfiliasiBonus struct {
-	ID              uint                `gorm:"primaryKey;autoIncrement"`
-	UserId          uint                `gorm:"not null;index"`
-	ReferralUserId  uint                `gorm:"not null;index"`
-	PesananId       uint                `gorm:"not null;index"`
-	BonusAmount     float64             `gorm:"type:decimal(12,2)"`
-	BonusLevel      int                 `gorm:"not null"`
-	ExpiryDate      time.Time           `gorm:"not null"`
-	Status          AfiliasiBonusStatus `gorm:"type:varchar(20);not null;default:'pending'"`
-	ClaimedAt       *time.Time          `gorm:"default:null"`
-	BonusReceivedAt time.Time           `gorm:"not null"`
-	TransferredAt   *time.Time          `gorm:"default:null"`
+	ID                uint                `gorm:"primaryKey;autoIncrement"`
+	UserId            uint                `gorm:"not null;index"`
+	ReferralUserId    uint                `gorm:"not null;index"`
+	PesananId         uint                `gorm:"not null;index"`
+	BonusAmount       float64             `gorm:"type:decimal(12,2)"`
+	BonusLevel        int                 `gorm:"not null"`
+	ExpiryDate        time.Time           `gorm:"not null"`
+	Status            AfiliasiBonusStatus `gorm:"type:varchar(20);not null;default:'awaiting_delivery'"`
+	ClaimedAt         *time.Time          `gorm:"default:null"`
+	BonusReceivedAt   time.Time           `gorm:"not null"`
+	TransferredAt     *time.Time          `gorm:"default:null"`
+	VoidedAt          *time.Time          `gorm:"default:null"`
+	CommissionRuleID  *uint               `gorm:"index"`             // Versi aturan komisi yang menghasilkan bonus ini
+	PayoutBatchID     *uint               `gorm:"index"`             // Batch pembayaran yang memuat bonus ini
+	HoldReason        string              `gorm:"type:varchar(255)"` // Terisi jika bonus harus direview admin sebelum bisa diklaim
+	ReviewedAt        *time.Time          `gorm:"default:null"`
+	ReviewedBy        *uint               `gorm:"default:null"`
+	BonusConversionID *uint               `gorm:"index"` // Konversi bonus ke poin yang memuat bonus ini
+	ConvertedAt       *time.Time          `gorm:"default:null"`
+	LastReminderDays  int                 `gorm:"not null;default:0"` // Ambang pengingat kedaluwarsa terakhir yang sudah dikirim (hari)
 
 	// Associations
 	User         User    `gorm:"foreignKey:UserId;references:ID"`
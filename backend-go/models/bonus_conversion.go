@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// BonusConversion mencatat penukaran bonus afiliasi yang sudah diklaim menjadi poin
+type BonusConversion struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	ConversionID   string    `gorm:"type:varchar(50);unique;not null"` // Dipakai sebagai referensi point_history
+	IdempotencyKey string    `gorm:"type:varchar(255);unique"`
+	UserID         uint      `gorm:"not null;index"`
+	BonusAmount    float64   `gorm:"type:decimal(12,2);not null"` // Total Rupiah bonus yang ditukar
+	PointRateID    *uint     `gorm:"default:null"`
+	PointRate      int       `gorm:"not null"` // Nilai 1 poin (Rp) saat konversi
+	Multiplier     float64   `gorm:"type:decimal(6,3);not null;default:1"`
+	Points         int       `gorm:"not null"`
+	BonusCount     int       `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+
+	Bonuses []AfiliasiBonus `gorm:"foreignKey:BonusConversionID"`
+}
+
+func (BonusConversion) TableName() string {
+	return "bonus_conversions"
+}
@@ -5,14 +5,16 @@ import (
 )
 
 type Cart struct {
-	ID            uint      `gorm:"primaryKey;autoIncrement"`
-	UserID        uint      `gorm:"not null;index"`
-	ProductItemID uint      `gorm:"not null;index"`
-	Quantity      int       `gorm:"not null"`
-	Notes         string    `gorm:"type:text"`
-	Status        string    `gorm:"type:varchar(50);not null;default:'active'"`
-	CreatedAt     time.Time `gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	UserID         uint      `gorm:"not null;index"`
+	ProductItemID  uint      `gorm:"not null;index"`
+	Quantity       int       `gorm:"not null"`
+	Notes          string    `gorm:"type:text"`
+	Status         string    `gorm:"type:varchar(50);not null;default:'active'"`
+	HargaRpAdded   int       `gorm:"not null;default:0"` // Harga saat item dimasukkan ke keranjang (0 = data lama)
+	HargaPoinAdded int       `gorm:"not null;default:0"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
 
 	// Relationships
 	User        *User        `gorm:"foreignKey:UserID"`
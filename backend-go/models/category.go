@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// Category adalah kategori produk yang bisa bertingkat (parent/child)
+type Category struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ParentID     *uint     `gorm:"index" json:"parentId"`
+	Name         string    `gorm:"type:varchar(100);not null" json:"name"`
+	Slug         string    `gorm:"type:varchar(120);uniqueIndex;not null" json:"slug"`
+	Icon         string    `gorm:"type:varchar(255)" json:"icon"`
+	DisplayOrder int       `gorm:"not null;default:0" json:"displayOrder"`
+	IsActive     bool      `gorm:"not null;default:true" json:"isActive"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Parent   *Category  `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
+	Children []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+type CommissionBaseType string
+
+const (
+	CommissionBaseFixed CommissionBaseType = "fixed" // Bonus dihitung dari nominal tetap (BaseAmount)
+	CommissionBaseOrder CommissionBaseType = "order" // Bonus dihitung dari persentase nilai pesanan (BasePercentage)
+)
+
+// CommissionRule adalah satu versi aturan komisi afiliasi. Versi tidak pernah diubah
+// setelah dibuat; perubahan aturan dilakukan dengan membuat versi baru lalu mengaktifkannya.
+type CommissionRule struct {
+	ID               uint               `gorm:"primaryKey;autoIncrement"`
+	Version          int                `gorm:"not null;uniqueIndex"`
+	BaseType         CommissionBaseType `gorm:"type:varchar(20);not null;default:'fixed'"`
+	BaseAmount       float64            `gorm:"type:decimal(12,2);not null;default:0"`
+	BasePercentage   float64            `gorm:"type:decimal(5,2);not null;default:0"`
+	Threshold        float64            `gorm:"type:decimal(12,2);not null;default:0"` // Minimal nilai pesanan (Rp)
+	ExpiryMonths     int                `gorm:"not null;default:0"`
+	ExpiryDays       int                `gorm:"not null;default:0"`
+	MaxBonusPerOrder float64            `gorm:"type:decimal(12,2);not null;default:0"` // Batas total bonus semua level per pesanan, 0 berarti tanpa batas
+	LifetimeCap      float64            `gorm:"type:decimal(12,2);not null;default:0"` // Batas total bonus yang bisa diklaim user, 0 berarti tanpa batas
+	IsActive         bool               `gorm:"not null;default:false;index"`
+	ActivatedAt      *time.Time         `gorm:"default:null"`
+	Note             string             `gorm:"type:varchar(255)"`
+	CreatedBy        *uint              `gorm:"default:null"`
+	CreatedAt        time.Time          `gorm:"autoCreateTime"`
+
+	Levels []CommissionRuleLevel `gorm:"foreignKey:RuleID;constraint:OnDelete:CASCADE"`
+}
+
+func (CommissionRule) TableName() string {
+	return "commission_rules"
+}
+
+// CommissionRuleLevel adalah persentase bonus untuk satu level referral
+type CommissionRuleLevel struct {
+	ID         uint    `gorm:"primaryKey;autoIncrement"`
+	RuleID     uint    `gorm:"not null;uniqueIndex:idx_commission_rule_level"`
+	Level      int     `gorm:"not null;uniqueIndex:idx_commission_rule_level"`
+	Percentage float64 `gorm:"type:decimal(5,2);not null"`
+}
+
+func (CommissionRuleLevel) TableName() string {
+	return "commission_rule_levels"
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// FlashSale adalah harga promo terbatas waktu dan kuota untuk satu varian produk.
+// IsActive diatur otomatis oleh scheduler berdasarkan StartAt/EndAt dan dimatikan saat kuota habis.
+type FlashSale struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name          string    `gorm:"type:varchar(255);not null" json:"name"`
+	ProductItemID uint      `gorm:"not null;index" json:"productItemId"`
+	HargaRp       int       `gorm:"not null" json:"hargaRp"`
+	HargaPoin     int       `gorm:"not null" json:"hargaPoin"`
+	Quota         int       `gorm:"not null" json:"quota"` // Jumlah unit yang dijual dengan harga promo
+	SoldQuantity  int       `gorm:"not null;default:0" json:"soldQuantity"`
+	MaxPerUser    int       `gorm:"not null;default:0" json:"maxPerUser"` // 0 berarti tanpa batas
+	StartAt       time.Time `gorm:"not null;index" json:"startAt"`
+	EndAt         time.Time `gorm:"not null;index" json:"endAt"`
+	IsActive      bool      `gorm:"not null;default:false;index" json:"isActive"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	ProductItem *ProductItem `gorm:"foreignKey:ProductItemID" json:"productItem,omitempty"`
+}
+
+// FlashSalePurchase mencatat unit promo yang diambil satu pesanan.
+// ReleasedAt terisi jika pesanan dibatalkan dan kuota dikembalikan.
+type FlashSalePurchase struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	FlashSaleID uint       `gorm:"not null;index" json:"flashSaleId"`
+	UserID      uint       `gorm:"not null;index" json:"userId"`
+	PesananID   uint       `gorm:"not null;index" json:"pesananId"`
+	Quantity    int        `gorm:"not null" json:"quantity"`
+	ReleasedAt  *time.Time `json:"releasedAt"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// FlashSaleOffer adalah info promo yang ditampilkan di aplikasi bersama harga normal varian
+type FlashSaleOffer struct {
+	FlashSaleID   uint      `json:"flashSaleId"`
+	Name          string    `json:"name"`
+	HargaRp       int       `json:"hargaRp"`
+	HargaPoin     int       `json:"hargaPoin"`
+	Remaining     int       `json:"remaining"`
+	MaxPerUser    int       `json:"maxPerUser"`
+	UserRemaining *int      `json:"userRemaining,omitempty"` // Sisa jatah user, nil jika tanpa batas
+	EndAt         time.Time `json:"endAt"`
+}
+
+func (FlashSale) TableName() string {
+	return "flash_sales"
+}
+
+func (FlashSalePurchase) TableName() string {
+	return "flash_sale_purchases"
+}
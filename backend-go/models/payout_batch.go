@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+type PayoutBatchStatus string
+
+const (
+	PayoutDraft     PayoutBatchStatus = "draft" // Bonus sudah dikunci ke batch, transfer belum dilakukan
+	PayoutPaid      PayoutBatchStatus = "paid"
+	PayoutCancelled PayoutBatchStatus = "cancelled"
+)
+
+// PayoutBatch mengelompokkan bonus afiliasi yang sudah diklaim untuk ditransfer sekaligus
+type PayoutBatch struct {
+	ID           uint              `gorm:"primaryKey;autoIncrement"`
+	BatchNumber  string            `gorm:"type:varchar(50);unique;not null"`
+	Status       PayoutBatchStatus `gorm:"type:varchar(20);not null;default:'draft';index"`
+	PeriodStart  *time.Time        `gorm:"default:null"` // Filter claimed_at, kosong = semua bonus claimed
+	PeriodEnd    *time.Time        `gorm:"default:null"`
+	TotalAmount  float64           `gorm:"type:decimal(14,2);not null;default:0"`
+	TotalBonuses int               `gorm:"not null;default:0"`
+	TotalUsers   int               `gorm:"not null;default:0"`
+	CreatedBy    *uint             `gorm:"default:null"`
+	PaidAt       *time.Time        `gorm:"default:null"`
+	PaidBy       *uint             `gorm:"default:null"`
+	CreatedAt    time.Time         `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time         `gorm:"autoUpdateTime"`
+
+	Items []PayoutBatchItem `gorm:"foreignKey:BatchID;constraint:OnDelete:CASCADE"`
+}
+
+func (PayoutBatch) TableName() string {
+	return "payout_batches"
+}
+
+// PayoutBatchItem adalah total transfer satu affiliate dalam batch.
+// Data rekening disalin saat batch dibuat agar file transfer tidak berubah.
+type PayoutBatchItem struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	BatchID       uint      `gorm:"not null;index"`
+	UserID        uint      `gorm:"not null;index"`
+	BankAccountID uint      `gorm:"not null"`
+	AccountHolder string    `gorm:"type:varchar(255);not null"`
+	BankName      string    `gorm:"type:varchar(255);not null"`
+	AccountNumber string    `gorm:"type:varchar(255);not null"`
+	Amount        float64   `gorm:"type:decimal(14,2);not null"`
+	BonusCount    int       `gorm:"not null"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+
+	User *User `gorm:"foreignKey:UserID"`
+}
+
+func (PayoutBatchItem) TableName() string {
+	return "payout_batch_items"
+}
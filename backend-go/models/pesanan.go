@@ -27,6 +27,8 @@ type Pesanan struct {
 	HargaRp          int           `gorm:"type:integer"`
 	HargaPoin        int           `gorm:"type:integer"`
 	Ongkir           int           `gorm:"type:integer"`
+	PointRateID      *uint         `gorm:"index"`        // Versi nilai poin yang dipakai saat pesanan dibuat
+	PointRateValue   int           `gorm:"type:integer"` // Nilai 1 poin (Rp) saat pesanan dibuat
 	TotalBayar       int           `gorm:"not null"`
 	PaymentStatus    PaymentStatus `gorm:"type:varchar(50);not null;default:'unpaid'"`
 	Status           PesananStatus `gorm:"type:varchar(50);not null;default:'pending'"`
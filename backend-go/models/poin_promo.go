@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// PoinPromo adalah kampanye promo terjadwal untuk paket poin, mis. "top up 500 gratis 50".
+// IsActive diatur otomatis oleh scheduler berdasarkan StartAt dan EndAt.
+type PoinPromo struct {
+	ID                 uint      `gorm:"primaryKey;autoIncrement"`
+	Name               string    `gorm:"type:varchar(255);not null"`
+	PoinID             uint      `gorm:"not null;index"`
+	BonusPoints        int       `gorm:"not null;default:0"`
+	DiscountPercentage float64   `gorm:"type:decimal(5,2);not null;default:0"`
+	MaxPerUser         int       `gorm:"not null;default:0"` // 0 berarti tanpa batas
+	StartAt            time.Time `gorm:"not null;index"`
+	EndAt              time.Time `gorm:"not null;index"`
+	IsActive           bool      `gorm:"not null;default:false"`
+
+	// Diskon paket sebelum promo aktif, dipulihkan saat promo berakhir
+	DiscountApplied            bool     `gorm:"not null;default:false"` // Aktivasi memasang diskon promo pada paket
+	PreviousPromoProductID     string   `gorm:"type:varchar(255)"`
+	PreviousDiscountPercentage *float64 `gorm:"type:decimal(5,2)"` // nil = paket tidak punya diskon
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	Poin *Poin `gorm:"foreignKey:PoinID"`
+}
+
+func (PoinPromo) TableName() string {
+	return "poin_promos"
+}
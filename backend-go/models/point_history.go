@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// PointHistory adalah buku besar mutasi poin user. Points bernilai positif untuk
+// penambahan dan negatif untuk pengurangan.
+type PointHistory struct {
+	ID           uint        `gorm:"primaryKey;autoIncrement"`
+	UserID       uint        `gorm:"not null;index"`
+	Source       PointSource `gorm:"type:varchar(50);not null;index"`
+	Reference    string      `gorm:"type:varchar(255)"`
+	Points       int         `gorm:"not null"`
+	BalanceAfter int         `gorm:"not null"`
+	CreatedAt    time.Time   `gorm:"autoCreateTime;index"`
+
+	User *User `gorm:"foreignKey:UserID"`
+}
+
+func (PointHistory) TableName() string {
+	return "point_history"
+}
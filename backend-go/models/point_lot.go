@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+type PointSource string
+
+const (
+	PointSourceTopUp        PointSource = "topup"
+	PointSourceAdjustment   PointSource = "adjustment"
+	PointSourceOrder        PointSource = "order"
+	PointSourceExpiry       PointSource = "expiry"
+	PointSourceTransferIn   PointSource = "transfer_in"
+	PointSourceTransferOut  PointSource = "transfer_out"
+	PointSourcePromoBonus   PointSource = "promo_bonus"
+	PointSourceRefund       PointSource = "refund"
+	PointSourceBonusConvert PointSource = "bonus_conversion" // Bonus afiliasi yang ditukar menjadi poin
+	PointSourceReferral     PointSource = "referral_reward"  // Hadiah referral sekali-bayar
+)
+
+// PointLot mencatat setiap penambahan poin agar bisa kedaluwarsa dan dipakai secara FIFO
+type PointLot struct {
+	ID               uint        `gorm:"primaryKey;autoIncrement"`
+	UserID           uint        `gorm:"not null;index"`
+	Source           PointSource `gorm:"type:varchar(50);not null"`
+	Reference        string      `gorm:"type:varchar(255)"`
+	Points           int         `gorm:"not null"`
+	Remaining        int         `gorm:"not null"`
+	CreditedAt       time.Time   `gorm:"not null"`
+	ExpiresAt        *time.Time  `gorm:"index"`
+	ExpiredAt        *time.Time  `gorm:"default:null"`
+	LastReminderDays int         `gorm:"not null;default:0"` // Ambang pengingat terakhir yang sudah dikirim (hari)
+	CreatedAt        time.Time   `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time   `gorm:"autoUpdateTime"`
+
+	User *User `gorm:"foreignKey:UserID"`
+}
+
+func (PointLot) TableName() string {
+	return "point_lots"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// PointRate adalah riwayat nilai tukar 1 poin ke Rupiah. Rate baru bisa dijadwalkan
+// dan baru berlaku (serta harga produk dihitung ulang) saat AppliedAt terisi.
+type PointRate struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement"`
+	Rate        int        `gorm:"not null"`
+	EffectiveAt time.Time  `gorm:"not null;index"`
+	AppliedAt   *time.Time `gorm:"default:null;index"`
+	CreatedBy   *uint      `gorm:"default:null"`
+	Note        string     `gorm:"type:varchar(255)"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime"`
+}
+
+func (PointRate) TableName() string {
+	return "point_rates"
+}
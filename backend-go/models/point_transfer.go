@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+type PointTransferStatus string
+
+const (
+	TransferAwaitingOtp PointTransferStatus = "awaiting_otp"
+	TransferCompleted   PointTransferStatus = "completed"
+	TransferExpired     PointTransferStatus = "expired"
+	TransferFailed      PointTransferStatus = "failed"
+)
+
+type PointTransfer struct {
+	ID             uint                `gorm:"primaryKey;autoIncrement"`
+	TransferID     string              `gorm:"type:varchar(255);not null;uniqueIndex"`
+	IdempotencyKey string              `gorm:"type:varchar(255);not null;uniqueIndex"`
+	SenderID       uint                `gorm:"not null;index"`
+	ReceiverID     uint                `gorm:"not null;index"`
+	Points         int                 `gorm:"not null"`
+	Note           string              `gorm:"type:varchar(255)"`
+	Status         PointTransferStatus `gorm:"type:varchar(20);not null;default:'awaiting_otp'"`
+	Otp            *string             `gorm:"type:varchar(255)" json:"-"`
+	OtpExpires     *time.Time          `json:"-"`
+	OtpAttempts    int                 `gorm:"not null;default:0" json:"-"`
+	CompletedAt    *time.Time          `gorm:"default:null"`
+	CreatedAt      time.Time           `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time           `gorm:"autoUpdateTime"`
+
+	Sender   User `gorm:"foreignKey:SenderID;references:ID"`
+	Receiver User `gorm:"foreignKey:ReceiverID;references:ID"`
+}
+
+func (PointTransfer) TableName() string {
+	return "point_transfers"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+type PriceChangeSource string
+
+const (
+	PriceSourceManual    PriceChangeSource = "manual"     // Diubah admin lewat form produk
+	PriceSourceImport    PriceChangeSource = "import"     // Import spreadsheet katalog
+	PriceSourcePriceList PriceChangeSource = "price_list" // Daftar harga terjadwal
+	PriceSourcePointRate PriceChangeSource = "point_rate" // Dihitung ulang karena nilai poin berubah
+)
+
+// PriceHistory mencatat setiap perubahan harga varian untuk laporan
+type PriceHistory struct {
+	ID            uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductItemID uint              `gorm:"not null;index" json:"productItemId"`
+	OldHargaRp    int               `gorm:"not null" json:"oldHargaRp"`
+	OldHargaPoin  int               `gorm:"not null" json:"oldHargaPoin"`
+	HargaRp       int               `gorm:"not null" json:"hargaRp"`
+	HargaPoin     int               `gorm:"not null" json:"hargaPoin"`
+	Source        PriceChangeSource `gorm:"type:varchar(20);not null" json:"source"`
+	ReferenceID   *uint             `gorm:"default:null" json:"referenceId"` // ID PriceList atau PointRate sesuai Source
+	ChangedBy     *uint             `gorm:"default:null" json:"changedBy"`
+	CreatedAt     time.Time         `gorm:"autoCreateTime;index" json:"createdAt"`
+
+	ProductItem *ProductItem `gorm:"foreignKey:ProductItemID" json:"productItem,omitempty"`
+}
+
+func (PriceHistory) TableName() string {
+	return "price_histories"
+}
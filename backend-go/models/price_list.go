@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// PriceList adalah daftar harga terjadwal, mis. harga cabai dan bawang untuk besok pagi.
+// Harga baru berlaku saat AppliedAt terisi oleh cron atau oleh admin secara manual.
+type PriceList struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
+	EffectiveAt time.Time  `gorm:"not null;index" json:"effectiveAt"`
+	AppliedAt   *time.Time `gorm:"default:null;index" json:"appliedAt"`
+	CreatedBy   *uint      `gorm:"default:null" json:"createdBy"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Items []PriceListItem `gorm:"foreignKey:PriceListID" json:"items,omitempty"`
+}
+
+func (PriceList) TableName() string {
+	return "price_lists"
+}
+
+// PriceListItem adalah harga Rupiah baru untuk satu varian dalam daftar harga
+type PriceListItem struct {
+	ID            uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	PriceListID   uint `gorm:"not null;uniqueIndex:idx_price_list_item" json:"priceListId"`
+	ProductItemID uint `gorm:"not null;uniqueIndex:idx_price_list_item;index" json:"productItemId"`
+	HargaRp       int  `gorm:"not null" json:"hargaRp"`
+
+	ProductItem *ProductItem `gorm:"foreignKey:ProductItemID" json:"productItem,omitempty"`
+}
+
+func (PriceListItem) TableName() string {
+	return "price_list_items"
+}
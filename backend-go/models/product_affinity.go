@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductAffinity adalah seberapa sering RelatedProductID dibeli bersama ProductID dalam satu pesanan.
+// Dihitung ulang setiap malam dari riwayat OrderItem.
+type ProductAffinity struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID        uint      `gorm:"not null;uniqueIndex:idx_product_affinity_pair" json:"productId"`
+	RelatedProductID uint      `gorm:"not null;uniqueIndex:idx_product_affinity_pair" json:"relatedProductId"`
+	CoPurchaseCount  int       `gorm:"not null" json:"coPurchaseCount"` // Jumlah pesanan berisi kedua produk
+	Confidence       float64   `gorm:"not null" json:"confidence"`      // Peluang produk terkait ikut dibeli saat ProductID dibeli
+	Lift             float64   `gorm:"not null" json:"lift"`            // Confidence dibanding popularitas umum produk terkait
+	Score            float64   `gorm:"not null;index" json:"score"`
+	ComputedAt       time.Time `gorm:"not null" json:"computedAt"`
+}
+
+func (ProductAffinity) TableName() string {
+	return "product_affinities"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductImage adalah satu gambar pada galeri produk. Gambar bisa dikaitkan ke varian tertentu
+// melalui ProductItemID. Tepat satu gambar per produk menjadi gambar utama (IsPrimary).
+type ProductImage struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	ProductID     uint      `gorm:"not null;index"`
+	ProductItemID *uint     `gorm:"index"`
+	Filename      string    `gorm:"type:varchar(255);not null"`
+	AltText       string    `gorm:"type:varchar(255)"`
+	Position      int       `gorm:"not null;default:0"`
+	IsPrimary     bool      `gorm:"not null;default:false"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
+
+func (ProductImage) TableName() string {
+	return "product_images"
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"  // Menunggu moderasi admin
+	ReviewApproved ReviewStatus = "approved" // Tampil di aplikasi dan dihitung ke rating produk
+	ReviewHidden   ReviewStatus = "hidden"   // Disembunyikan admin
+)
+
+// ProductReview adalah ulasan pembeli untuk satu OrderItem dari pesanan yang sudah delivered.
+// Setiap OrderItem hanya bisa diulas sekali.
+type ProductReview struct {
+	ID            uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID     uint         `gorm:"not null;index" json:"productId"`
+	ProductItemID uint         `gorm:"not null;index" json:"productItemId"`
+	OrderItemID   uint         `gorm:"not null;uniqueIndex" json:"orderItemId"`
+	UserID        uint         `gorm:"not null;index" json:"userId"`
+	Rating        int          `gorm:"not null" json:"rating"` // 1 sampai 5 bintang
+	Comment       string       `gorm:"type:text" json:"comment"`
+	Status        ReviewStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	AdminReply    string       `gorm:"type:text" json:"adminReply"`
+	RepliedAt     *time.Time   `json:"repliedAt"`
+	RepliedBy     *uint        `json:"repliedBy"`
+	ModeratedAt   *time.Time   `json:"moderatedAt"`
+	ModeratedBy   *uint        `json:"moderatedBy"`
+	CreatedAt     time.Time    `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time    `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Photos       []ReviewPhoto `gorm:"foreignKey:ReviewID" json:"photos"`
+	Product      *Product      `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	ProductItem  *ProductItem  `gorm:"foreignKey:ProductItemID" json:"productItem,omitempty"`
+	User         *User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ReviewerName string        `gorm:"-" json:"reviewerName"`
+}
+
+// ReviewPhoto adalah foto yang dilampirkan pembeli pada ulasan
+type ReviewPhoto struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ReviewID  uint      `gorm:"not null;index" json:"reviewId"`
+	Filename  string    `gorm:"type:varchar(255);not null" json:"filename"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+func (ProductReview) TableName() string {
+	return "product_reviews"
+}
+
+func (ReviewPhoto) TableName() string {
+	return "review_photos"
+}
@@ -5,34 +5,44 @@ import (
 )
 
 type Product struct {
-	ID         uint      `gorm:"primaryKey;autoIncrement"`
-	NameProduk string    `gorm:"type:varchar(100);not null" validate:"required,min=3,max=100"`
-	Deskripsi  string    `gorm:"type:text;not null"`
-	Kategori   string    `gorm:"type:varchar(255);not null;index"`
-	Image      string    `gorm:"type:varchar(255)"`
-	CreatedAt  time.Time `gorm:"autoCreateTime"`
-	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
-
-	ProductItems []ProductItem `gorm:"foreignKey:ProductID"`
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	NameProduk    string    `gorm:"type:varchar(100);not null" validate:"required,min=3,max=100"`
+	Deskripsi     string    `gorm:"type:text;not null"`
+	Kategori      string    `gorm:"type:varchar(255);not null;index"` // Nama kategori, disinkronkan dari CategoryID
+	CategoryID    *uint     `gorm:"index"`
+	Image         string    `gorm:"type:varchar(255)"`                          // Gambar utama, disinkronkan dari galeri ProductImage
+	RatingAverage float64   `gorm:"type:decimal(3,2);not null;default:0;index"` // Rata-rata ulasan yang disetujui
+	RatingCount   int       `gorm:"not null;default:0"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+
+	Category     *Category      `gorm:"foreignKey:CategoryID"`
+	ProductItems []ProductItem  `gorm:"foreignKey:ProductID"`
+	Images       []ProductImage `gorm:"foreignKey:ProductID"`
 
 	Favorites []Favorite `gorm:"foreignKey:ProductID"`
 }
 
 type ProductItem struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement"`
-	ProductID uint      `gorm:"not null;index"`
-	Stok      int       `gorm:"not null;default:0" validate:"min=0"` //stok
-	HargaPoin int       `gorm:"not null"`
-	HargaRp   int       `gorm:"not null"`
-	Jumlah    int       `gorm:"not null"`                        //misalnya 1kg, 500gr, 1buah, 1ikat
-	Satuan    string    `gorm:"type:varchar(50);not null;index"` //misalnya kg, gr, buah, ikat
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
-
-	Product *Product `gorm:"foreignKey:ProductID"`
+	ID               uint      `gorm:"primaryKey;autoIncrement"`
+	ProductID        uint      `gorm:"not null;index"`
+	Stok             int       `gorm:"not null;default:0" validate:"min=0"` //stok
+	HargaPoin        int       `gorm:"not null"`
+	HargaRp          int       `gorm:"not null"`
+	Jumlah           int       `gorm:"not null"`                        //misalnya 1kg, 500gr, 1buah, 1ikat
+	Satuan           string    `gorm:"type:varchar(50);not null;index"` //misalnya kg, gr, buah, ikat
+	ReorderThreshold int       `gorm:"not null;default:0"`              // Alert stok menipis jika stok di bawah nilai ini (0 = nonaktif)
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
+
+	Product *Product       `gorm:"foreignKey:ProductID"`
+	Images  []ProductImage `gorm:"foreignKey:ProductItemID"`
 
 	// Tambahkan relasi ke Cart jika diperlukan
 	Carts []Cart `gorm:"foreignKey:ProductItemID"`
+
+	// Harga flash sale yang sedang berjalan, diisi oleh endpoint aplikasi (tidak disimpan)
+	FlashSale *FlashSaleOffer `gorm:"-" json:",omitempty"`
 }
 
 func (Product) TableName() string {
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+type FraudSignalType string
+
+const (
+	FraudSharedDevice      FraudSignalType = "shared_device"       // FCM token sama
+	FraudSharedBankAccount FraudSignalType = "shared_bank_account" // Nomor rekening sama
+	FraudSharedAddress     FraudSignalType = "shared_address"      // Alamat pengiriman sama
+	FraudSharedPhone       FraudSignalType = "shared_phone"        // Nomor HP profil/alamat sama
+	FraudBurstRegistration FraudSignalType = "burst_registration"  // Banyak registrasi di bawah satu referrer dalam waktu singkat
+)
+
+type FraudSignalContext string
+
+const (
+	FraudContextRegistration FraudSignalContext = "registration"
+	FraudContextBonus        FraudSignalContext = "bonus"
+)
+
+// ReferralFraudSignal mencatat indikasi self-referral antara user dan user lain di jaringannya
+type ReferralFraudSignal struct {
+	ID            uint               `gorm:"primaryKey;autoIncrement"`
+	UserID        uint               `gorm:"not null;index"` // User yang dicurigai (pendaftar/pembeli)
+	RelatedUserID *uint              `gorm:"index"`          // User lain yang berbagi data dengan UserID
+	ReferrerID    *uint              `gorm:"index"`
+	BonusID       *uint              `gorm:"index"` // Terisi jika sinyal ditemukan saat pembuatan bonus
+	Type          FraudSignalType    `gorm:"type:varchar(30);not null;index"`
+	Context       FraudSignalContext `gorm:"type:varchar(20);not null"`
+	Detail        string             `gorm:"type:varchar(255)"`
+	CreatedAt     time.Time          `gorm:"autoCreateTime"`
+
+	User        *User `gorm:"foreignKey:UserID"`
+	RelatedUser *User `gorm:"foreignKey:RelatedUserID"`
+}
+
+func (ReferralFraudSignal) TableName() string {
+	return "referral_fraud_signals"
+}
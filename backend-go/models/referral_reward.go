@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+type ReferralRewardType string
+
+const (
+	ReferralRewardSignup     ReferralRewardType = "signup"      // Poin untuk user baru saat akun disetujui
+	ReferralRewardFirstOrder ReferralRewardType = "first_order" // Poin untuk referrer saat pesanan pertama referral delivered
+)
+
+// ReferralReward mencatat hadiah poin referral sekali-bayar. Kombinasi referral_user_id dan type
+// unik sehingga approval atau update status berulang tidak memberi poin dua kali.
+type ReferralReward struct {
+	ID             uint               `gorm:"primaryKey;autoIncrement"`
+	UserID         uint               `gorm:"not null;index"` // Penerima poin
+	ReferralUserID uint               `gorm:"not null;uniqueIndex:idx_referral_reward_once"`
+	Type           ReferralRewardType `gorm:"type:varchar(20);not null;uniqueIndex:idx_referral_reward_once"`
+	PesananID      *uint              `gorm:"default:null"`
+	Points         int                `gorm:"not null"`
+	CreatedAt      time.Time          `gorm:"autoCreateTime"`
+
+	User         *User `gorm:"foreignKey:UserID"`
+	ReferralUser *User `gorm:"foreignKey:ReferralUserID"`
+}
+
+func (ReferralReward) TableName() string {
+	return "referral_rewards"
+}
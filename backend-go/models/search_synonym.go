@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// SearchSynonym adalah satu grup sinonim pencarian produk, mis. Term "cabai" dengan
+// Synonyms "cabe,lombok". Mencari salah satu kata juga mencari kata lain di grupnya.
+type SearchSynonym struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Term      string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"term"` // Kata baku
+	Synonyms  string    `gorm:"type:varchar(500);not null" json:"synonyms"`         // Dipisah koma
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+func (SearchSynonym) TableName() string {
+	return "search_synonyms"
+}
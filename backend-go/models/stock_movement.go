@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+type StockMovementType string
+
+const (
+	StockInitial    StockMovementType = "initial"    // Stok awal saat varian dibuat
+	StockSale       StockMovementType = "sale"       // Terjual lewat checkout
+	StockRestock    StockMovementType = "restock"    // Dikembalikan karena pesanan dibatalkan
+	StockAdjustment StockMovementType = "adjustment" // Koreksi manual oleh admin (stock opname)
+	StockReceiving  StockMovementType = "receiving"  // Barang masuk dari pemasok
+	StockSpoilage   StockMovementType = "spoilage"   // Barang rusak/busuk dibuang
+)
+
+// Jenis referensi pergerakan stok
+const (
+	StockReferenceOrder   = "order"
+	StockReferenceProduct = "product"
+)
+
+// StockMovement mencatat setiap perubahan ProductItem.Stok beserta alasan dan pelakunya
+type StockMovement struct {
+	ID            uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductItemID uint              `gorm:"not null;index" json:"productItemId"`
+	Type          StockMovementType `gorm:"type:varchar(20);not null;index" json:"type"`
+	Quantity      int               `gorm:"not null" json:"quantity"` // Positif = masuk, negatif = keluar
+	StockBefore   int               `gorm:"not null" json:"stockBefore"`
+	StockAfter    int               `gorm:"not null" json:"stockAfter"`
+	Reason        string            `gorm:"type:varchar(255)" json:"reason"`
+	ReferenceType string            `gorm:"type:varchar(50);index:idx_stock_movement_reference" json:"referenceType"`
+	ReferenceID   *uint             `gorm:"index:idx_stock_movement_reference" json:"referenceId"`
+	ActorID       *uint             `gorm:"index" json:"actorId"` // Admin atau pembeli yang memicu perubahan
+	CreatedAt     time.Time         `gorm:"autoCreateTime;index" json:"createdAt"`
+
+	ProductItem *ProductItem `gorm:"foreignKey:ProductItemID" json:"productItem,omitempty"`
+	Actor       *User        `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+}
+
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
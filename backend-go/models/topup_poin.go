@@ -14,6 +14,8 @@ type TopUpPoin struct {
 	Price         int       `gorm:"not null"`
 	PaymentMethod string    `gorm:"type:varchar(255);not null"`
 	Status        string    `gorm:"type:varchar(255);not null;default:'success'"`
+	PromoID       *uint     `gorm:"index"`
+	BonusPoints   int       `gorm:"not null;default:0"`
 	CreatedAt     time.Time `gorm:"autoCreateTime"`
 	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
 
@@ -16,6 +16,10 @@ func SetupAfiliasiRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		afiliasiGroup.POST("/claim", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), afiliasiController.ClaimBonus)
 		afiliasiGroup.GET("/total/:userId", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), afiliasiController.GetTotalBonus)
 		afiliasiGroup.GET("/pending/:userId", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), afiliasiController.GetPendingBonus)
+		afiliasiGroup.GET("/awaiting/:userId", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), afiliasiController.GetAwaitingBonus)
+		afiliasiGroup.GET("/convert/quote", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), afiliasiController.GetConversionQuote)
+		afiliasiGroup.POST("/convert", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), afiliasiController.ConvertBonus)
+		afiliasiGroup.GET("/leaderboard", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), afiliasiController.GetLeaderboard)
 		afiliasiGroup.GET("/expired/:userId", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), afiliasiController.GetExpiredBonus)
 	}
 }
@@ -0,0 +1,18 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/app"
+	"backend-go/middleware"
+)
+
+func setupCategoryAppRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	categoryController := app.NewCategoryController(db)
+
+	categoryGroup := rg.Group("/categories-app")
+	{
+		categoryGroup.GET("/tree", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), categoryController.GetCategoryTree)
+	}
+}
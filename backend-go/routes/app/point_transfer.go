@@ -0,0 +1,20 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/app"
+	"backend-go/middleware"
+)
+
+func setupPointTransferAppRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	transferController := app.NewPointTransferController(db)
+
+	transferGroup := rg.Group("/transfer-app")
+	{
+		transferGroup.GET("", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), transferController.GetTransfers)
+		transferGroup.POST("/request", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), transferController.RequestTransfer)
+		transferGroup.POST("/confirm", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), transferController.ConfirmTransfer)
+	}
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"backend-go/controllers/app"
+	"backend-go/middleware"
+	"backend-go/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupProductReviewAppRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	reviewController := app.NewProductReviewController(db)
+
+	reviewGroup := rg.Group("/reviews")
+	{
+		reviewGroup.POST("", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), middleware.UploadFiles("photos", utils.MaxReviewPhotos), reviewController.CreateReview)
+		reviewGroup.GET("/pending", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), reviewController.GetReviewableItems)
+		reviewGroup.GET("/mine", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), reviewController.GetMyReviews)
+		reviewGroup.GET("/product/:productId", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), reviewController.GetProductReviews)
+	}
+}
@@ -21,5 +21,6 @@ func setupProductAppRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		// App routes (no admin check, but require authentication)
 		productGroup.GET("/app", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), productController.GetProductsApp)
 		productGroup.GET("/search", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), productController.SearchProductsApp)
+		productGroup.GET("/autocomplete", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), productController.AutocompleteProducts)
 	}
 }
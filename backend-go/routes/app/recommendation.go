@@ -0,0 +1,20 @@
+package app
+
+import (
+	"backend-go/controllers/app"
+	"backend-go/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupRecommendationAppRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	recommendationController := app.NewRecommendationController(db)
+
+	recommendationGroup := rg.Group("/recommendations")
+	{
+		recommendationGroup.GET("/related/:productId", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), recommendationController.GetRelatedProducts)
+		recommendationGroup.GET("/cart", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), recommendationController.GetCartRecommendations)
+		recommendationGroup.GET("/buy-again", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), recommendationController.GetBuyAgain)
+	}
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/app"
+	"backend-go/middleware"
+)
+
+func setupReferralAppRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	referralController := app.NewReferralController(db)
+
+	referralGroup := rg.Group("/referrals-app")
+	{
+		referralGroup.GET("/tree", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), referralController.GetReferralTree)
+		referralGroup.GET("/tree/levels/:level", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), referralController.GetReferralTreeLevel)
+	}
+}
@@ -17,8 +17,14 @@ func SetupAppRoutes(router *gin.Engine, db *gorm.DB) {
 		SetupPesananAppRoutes(apiGroup, db)
 		setupPoinAppRoutes(apiGroup, db)
 		setupProductAppRoutes(apiGroup, db)
+		setupCategoryAppRoutes(apiGroup, db)
 		SetupFavoriteRoutes(apiGroup, db)
+		setupProductReviewAppRoutes(apiGroup, db)
+		setupRecommendationAppRoutes(apiGroup, db)
 		setupProvinceCityAppRoutes(apiGroup, db)
 		setupSettingAppRoutes(apiGroup, db)
+		setupUserPointsAppRoutes(apiGroup, db)
+		setupPointTransferAppRoutes(apiGroup, db)
+		setupReferralAppRoutes(apiGroup, db)
 	}
 }
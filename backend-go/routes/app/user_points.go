@@ -0,0 +1,20 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/app"
+	"backend-go/middleware"
+)
+
+func setupUserPointsAppRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	userPointsController := app.NewUserPointsController(db)
+
+	pointsGroup := rg.Group("/points-app")
+	{
+		pointsGroup.GET("/expiring", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), userPointsController.GetExpiringPoints)
+		pointsGroup.GET("/history", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), userPointsController.GetPointHistory)
+		pointsGroup.GET("/statement", middleware.AuthMiddleware(), middleware.CheckTokenBlacklist(), userPointsController.GetStatement)
+	}
+}
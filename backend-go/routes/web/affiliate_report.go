@@ -0,0 +1,20 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupAffiliateReportRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	reportController := web.NewAffiliateReportController(db)
+
+	reportGroup := rg.Group("/affiliate-reports")
+	reportGroup.Use(middleware.VerifyUser, middleware.AdminOnly)
+	{
+		reportGroup.GET("/leaderboard", reportController.GetLeaderboard)
+		reportGroup.GET("/performance", reportController.GetPerformanceReport)
+	}
+}
@@ -0,0 +1,21 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupAffiliateSummaryRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	summaryController := web.NewAffiliateSummaryController(db)
+
+	summaryGroup := rg.Group("/affiliate-summaries")
+	{
+		summaryGroup.POST("/rebuild",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			summaryController.RebuildAffiliateSummaries)
+	}
+}
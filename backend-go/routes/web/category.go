@@ -0,0 +1,23 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupCategoryRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	categoryController := web.NewCategoryController(db)
+
+	categoryGroup := rg.Group("/categories")
+	{
+		categoryGroup.GET("", middleware.VerifyUser, middleware.AdminOnly, categoryController.GetCategories)
+		categoryGroup.GET("/:id", middleware.VerifyUser, middleware.AdminOnly, categoryController.GetCategoryById)
+		categoryGroup.POST("", middleware.VerifyUser, middleware.AdminOnly, middleware.UploadFile("icon"), categoryController.CreateCategory)
+		categoryGroup.POST("/reorder", middleware.VerifyUser, middleware.AdminOnly, categoryController.ReorderCategories)
+		categoryGroup.PATCH("/:id", middleware.VerifyUser, middleware.AdminOnly, middleware.UploadFile("icon"), categoryController.UpdateCategory)
+		categoryGroup.DELETE("/:id", middleware.VerifyUser, middleware.AdminOnly, categoryController.DeleteCategory)
+	}
+}
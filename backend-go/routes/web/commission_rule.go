@@ -0,0 +1,21 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupCommissionRuleRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	commissionRuleController := web.NewCommissionRuleController(db)
+
+	commissionRuleGroup := rg.Group("/commission-rules")
+	{
+		commissionRuleGroup.GET("", middleware.VerifyUser, middleware.AdminOnly, commissionRuleController.GetCommissionRules)
+		commissionRuleGroup.GET("/active", middleware.VerifyUser, middleware.AdminOnly, commissionRuleController.GetActiveCommissionRule)
+		commissionRuleGroup.POST("", middleware.VerifyUser, middleware.AdminOnly, commissionRuleController.CreateCommissionRule)
+		commissionRuleGroup.POST("/:id/activate", middleware.VerifyUser, middleware.AdminOnly, commissionRuleController.ActivateCommissionRule)
+	}
+}
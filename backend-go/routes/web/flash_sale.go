@@ -0,0 +1,21 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupFlashSaleRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	flashSaleController := web.NewFlashSaleController(db)
+
+	flashSaleGroup := rg.Group("/flash-sales")
+	{
+		flashSaleGroup.GET("", middleware.VerifyUser, middleware.AdminOnly, flashSaleController.GetFlashSales)
+		flashSaleGroup.POST("", middleware.VerifyUser, middleware.AdminOnly, flashSaleController.CreateFlashSale)
+		flashSaleGroup.PATCH("/:id", middleware.VerifyUser, middleware.AdminOnly, flashSaleController.UpdateFlashSale)
+		flashSaleGroup.DELETE("/:id", middleware.VerifyUser, middleware.AdminOnly, flashSaleController.DeleteFlashSale)
+	}
+}
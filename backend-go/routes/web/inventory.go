@@ -0,0 +1,21 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupInventoryRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	inventoryController := web.NewInventoryController(db)
+
+	inventoryGroup := rg.Group("/inventory")
+	{
+		inventoryGroup.GET("/movements", middleware.VerifyUser, middleware.AdminOnly, inventoryController.GetStockMovements)
+		inventoryGroup.POST("/adjustments", middleware.VerifyUser, middleware.AdminOnly, inventoryController.CreateStockAdjustment)
+		inventoryGroup.GET("/low-stock", middleware.VerifyUser, middleware.AdminOnly, inventoryController.GetLowStockItems)
+		inventoryGroup.PATCH("/items/:id/threshold", middleware.VerifyUser, middleware.AdminOnly, inventoryController.UpdateReorderThreshold)
+	}
+}
@@ -0,0 +1,46 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupPayoutBatchRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	payoutController := web.NewPayoutBatchController(db)
+
+	payoutGroup := rg.Group("/payout-batches")
+	{
+		payoutGroup.GET("",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			payoutController.GetPayoutBatches)
+
+		payoutGroup.POST("",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			payoutController.CreatePayoutBatch)
+
+		payoutGroup.GET("/:id",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			payoutController.GetPayoutBatch)
+
+		payoutGroup.GET("/:id/export",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			payoutController.ExportPayoutBatch)
+
+		payoutGroup.POST("/:id/paid",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			payoutController.MarkPayoutBatchPaid)
+
+		payoutGroup.DELETE("/:id",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			payoutController.CancelPayoutBatch)
+	}
+}
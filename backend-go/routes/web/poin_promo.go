@@ -0,0 +1,21 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupPoinPromoRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	poinPromoController := web.NewPoinPromoController(db)
+
+	poinPromoGroup := rg.Group("/poin-promos")
+	{
+		poinPromoGroup.GET("", middleware.VerifyUser, middleware.AdminOnly, poinPromoController.GetPoinPromos)
+		poinPromoGroup.POST("", middleware.VerifyUser, middleware.AdminOnly, poinPromoController.CreatePoinPromo)
+		poinPromoGroup.PATCH("/:id", middleware.VerifyUser, middleware.AdminOnly, poinPromoController.UpdatePoinPromo)
+		poinPromoGroup.DELETE("/:id", middleware.VerifyUser, middleware.AdminOnly, poinPromoController.DeletePoinPromo)
+	}
+}
@@ -0,0 +1,21 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupPointStatementRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	statementController := web.NewPointStatementController(db)
+
+	statementGroup := rg.Group("/point-statements")
+	{
+		statementGroup.GET("/auto-email", middleware.VerifyUser, middleware.AdminOnly, statementController.GetAutoEmail)
+		statementGroup.POST("/auto-email", middleware.VerifyUser, middleware.AdminOnly, statementController.SetAutoEmail)
+		statementGroup.POST("/email", middleware.VerifyUser, middleware.AdminOnly, statementController.EmailStatements)
+		statementGroup.GET("/:userId", middleware.VerifyUser, middleware.AdminOnly, statementController.GetUserStatement)
+	}
+}
@@ -0,0 +1,23 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupPriceListRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	priceListController := web.NewPriceListController(db)
+
+	priceListGroup := rg.Group("/price-lists")
+	{
+		priceListGroup.GET("", middleware.VerifyUser, middleware.AdminOnly, priceListController.GetPriceLists)
+		priceListGroup.GET("/history", middleware.VerifyUser, middleware.AdminOnly, priceListController.GetPriceHistory)
+		priceListGroup.GET("/:id", middleware.VerifyUser, middleware.AdminOnly, priceListController.GetPriceListById)
+		priceListGroup.POST("", middleware.VerifyUser, middleware.AdminOnly, priceListController.CreatePriceList)
+		priceListGroup.POST("/:id/apply", middleware.VerifyUser, middleware.AdminOnly, priceListController.ApplyPriceList)
+		priceListGroup.DELETE("/:id", middleware.VerifyUser, middleware.AdminOnly, priceListController.CancelPriceList)
+	}
+}
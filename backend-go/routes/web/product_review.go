@@ -0,0 +1,20 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupProductReviewRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	reviewController := web.NewProductReviewController(db)
+
+	reviewGroup := rg.Group("/reviews")
+	{
+		reviewGroup.GET("", middleware.VerifyUser, middleware.AdminOnly, reviewController.GetReviews)
+		reviewGroup.PATCH("/:id/status", middleware.VerifyUser, middleware.AdminOnly, reviewController.ModerateReview)
+		reviewGroup.PATCH("/:id/reply", middleware.VerifyUser, middleware.AdminOnly, reviewController.ReplyReview)
+	}
+}
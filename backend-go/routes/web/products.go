@@ -6,6 +6,7 @@ import (
 
 	"backend-go/controllers/web"
 	"backend-go/middleware"
+	"backend-go/utils"
 )
 
 func setupProductRoutes(rg *gin.RouterGroup, db *gorm.DB) {
@@ -15,9 +16,18 @@ func setupProductRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 	{
 		// Admin routes
 		productGroup.GET("", middleware.VerifyUser, middleware.AdminOnly, productController.GetProducts)
+		productGroup.GET("/export", middleware.VerifyUser, middleware.AdminOnly, productController.ExportProducts)
+		productGroup.POST("/import", middleware.VerifyUser, middleware.AdminOnly, productController.ImportProducts)
 		productGroup.GET("/:id", middleware.VerifyUser, middleware.AdminOnly, productController.GetProductById)
-		productGroup.POST("", middleware.VerifyUser, middleware.AdminOnly, middleware.UploadFile("image"), productController.CreateProduct)
-		productGroup.PATCH("/:id", middleware.VerifyUser, middleware.AdminOnly, middleware.UploadFile("image"), productController.UpdateProduct)
+		productGroup.POST("", middleware.VerifyUser, middleware.AdminOnly, middleware.UploadFile("image"), middleware.UploadFiles("images", utils.MaxProductImages), productController.CreateProduct)
+		productGroup.PATCH("/:id", middleware.VerifyUser, middleware.AdminOnly, middleware.UploadFile("image"), middleware.UploadFiles("images", utils.MaxProductImages), productController.UpdateProduct)
 		productGroup.DELETE("/:id", middleware.VerifyUser, middleware.AdminOnly, productController.DeleteProduct)
+
+		// Galeri gambar produk
+		productGroup.GET("/:id/images", middleware.VerifyUser, middleware.AdminOnly, productController.GetProductImages)
+		productGroup.POST("/:id/images", middleware.VerifyUser, middleware.AdminOnly, middleware.UploadFiles("images", utils.MaxProductImages), productController.UploadProductImages)
+		productGroup.POST("/:id/images/reorder", middleware.VerifyUser, middleware.AdminOnly, productController.ReorderProductImages)
+		productGroup.PATCH("/:id/images/:imageId", middleware.VerifyUser, middleware.AdminOnly, productController.UpdateProductImage)
+		productGroup.DELETE("/:id/images/:imageId", middleware.VerifyUser, middleware.AdminOnly, productController.DeleteProductImage)
 	}
 }
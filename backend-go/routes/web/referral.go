@@ -0,0 +1,31 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupReferralRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	referralController := web.NewReferralController(db)
+
+	referralGroup := rg.Group("/referrals")
+	{
+		referralGroup.GET("/search",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			referralController.SearchReferrals)
+
+		referralGroup.GET("/:userId/tree",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			referralController.GetUserReferralTree)
+
+		referralGroup.GET("/:userId/tree/levels/:level",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			referralController.GetUserReferralTreeLevel)
+	}
+}
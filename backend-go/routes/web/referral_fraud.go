@@ -0,0 +1,36 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupReferralFraudRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	fraudController := web.NewReferralFraudController(db)
+
+	fraudGroup := rg.Group("/referral-fraud")
+	{
+		fraudGroup.GET("/signals",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			fraudController.GetFraudSignals)
+
+		fraudGroup.GET("/bonuses",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			fraudController.GetHeldBonuses)
+
+		fraudGroup.POST("/bonuses/:id/approve",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			fraudController.ApproveHeldBonus)
+
+		fraudGroup.POST("/bonuses/:id/reject",
+			middleware.VerifyUser,
+			middleware.AdminOnly,
+			fraudController.RejectHeldBonus)
+	}
+}
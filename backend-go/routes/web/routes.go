@@ -14,12 +14,26 @@ func SetupWebRoutes(router *gin.Engine, db *gorm.DB) {
 		setupTopUpWebRoutes(apiGroup, db)
 		setupProvinceCityRoutes(apiGroup, db)
 		setupPoinRoutes(apiGroup, db)
+		setupPoinPromoRoutes(apiGroup, db)
 		setupProductRoutes(apiGroup, db)
+		setupCategoryRoutes(apiGroup, db)
+		setupSearchSynonymRoutes(apiGroup, db)
+		setupInventoryRoutes(apiGroup, db)
+		setupPriceListRoutes(apiGroup, db)
+		setupFlashSaleRoutes(apiGroup, db)
+		setupProductReviewRoutes(apiGroup, db)
 		setupSettingRoutes(apiGroup, db)
 		SetupHargaPoinRoutes(apiGroup, db)
 		setupShippingRateRoutes(apiGroup, db)
 		SetupPesananRoutes(apiGroup, db)
 		setupAfiliasiBonusRoutes(apiGroup, db)
+		setupCommissionRuleRoutes(apiGroup, db)
+		setupPayoutBatchRoutes(apiGroup, db)
+		setupReferralRoutes(apiGroup, db)
+		setupReferralFraudRoutes(apiGroup, db)
+		setupAffiliateSummaryRoutes(apiGroup, db)
+		setupAffiliateReportRoutes(apiGroup, db)
 		setupTotalWebRoutes(apiGroup, db)
+		setupPointStatementRoutes(apiGroup, db)
 	}
 }
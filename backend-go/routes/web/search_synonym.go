@@ -0,0 +1,21 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend-go/controllers/web"
+	"backend-go/middleware"
+)
+
+func setupSearchSynonymRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	synonymController := web.NewSearchSynonymController(db)
+
+	synonymGroup := rg.Group("/search-synonyms")
+	{
+		synonymGroup.GET("", middleware.VerifyUser, middleware.AdminOnly, synonymController.GetSearchSynonyms)
+		synonymGroup.POST("", middleware.VerifyUser, middleware.AdminOnly, synonymController.CreateSearchSynonym)
+		synonymGroup.PATCH("/:id", middleware.VerifyUser, middleware.AdminOnly, synonymController.UpdateSearchSynonym)
+		synonymGroup.DELETE("/:id", middleware.VerifyUser, middleware.AdminOnly, synonymController.DeleteSearchSynonym)
+	}
+}
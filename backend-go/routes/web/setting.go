@@ -15,5 +15,17 @@ func setupSettingRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 	{
 		settingGroup.GET("/harga-poin", middleware.VerifyUser, middleware.AdminOnly, settingController.GetHargaPoin)
 		settingGroup.POST("/harga-poin", middleware.VerifyUser, middleware.AdminOnly, settingController.SetHargaPoin)
+		settingGroup.GET("/harga-poin/history", middleware.VerifyUser, middleware.AdminOnly, settingController.GetHargaPoinHistory)
+		settingGroup.DELETE("/harga-poin/history/:id", middleware.VerifyUser, middleware.AdminOnly, settingController.CancelHargaPoinSchedule)
+		settingGroup.GET("/poin-expiry", middleware.VerifyUser, middleware.AdminOnly, settingController.GetPoinExpiry)
+		settingGroup.POST("/poin-expiry", middleware.VerifyUser, middleware.AdminOnly, settingController.SetPoinExpiry)
+		settingGroup.GET("/poin-transfer-limit", middleware.VerifyUser, middleware.AdminOnly, settingController.GetPoinTransferLimit)
+		settingGroup.POST("/poin-transfer-limit", middleware.VerifyUser, middleware.AdminOnly, settingController.SetPoinTransferLimit)
+		settingGroup.GET("/bonus-conversion-multiplier", middleware.VerifyUser, middleware.AdminOnly, settingController.GetBonusConversionMultiplier)
+		settingGroup.POST("/bonus-conversion-multiplier", middleware.VerifyUser, middleware.AdminOnly, settingController.SetBonusConversionMultiplier)
+		settingGroup.GET("/bonus-expiry-reminder", middleware.VerifyUser, middleware.AdminOnly, settingController.GetBonusExpiryReminder)
+		settingGroup.POST("/bonus-expiry-reminder", middleware.VerifyUser, middleware.AdminOnly, settingController.SetBonusExpiryReminder)
+		settingGroup.GET("/referral-rewards", middleware.VerifyUser, middleware.AdminOnly, settingController.GetReferralRewards)
+		settingGroup.POST("/referral-rewards", middleware.VerifyUser, middleware.AdminOnly, settingController.SetReferralRewards)
 	}
 }
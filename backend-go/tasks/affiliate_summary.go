@@ -0,0 +1,26 @@
+package tasks
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend-go/utils"
+)
+
+// RebuildAffiliateSummaries membangun ulang ringkasan affiliate bulan ini dan bulan lalu
+// sebagai pengaman jika ada perubahan yang terlewat oleh update incremental.
+func RebuildAffiliateSummaries(db *gorm.DB) {
+	log.Println("Running cron job to rebuild affiliate summaries...")
+
+	thisMonth := utils.SummaryMonth(time.Now())
+	for _, month := range []time.Time{thisMonth.AddDate(0, -1, 0), thisMonth} {
+		rows, err := utils.RebuildAffiliateSummaries(db, month)
+		if err != nil {
+			log.Printf("Error rebuilding affiliate summaries for %s: %v\n", month.Format("2006-01"), err)
+			continue
+		}
+		log.Printf("Rebuilt %d affiliate summaries for %s\n", rows, month.Format("2006-01"))
+	}
+}
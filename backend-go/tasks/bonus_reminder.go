@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"backend-go/models"
+	"backend-go/utils"
+
+	"gorm.io/gorm"
+)
+
+// SendBonusExpiryReminders mengirim push ke affiliate yang punya bonus pending akan kedaluwarsa.
+// Setiap bonus hanya diingatkan sekali per ambang hari (mis. 7 dan 1 hari).
+func SendBonusExpiryReminders(db *gorm.DB) {
+	log.Println("Running cron job to send bonus expiry reminders...")
+
+	reminderDays := utils.GetBonusExpiryReminderDays(db)
+
+	// Ambang terkecil diproses lebih dulu agar bonus yang sudah dekat tidak menerima dua pengingat sekaligus
+	sort.Ints(reminderDays)
+
+	now := time.Now()
+	for _, days := range reminderDays {
+		var bonuses []models.AfiliasiBonus
+		if err := db.Preload("User").
+			Where("status = ? AND expiry_date BETWEEN ? AND ?", models.BonusPending, now, now.AddDate(0, 0, days)).
+			Where("last_reminder_days = 0 OR last_reminder_days > ?", days).
+			Order("expiry_date ASC").
+			Find(&bonuses).Error; err != nil {
+			log.Printf("Error fetching bonuses for %d-day reminder: %v", days, err)
+			continue
+		}
+
+		type reminder struct {
+			user      *models.User
+			amount    float64
+			expiresAt time.Time
+			bonusIDs  []uint
+		}
+
+		reminders := make(map[uint]*reminder)
+		for i := range bonuses {
+			bonus := &bonuses[i]
+			r, ok := reminders[bonus.UserId]
+			if !ok {
+				r = &reminder{user: &bonus.User, expiresAt: bonus.ExpiryDate}
+				reminders[bonus.UserId] = r
+			}
+			r.amount += bonus.BonusAmount
+			r.bonusIDs = append(r.bonusIDs, bonus.ID)
+		}
+
+		for _, r := range reminders {
+			if r.user.FCMToken != "" {
+				daysLeft := int(r.expiresAt.Sub(now).Hours()/24) + 1
+				utils.SendBonusExpiryReminderNotification(r.user.FCMToken, r.amount, len(r.bonusIDs), r.expiresAt, daysLeft)
+			}
+
+			if err := db.Model(&models.AfiliasiBonus{}).
+				Where("id IN ?", r.bonusIDs).
+				Update("last_reminder_days", days).Error; err != nil {
+				log.Printf("Error marking bonus reminders: %v", err)
+			}
+		}
+
+		log.Printf("Sent %d-day bonus expiry reminders to %d users\n", days, len(reminders))
+	}
+}
+
+// SendWeeklyBonusDigest mengirim ringkasan bonus pending yang belum diklaim ke setiap affiliate
+func SendWeeklyBonusDigest(db *gorm.DB) {
+	log.Println("Running cron job to send weekly bonus digest...")
+
+	type digest struct {
+		UserID        uint
+		Total         float64
+		BonusCount    int
+		NearestExpiry time.Time
+	}
+
+	var digests []digest
+	if err := db.Model(&models.AfiliasiBonus{}).
+		Select("user_id, SUM(bonus_amount) AS total, COUNT(*) AS bonus_count, MIN(expiry_date) AS nearest_expiry").
+		Where("status = ? AND expiry_date > ?", models.BonusPending, time.Now()).
+		Group("user_id").
+		Scan(&digests).Error; err != nil {
+		log.Println("Error fetching bonus digest:", err)
+		return
+	}
+
+	sent := 0
+	for _, d := range digests {
+		var user models.User
+		if err := db.Select("id", "fcm_token").First(&user, d.UserID).Error; err != nil || user.FCMToken == "" {
+			continue
+		}
+		utils.SendBonusDigestNotification(user.FCMToken, d.Total, d.BonusCount, d.NearestExpiry)
+		sent++
+	}
+
+	log.Printf("Sent weekly bonus digest to %d users\n", sent)
+}
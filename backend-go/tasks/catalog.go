@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+
+	"backend-go/utils"
+)
+
+// MigrateProductCategories memindahkan teks kategori produk lama ke tabel categories
+func MigrateProductCategories(db *gorm.DB) {
+	created, updated, err := utils.MigrateProductCategories(db)
+	if err != nil {
+		log.Println("Error migrating product categories:", err)
+		return
+	}
+	if created > 0 || updated > 0 {
+		log.Printf("Migrated product categories: %d categories created, %d products linked\n", created, updated)
+	}
+}
+
+// MigrateProductImages memasukkan gambar tunggal produk lama ke galeri sebagai gambar utama
+func MigrateProductImages(db *gorm.DB) {
+	migrated, err := utils.MigrateProductImages(db)
+	if err != nil {
+		log.Println("Error migrating product images:", err)
+		return
+	}
+	if migrated > 0 {
+		log.Printf("Migrated %d product images into gallery\n", migrated)
+	}
+}
+
+// EnsureSearchIndexes menyiapkan index full-text/trigram produk dan kamus sinonim awal
+func EnsureSearchIndexes(db *gorm.DB) {
+	if err := utils.EnsureSearchIndexes(db); err != nil {
+		log.Println("Error creating product search indexes:", err)
+		return
+	}
+	seeded, err := utils.SeedSearchSynonyms(db)
+	if err != nil {
+		log.Println("Error seeding search synonyms:", err)
+		return
+	}
+	if seeded > 0 {
+		log.Printf("Seeded %d default search synonyms\n", seeded)
+	}
+}
+
+// CleanupOrphanedUploads menghapus file upload yang tidak lagi dipakai produk, kategori, ulasan, atau foto profil
+func CleanupOrphanedUploads(db *gorm.DB) {
+	removed, err := utils.CleanupOrphanedUploads(db)
+	if err != nil {
+		log.Println("Error cleaning up uploads:", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("Removed %d orphaned upload files\n", removed)
+	}
+}
+
+// GenerateMissingRenditions membuat thumbnail dan WebP untuk gambar yang diupload sebelum fitur rendition ada
+func GenerateMissingRenditions(db *gorm.DB) {
+	referenced, err := utils.ReferencedUploads(db)
+	if err != nil {
+		log.Println("Error loading uploads for renditions:", err)
+		return
+	}
+
+	generated := 0
+	for filename := range referenced {
+		created, err := utils.GenerateMissingRenditions(filename)
+		if err != nil {
+			log.Printf("Error generating renditions for %s: %v\n", filename, err)
+			continue
+		}
+		if created {
+			generated++
+		}
+	}
+	if generated > 0 {
+		log.Printf("Generated renditions for %d images\n", generated)
+	}
+}
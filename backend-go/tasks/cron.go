@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"backend-go/models"
+	"backend-go/utils"
 
 	"gorm.io/gorm"
 )
@@ -14,6 +15,15 @@ func CheckExpiredBonuses(db *gorm.DB) {
 
 	currentTime := time.Now()
 
+	// Ringkasan affiliate yang memuat bonus ini perlu dihitung ulang setelah expired
+	var expiring []models.AfiliasiBonus
+	if err := db.Select("id", "referral_user_id", "bonus_received_at").
+		Where("status = ? AND expiry_date < ?", "pending", currentTime).
+		Find(&expiring).Error; err != nil {
+		log.Println("Error fetching expiring bonuses:", err)
+		return
+	}
+
 	result := db.Model(&models.AfiliasiBonus{}).
 		Where("status = ? AND expiry_date < ?", "pending", currentTime).
 		Update("status", "expired")
@@ -23,4 +33,46 @@ func CheckExpiredBonuses(db *gorm.DB) {
 	} else {
 		log.Printf("Updated %d bonuses to status \"expired\"\n", result.RowsAffected)
 	}
+
+	if len(expiring) > 0 {
+		if err := utils.RefreshAffiliateSummariesForBonuses(db, expiring...); err != nil {
+			log.Println("Error refreshing affiliate summaries:", err)
+		}
+	}
+}
+
+// MigrateLegacyAffiliateBonuses menyesuaikan bonus lama yang dibuat langsung sebagai pending:
+// bonus dari pesanan yang dibatalkan di-void, bonus dari pesanan yang belum delivered
+// dikembalikan ke awaiting_delivery. Hanya dijalankan sekali; penandanya disimpan di Setting.
+func MigrateLegacyAffiliateBonuses(db *gorm.DB) {
+	var done models.Setting
+	if err := db.Where("key = ?", utils.SettingLegacyAffiliateBonusesMigrated).First(&done).Error; err == nil && done.Value == "true" {
+		return
+	}
+
+	voided := db.Model(&models.AfiliasiBonus{}).
+		Where("status IN ? AND pesanan_id IN (?)",
+			[]models.AfiliasiBonusStatus{models.BonusAwaitingDelivery, models.BonusPending},
+			db.Model(&models.Pesanan{}).Select("id").Where("status = ?", models.PesananCancelled)).
+		Updates(map[string]interface{}{"status": models.BonusVoided, "voided_at": time.Now()})
+	if voided.Error != nil {
+		log.Println("Error voiding legacy affiliate bonuses:", voided.Error)
+		return
+	}
+
+	awaiting := db.Model(&models.AfiliasiBonus{}).
+		Where("status = ? AND pesanan_id IN (?)", models.BonusPending,
+			db.Model(&models.Pesanan{}).Select("id").
+				Where("status NOT IN ?", []models.PesananStatus{models.PesananDelivered, models.PesananCancelled})).
+		Update("status", models.BonusAwaitingDelivery)
+	if awaiting.Error != nil {
+		log.Println("Error migrating legacy affiliate bonuses:", awaiting.Error)
+		return
+	}
+
+	if err := db.Save(&models.Setting{Key: utils.SettingLegacyAffiliateBonusesMigrated, Value: "true"}).Error; err != nil {
+		log.Println("Error recording legacy affiliate bonus migration:", err)
+	}
+
+	log.Printf("Migrated legacy affiliate bonuses: %d voided, %d awaiting delivery\n", voided.RowsAffected, awaiting.RowsAffected)
 }
@@ -0,0 +1,20 @@
+package tasks
+
+import (
+	"log"
+
+	"backend-go/utils"
+
+	"gorm.io/gorm"
+)
+
+// SyncFlashSales mengaktifkan dan menonaktifkan flash sale sesuai jadwal dan kuota
+func SyncFlashSales(db *gorm.DB) {
+	activated, deactivated, err := utils.SyncFlashSales(db)
+	if err != nil {
+		log.Println("Error syncing flash sales:", err)
+	}
+	if activated > 0 || deactivated > 0 {
+		log.Printf("Flash sales synced: %d activated, %d deactivated\n", activated, deactivated)
+	}
+}
@@ -0,0 +1,20 @@
+package tasks
+
+import (
+	"log"
+
+	"backend-go/utils"
+
+	"gorm.io/gorm"
+)
+
+// SyncPoinPromos mengaktifkan dan menonaktifkan promo paket poin sesuai jadwal
+func SyncPoinPromos(db *gorm.DB) {
+	activated, deactivated, err := utils.SyncPoinPromos(db)
+	if err != nil {
+		log.Println("Error syncing poin promos:", err)
+	}
+	if activated > 0 || deactivated > 0 {
+		log.Printf("Poin promos synced: %d activated, %d deactivated\n", activated, deactivated)
+	}
+}
@@ -0,0 +1,102 @@
+package tasks
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"backend-go/models"
+	"backend-go/utils"
+
+	"gorm.io/gorm"
+)
+
+// ExpirePointLots menghanguskan lot poin yang sudah melewati tanggal kedaluwarsa
+func ExpirePointLots(db *gorm.DB) {
+	log.Println("Running cron job to expire point lots...")
+
+	var lotIDs []uint
+	if err := db.Model(&models.PointLot{}).
+		Where("remaining > 0 AND expired_at IS NULL AND expires_at < ?", time.Now()).
+		Pluck("id", &lotIDs).Error; err != nil {
+		log.Println("Error fetching expired point lots:", err)
+		return
+	}
+
+	totalExpired := 0
+	for _, lotID := range lotIDs {
+		var expired int
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			expired, err = utils.ExpirePointLot(tx, lotID)
+			return err
+		})
+		if err != nil {
+			log.Printf("Error expiring point lot %d: %v", lotID, err)
+			continue
+		}
+		totalExpired += expired
+	}
+
+	log.Printf("Expired %d point lots (%d points)\n", len(lotIDs), totalExpired)
+}
+
+// SendPointExpiryReminders mengirim push ke user yang punya poin akan kedaluwarsa.
+// Setiap lot hanya diingatkan sekali per ambang hari (mis. 30 dan 7 hari).
+func SendPointExpiryReminders(db *gorm.DB) {
+	log.Println("Running cron job to send point expiry reminders...")
+
+	months, reminderDays := utils.GetPointExpiryConfig(db)
+	if months == 0 {
+		return
+	}
+
+	// Ambang terkecil diproses lebih dulu agar lot yang sudah dekat tidak menerima dua pengingat sekaligus
+	sort.Ints(reminderDays)
+
+	now := time.Now()
+	for _, days := range reminderDays {
+		var lots []models.PointLot
+		if err := db.Preload("User").
+			Where("remaining > 0 AND expired_at IS NULL AND expires_at BETWEEN ? AND ?", now, now.AddDate(0, 0, days)).
+			Where("last_reminder_days = 0 OR last_reminder_days > ?", days).
+			Order("expires_at ASC").
+			Find(&lots).Error; err != nil {
+			log.Printf("Error fetching point lots for %d-day reminder: %v", days, err)
+			continue
+		}
+
+		type reminder struct {
+			user      *models.User
+			points    int
+			expiresAt time.Time
+			lotIDs    []uint
+		}
+
+		reminders := make(map[uint]*reminder)
+		for _, lot := range lots {
+			r, ok := reminders[lot.UserID]
+			if !ok {
+				r = &reminder{user: lot.User, expiresAt: *lot.ExpiresAt}
+				reminders[lot.UserID] = r
+			}
+			r.points += lot.Remaining
+			r.lotIDs = append(r.lotIDs, lot.ID)
+		}
+
+		for _, r := range reminders {
+			if r.user != nil && r.user.FCMToken != "" {
+				daysLeft := int(r.expiresAt.Sub(now).Hours()/24) + 1
+				utils.SendPointExpiryReminderNotification(r.user.FCMToken, r.points, r.expiresAt, daysLeft)
+			}
+
+			if err := db.Model(&models.PointLot{}).
+				Where("id IN ?", r.lotIDs).
+				Update("last_reminder_days", days).Error; err != nil {
+				log.Printf("Error marking point lot reminders: %v", err)
+			}
+		}
+
+		log.Printf("Sent %d-day point expiry reminders to %d users\n", days, len(reminders))
+	}
+}
@@ -0,0 +1,37 @@
+package tasks
+
+import (
+	"log"
+	"time"
+
+	"backend-go/models"
+	"backend-go/utils"
+
+	"gorm.io/gorm"
+)
+
+// ApplyScheduledPointRates memberlakukan nilai poin terjadwal yang sudah mencapai tanggal efektif
+func ApplyScheduledPointRates(db *gorm.DB) {
+	var rates []models.PointRate
+	if err := db.Where("applied_at IS NULL AND effective_at <= ?", time.Now()).
+		Order("effective_at ASC, id ASC").
+		Find(&rates).Error; err != nil {
+		log.Println("Error fetching scheduled point rates:", err)
+		return
+	}
+
+	// Diterapkan berurutan sehingga rate dengan tanggal efektif terbaru menjadi yang berlaku
+	for _, rate := range rates {
+		var updated int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			updated, err = utils.ApplyPointRate(tx, rate.ID)
+			return err
+		})
+		if err != nil {
+			log.Printf("Error applying point rate %d: %v", rate.ID, err)
+			return
+		}
+		log.Printf("Applied point rate %d (Rp %d per poin), repriced %d product items\n", rate.ID, rate.Rate, updated)
+	}
+}
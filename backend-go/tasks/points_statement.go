@@ -0,0 +1,73 @@
+package tasks
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"backend-go/config"
+	"backend-go/models"
+	"backend-go/utils"
+
+	"gorm.io/gorm"
+)
+
+// SendMonthlyPointStatements mengirim laporan poin PDF bulan tertentu ke semua user aktif
+func SendMonthlyPointStatements(db *gorm.DB, month time.Time) {
+	log.Printf("Sending point statements for %s...\n", month.Format("2006-01"))
+
+	var users []models.User
+	if err := db.Joins("JOIN roles ON roles.id = users.role_id").
+		Where("users.is_approved = ? AND roles.role_name <> ?", true, "admin").
+		Find(&users).Error; err != nil {
+		log.Println("Error fetching users for point statements:", err)
+		return
+	}
+
+	mailer := config.NewMailer()
+	sent := 0
+	for _, user := range users {
+		statement, err := utils.BuildPointStatement(db, user.ID, month)
+		if err != nil {
+			log.Printf("Error building point statement for user %d: %v", user.ID, err)
+			continue
+		}
+
+		data, err := utils.RenderPointStatementPDF(statement)
+		if err != nil {
+			log.Printf("Error rendering point statement for user %d: %v", user.ID, err)
+			continue
+		}
+
+		period := month.Format("01-2006")
+		subject := "Laporan Poin Bulan " + period
+		body := fmt.Sprintf(`
+			<html>
+			<body style="font-family: Arial, sans-serif; color: #333;">
+				<h2>Laporan Poin Bulan %s</h2>
+				<p>Saldo awal: <b>%d poin</b></p>
+				<p>Saldo akhir: <b>%d poin</b></p>
+				<p>Rincian mutasi poin terlampir pada file PDF.</p>
+			</body>
+			</html>
+		`, period, statement.OpeningBalance, statement.ClosingBalance)
+
+		if err := mailer.SendEmailWithAttachment(user.Email, subject, body, utils.StatementFileName(statement, "pdf"), data); err != nil {
+			continue
+		}
+		sent++
+	}
+
+	log.Printf("Sent %d of %d point statements\n", sent, len(users))
+}
+
+// SendLastMonthPointStatements dijalankan awal bulan jika pengiriman otomatis diaktifkan admin
+func SendLastMonthPointStatements(db *gorm.DB) {
+	var setting models.Setting
+	if err := db.Where("key = ?", utils.SettingPoinStatementAutoEmail).First(&setting).Error; err != nil || setting.Value != "true" {
+		return
+	}
+
+	month, _ := utils.ParseStatementMonth("")
+	SendMonthlyPointStatements(db, month)
+}
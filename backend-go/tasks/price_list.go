@@ -0,0 +1,37 @@
+package tasks
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+	"backend-go/utils"
+)
+
+// ApplyScheduledPriceLists memberlakukan daftar harga yang sudah mencapai waktu efektif
+func ApplyScheduledPriceLists(db *gorm.DB) {
+	var priceLists []models.PriceList
+	if err := db.Where("applied_at IS NULL AND effective_at <= ?", time.Now()).
+		Order("effective_at ASC, id ASC").
+		Find(&priceLists).Error; err != nil {
+		log.Println("Error fetching scheduled price lists:", err)
+		return
+	}
+
+	// Diterapkan berurutan sehingga daftar dengan waktu efektif terbaru menjadi harga yang berlaku
+	for _, priceList := range priceLists {
+		var changed int
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			changed, err = utils.ApplyPriceList(tx, priceList.ID, nil)
+			return err
+		})
+		if err != nil {
+			log.Printf("Error applying price list %d: %v", priceList.ID, err)
+			return
+		}
+		log.Printf("Applied price list %d (%s), repriced %d product items\n", priceList.ID, priceList.Name, changed)
+	}
+}
@@ -0,0 +1,19 @@
+package tasks
+
+import (
+	"log"
+
+	"backend-go/utils"
+
+	"gorm.io/gorm"
+)
+
+// ComputeProductAffinities menghitung ulang produk yang sering dibeli bersama dari riwayat pesanan
+func ComputeProductAffinities(db *gorm.DB) {
+	stored, err := utils.ComputeProductAffinities(db)
+	if err != nil {
+		log.Println("Error computing product affinities:", err)
+		return
+	}
+	log.Printf("Product affinities computed: %d pairs stored\n", stored)
+}
@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+const (
+	// Leaderboard bulan berjalan di-cache sebentar karena masih berubah; bulan lalu sudah final
+	leaderboardCurrentMonthTTL = 10 * time.Minute
+	leaderboardPastMonthTTL    = 24 * time.Hour
+)
+
+// Bonus yang dihitung sebagai "earned" di leaderboard dan laporan
+var earnedBonusStatuses = []models.AfiliasiBonusStatus{
+	models.BonusPending, models.BonusClaimed, models.BonusTransferred, models.BonusConverted,
+}
+
+// LeaderboardEntry adalah peringkat satu affiliate dalam satu bulan
+type LeaderboardEntry struct {
+	Rank            int     `json:"rank"`
+	UserID          uint    `json:"userId"`
+	Name            string  `json:"name"`
+	Email           string  `json:"email"`
+	ReferralCode    string  `json:"referralCode"`
+	ReferredSpend   int64   `json:"referredSpend"`   // Belanja delivered dari referral langsung
+	ReferredOrders  int     `json:"referredOrders"`  // Jumlah pesanan delivered referral langsung
+	ActiveReferrals int     `json:"activeReferrals"` // Referral langsung yang berbelanja
+	BonusEarned     float64 `json:"bonusEarned"`
+}
+
+// Leaderboard adalah hasil leaderboard satu bulan beserta waktu pembuatannya
+type Leaderboard struct {
+	Month       time.Time          `json:"month"`
+	GeneratedAt time.Time          `json:"generatedAt"`
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+type leaderboardCacheEntry struct {
+	leaderboard *Leaderboard
+	expiresAt   time.Time
+}
+
+var (
+	leaderboardCache   = make(map[string]leaderboardCacheEntry)
+	leaderboardCacheMu sync.Mutex
+)
+
+// GetAffiliateLeaderboard mengembalikan leaderboard bulanan dari cache, atau membangunnya jika belum ada/kedaluwarsa
+func GetAffiliateLeaderboard(db *gorm.DB, month time.Time, refresh bool) (*Leaderboard, error) {
+	month = SummaryMonth(month)
+	key := month.Format("2006-01")
+
+	leaderboardCacheMu.Lock()
+	cached, ok := leaderboardCache[key]
+	leaderboardCacheMu.Unlock()
+	if ok && !refresh && time.Now().Before(cached.expiresAt) {
+		return cached.leaderboard, nil
+	}
+
+	leaderboard, err := BuildAffiliateLeaderboard(db, month)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := leaderboardPastMonthTTL
+	if month.Equal(SummaryMonth(time.Now())) {
+		ttl = leaderboardCurrentMonthTTL
+	}
+
+	leaderboardCacheMu.Lock()
+	leaderboardCache[key] = leaderboardCacheEntry{leaderboard: leaderboard, expiresAt: time.Now().Add(ttl)}
+	leaderboardCacheMu.Unlock()
+
+	return leaderboard, nil
+}
+
+// BuildAffiliateLeaderboard menghitung peringkat affiliate dari Pesanan dan AfiliasiBonus,
+// diurutkan berdasarkan belanja delivered referral lalu bonus yang didapat.
+func BuildAffiliateLeaderboard(db *gorm.DB, month time.Time) (*Leaderboard, error) {
+	start := SummaryMonth(month)
+	end := start.AddDate(0, 1, 0)
+
+	type spendRow struct {
+		UserID          uint
+		Spend           int64
+		Orders          int
+		ActiveReferrals int
+	}
+	var spends []spendRow
+	if err := db.Table("pesanan").
+		Select("users.referred_by AS user_id, COALESCE(SUM(pesanan.total_bayar), 0) AS spend, COUNT(pesanan.id) AS orders, COUNT(DISTINCT pesanan.user_id) AS active_referrals").
+		Joins("JOIN users ON users.id = pesanan.user_id").
+		Where("users.referred_by IS NOT NULL AND pesanan.status = ? AND pesanan.created_at >= ? AND pesanan.created_at < ?",
+			models.PesananDelivered, start, end).
+		Group("users.referred_by").
+		Scan(&spends).Error; err != nil {
+		return nil, err
+	}
+
+	type bonusRow struct {
+		UserID uint
+		Total  float64
+	}
+	var bonuses []bonusRow
+	if err := db.Model(&models.AfiliasiBonus{}).
+		Select("user_id, COALESCE(SUM(bonus_amount), 0) AS total").
+		Where("status IN ? AND bonus_received_at >= ? AND bonus_received_at < ?", earnedBonusStatuses, start, end).
+		Group("user_id").
+		Scan(&bonuses).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make(map[uint]*LeaderboardEntry)
+	get := func(userID uint) *LeaderboardEntry {
+		entry, ok := entries[userID]
+		if !ok {
+			entry = &LeaderboardEntry{UserID: userID}
+			entries[userID] = entry
+		}
+		return entry
+	}
+	for _, row := range spends {
+		entry := get(row.UserID)
+		entry.ReferredSpend = row.Spend
+		entry.ReferredOrders = row.Orders
+		entry.ActiveReferrals = row.ActiveReferrals
+	}
+	for _, row := range bonuses {
+		get(row.UserID).BonusEarned = row.Total
+	}
+
+	if err := fillLeaderboardUsers(db, entries); err != nil {
+		return nil, err
+	}
+
+	result := make([]LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ReferredSpend != result[j].ReferredSpend {
+			return result[i].ReferredSpend > result[j].ReferredSpend
+		}
+		if result[i].BonusEarned != result[j].BonusEarned {
+			return result[i].BonusEarned > result[j].BonusEarned
+		}
+		return result[i].UserID < result[j].UserID
+	})
+	for i := range result {
+		result[i].Rank = i + 1
+	}
+
+	return &Leaderboard{Month: start, GeneratedAt: time.Now(), Entries: result}, nil
+}
+
+func fillLeaderboardUsers(db *gorm.DB, entries map[uint]*LeaderboardEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(entries))
+	for id := range entries {
+		ids = append(ids, id)
+	}
+
+	var users []models.User
+	if err := db.Unscoped().Preload("Details").
+		Select("id", "email", "referral_code").
+		Where("id IN ?", ids).
+		Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		entry := entries[user.ID]
+		entry.Email = user.Email
+		entry.ReferralCode = user.ReferralCode
+		if user.Details != nil {
+			entry.Name = user.Details.Fullname
+		}
+	}
+	return nil
+}
+
+// MaskName menyamarkan nama untuk leaderboard publik, mis. "Budi Santoso" menjadi "Budi S***"
+func MaskName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return "Affiliate"
+	}
+
+	first := []rune(parts[0])
+	if len(parts) == 1 {
+		if len(first) <= 2 {
+			return string(first[:1]) + "***"
+		}
+		return string(first[:2]) + "***"
+	}
+
+	last := []rune(parts[len(parts)-1])
+	return parts[0] + " " + string(last[:1]) + "***"
+}
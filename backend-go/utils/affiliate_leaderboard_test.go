@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestMaskName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Budi Santoso", "Budi S***"},
+		{"Siti Nur Aisyah", "Siti A***"},
+		{"Budi", "Bu***"},
+		{"Al", "A***"},
+		{"  Ani   Wijaya  ", "Ani W***"},
+		{"Émile Ångström", "Émile Å***"},
+		{"", "Affiliate"},
+		{"   ", "Affiliate"},
+	}
+
+	for _, tt := range tests {
+		if got := MaskName(tt.name); got != tt.want {
+			t.Errorf("MaskName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
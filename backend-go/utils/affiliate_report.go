@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+// ReferrerPerformance adalah kinerja satu referrer dalam satu bulan.
+// Konversi dihitung dari referral yang mendaftar bulan itu dan sudah punya pesanan delivered.
+type ReferrerPerformance struct {
+	UserID              uint    `json:"userId"`
+	Name                string  `json:"name"`
+	Email               string  `json:"email"`
+	ReferralCode        string  `json:"referralCode"`
+	Signups             int     `json:"signups"`
+	ConvertedSignups    int     `json:"convertedSignups"`
+	ConversionRate      float64 `json:"conversionRate"` // Persen
+	AvgDaysToFirstOrder float64 `json:"avgDaysToFirstOrder"`
+	ReferredSpend       int64   `json:"referredSpend"`
+	ReferredOrders      int     `json:"referredOrders"`
+	ActiveReferrals     int     `json:"activeReferrals"`
+	BonusEarned         float64 `json:"bonusEarned"`
+}
+
+// ReferrerPerformanceReport adalah laporan kinerja semua referrer dalam satu bulan
+type ReferrerPerformanceReport struct {
+	Month            time.Time             `json:"month"`
+	TotalSignups     int                   `json:"totalSignups"`
+	TotalConverted   int                   `json:"totalConverted"`
+	ConversionRate   float64               `json:"conversionRate"`
+	TotalSpend       int64                 `json:"totalSpend"`
+	TotalBonusEarned float64               `json:"totalBonusEarned"`
+	Referrers        []ReferrerPerformance `json:"referrers"`
+}
+
+// BuildReferrerPerformanceReport menyusun laporan kinerja referrer untuk admin
+func BuildReferrerPerformanceReport(db *gorm.DB, month time.Time) (*ReferrerPerformanceReport, error) {
+	start := SummaryMonth(month)
+	end := start.AddDate(0, 1, 0)
+
+	leaderboard, err := GetAffiliateLeaderboard(db, start, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[uint]*ReferrerPerformance)
+	get := func(userID uint) *ReferrerPerformance {
+		row, ok := rows[userID]
+		if !ok {
+			row = &ReferrerPerformance{UserID: userID}
+			rows[userID] = row
+		}
+		return row
+	}
+
+	for _, entry := range leaderboard.Entries {
+		row := get(entry.UserID)
+		row.Name = entry.Name
+		row.Email = entry.Email
+		row.ReferralCode = entry.ReferralCode
+		row.ReferredSpend = entry.ReferredSpend
+		row.ReferredOrders = entry.ReferredOrders
+		row.ActiveReferrals = entry.ActiveReferrals
+		row.BonusEarned = entry.BonusEarned
+	}
+
+	type cohortRow struct {
+		UserID              uint
+		Signups             int
+		Converted           int
+		AvgDaysToFirstOrder float64
+	}
+	var cohorts []cohortRow
+	if err := db.Raw(`SELECT u.referred_by AS user_id,
+			COUNT(*) AS signups,
+			COUNT(f.user_id) AS converted,
+			COALESCE(AVG(EXTRACT(EPOCH FROM (f.first_order_at - u.created_at)) / 86400), 0) AS avg_days_to_first_order
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, MIN(created_at) AS first_order_at FROM pesanan WHERE status = ? GROUP BY user_id
+		) f ON f.user_id = u.id
+		WHERE u.referred_by IS NOT NULL AND u.deleted_at IS NULL AND u.created_at >= ? AND u.created_at < ?
+		GROUP BY u.referred_by`, models.PesananDelivered, start, end).
+		Scan(&cohorts).Error; err != nil {
+		return nil, err
+	}
+
+	var missingUsers []uint
+	for _, cohort := range cohorts {
+		row, known := rows[cohort.UserID]
+		if !known {
+			row = get(cohort.UserID)
+			missingUsers = append(missingUsers, cohort.UserID)
+		}
+		row.Signups = cohort.Signups
+		row.ConvertedSignups = cohort.Converted
+		row.AvgDaysToFirstOrder = cohort.AvgDaysToFirstOrder
+		if cohort.Signups > 0 {
+			row.ConversionRate = float64(cohort.Converted) * 100 / float64(cohort.Signups)
+		}
+	}
+
+	// Referrer yang hanya punya pendaftar baru belum ada di leaderboard
+	if len(missingUsers) > 0 {
+		var users []models.User
+		if err := db.Unscoped().Preload("Details").
+			Select("id", "email", "referral_code").
+			Where("id IN ?", missingUsers).
+			Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			row := rows[user.ID]
+			row.Email = user.Email
+			row.ReferralCode = user.ReferralCode
+			if user.Details != nil {
+				row.Name = user.Details.Fullname
+			}
+		}
+	}
+
+	report := &ReferrerPerformanceReport{Month: start, Referrers: make([]ReferrerPerformance, 0, len(rows))}
+	for _, row := range rows {
+		report.TotalSignups += row.Signups
+		report.TotalConverted += row.ConvertedSignups
+		report.TotalSpend += row.ReferredSpend
+		report.TotalBonusEarned += row.BonusEarned
+		report.Referrers = append(report.Referrers, *row)
+	}
+	if report.TotalSignups > 0 {
+		report.ConversionRate = float64(report.TotalConverted) * 100 / float64(report.TotalSignups)
+	}
+
+	sort.Slice(report.Referrers, func(i, j int) bool {
+		a, b := report.Referrers[i], report.Referrers[j]
+		if a.ReferredSpend != b.ReferredSpend {
+			return a.ReferredSpend > b.ReferredSpend
+		}
+		if a.Signups != b.Signups {
+			return a.Signups > b.Signups
+		}
+		return a.UserID < b.UserID
+	})
+
+	return report, nil
+}
+
+// ParseLeaderboardMonth membaca YYYY-MM; kosong berarti bulan berjalan
+func ParseLeaderboardMonth(value string) (time.Time, error) {
+	if value == "" {
+		return SummaryMonth(time.Now()), nil
+	}
+	return ParseStatementMonth(value)
+}
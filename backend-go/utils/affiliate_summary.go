@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+// Status bonus yang dihitung di ringkasan affiliate (sama dengan tampilan profil)
+var summaryBonusStatuses = []models.AfiliasiBonusStatus{models.BonusPending, models.BonusClaimed}
+
+// AffiliateSummaryTarget menunjuk satu baris ringkasan yang perlu dihitung ulang
+type AffiliateSummaryTarget struct {
+	UserID         uint
+	ReferralUserID uint
+	Month          time.Time
+}
+
+type affiliateSummaryKey struct {
+	userID         uint
+	referralUserID uint
+}
+
+// SummaryMonth mengembalikan tanggal 1 dari bulan t
+func SummaryMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// computeAffiliateSummaries menghitung ringkasan bulan tertentu. userID/referralUserID = 0 berarti semua.
+func computeAffiliateSummaries(db *gorm.DB, month time.Time, userID, referralUserID uint) (map[affiliateSummaryKey]*models.AffiliateSummary, error) {
+	start := SummaryMonth(month)
+	end := start.AddDate(0, 1, 0)
+	summaries := make(map[affiliateSummaryKey]*models.AffiliateSummary)
+	get := func(key affiliateSummaryKey) *models.AffiliateSummary {
+		summary, ok := summaries[key]
+		if !ok {
+			summary = &models.AffiliateSummary{UserID: key.userID, ReferralUserID: key.referralUserID, Month: start}
+			summaries[key] = summary
+		}
+		return summary
+	}
+
+	type row struct {
+		UserID         uint
+		ReferralUserID uint
+		Total          float64
+		Count          int
+	}
+
+	// Belanja delivered setiap referral langsung
+	var spends []row
+	spendQuery := db.Table("pesanan").
+		Select("users.referred_by AS user_id, pesanan.user_id AS referral_user_id, COALESCE(SUM(pesanan.total_bayar), 0) AS total, COUNT(*) AS count").
+		Joins("JOIN users ON users.id = pesanan.user_id").
+		Where("users.referred_by IS NOT NULL AND pesanan.status = ? AND pesanan.created_at >= ? AND pesanan.created_at < ?",
+			models.PesananDelivered, start, end)
+	if userID > 0 {
+		spendQuery = spendQuery.Where("users.referred_by = ?", userID)
+	}
+	if referralUserID > 0 {
+		spendQuery = spendQuery.Where("pesanan.user_id = ?", referralUserID)
+	}
+	if err := spendQuery.Group("users.referred_by, pesanan.user_id").Scan(&spends).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range spends {
+		get(affiliateSummaryKey{r.UserID, r.ReferralUserID}).MonthlySpent = int64(r.Total)
+	}
+
+	// Bonus level 1 dari pesanan referral langsung
+	var level1 []row
+	level1Query := db.Table("afiliasi_bonus").
+		Select("afiliasi_bonus.user_id, afiliasi_bonus.referral_user_id, COALESCE(SUM(afiliasi_bonus.bonus_amount), 0) AS total, COUNT(*) AS count").
+		Joins("JOIN pesanan ON afiliasi_bonus.pesanan_id = pesanan.id").
+		Where("afiliasi_bonus.bonus_level = ? AND pesanan.status = ? AND afiliasi_bonus.status IN ? AND afiliasi_bonus.bonus_received_at >= ? AND afiliasi_bonus.bonus_received_at < ?",
+			1, models.PesananDelivered, summaryBonusStatuses, start, end)
+	if userID > 0 {
+		level1Query = level1Query.Where("afiliasi_bonus.user_id = ?", userID)
+	}
+	if referralUserID > 0 {
+		level1Query = level1Query.Where("afiliasi_bonus.referral_user_id = ?", referralUserID)
+	}
+	if err := level1Query.Group("afiliasi_bonus.user_id, afiliasi_bonus.referral_user_id").Scan(&level1).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range level1 {
+		summary := get(affiliateSummaryKey{r.UserID, r.ReferralUserID})
+		summary.BonusLevel1 = r.Total
+		summary.BonusLevel1Count = r.Count
+	}
+
+	// Bonus level 2 dikelompokkan ke referral langsung yang mengundang pembeli
+	var level2 []row
+	level2Query := db.Table("afiliasi_bonus").
+		Select("afiliasi_bonus.user_id, buyer.referred_by AS referral_user_id, COALESCE(SUM(afiliasi_bonus.bonus_amount), 0) AS total, COUNT(*) AS count").
+		Joins("JOIN pesanan ON afiliasi_bonus.pesanan_id = pesanan.id").
+		Joins("JOIN users AS buyer ON afiliasi_bonus.referral_user_id = buyer.id").
+		Where("buyer.referred_by IS NOT NULL AND afiliasi_bonus.bonus_level = ? AND pesanan.status = ? AND afiliasi_bonus.status IN ? AND afiliasi_bonus.bonus_received_at >= ? AND afiliasi_bonus.bonus_received_at < ?",
+			2, models.PesananDelivered, summaryBonusStatuses, start, end)
+	if userID > 0 {
+		level2Query = level2Query.Where("afiliasi_bonus.user_id = ?", userID)
+	}
+	if referralUserID > 0 {
+		level2Query = level2Query.Where("buyer.referred_by = ?", referralUserID)
+	}
+	if err := level2Query.Group("afiliasi_bonus.user_id, buyer.referred_by").Scan(&level2).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range level2 {
+		summary := get(affiliateSummaryKey{r.UserID, r.ReferralUserID})
+		summary.BonusLevel2 = r.Total
+		summary.BonusLevel2Count = r.Count
+	}
+
+	return summaries, nil
+}
+
+// RefreshAffiliateSummaries menghitung ulang baris ringkasan yang terdampak perubahan
+func RefreshAffiliateSummaries(db *gorm.DB, targets []AffiliateSummaryTarget) error {
+	seen := make(map[AffiliateSummaryTarget]bool)
+	for _, target := range targets {
+		target.Month = SummaryMonth(target.Month)
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		summaries, err := computeAffiliateSummaries(db, target.Month, target.UserID, target.ReferralUserID)
+		if err != nil {
+			return err
+		}
+
+		summary, ok := summaries[affiliateSummaryKey{target.UserID, target.ReferralUserID}]
+		if !ok {
+			if err := db.Where("user_id = ? AND referral_user_id = ? AND month = ?",
+				target.UserID, target.ReferralUserID, target.Month).
+				Delete(&models.AffiliateSummary{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := db.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "referral_user_id"}, {Name: "month"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"monthly_spent", "bonus_level1", "bonus_level1_count", "bonus_level2", "bonus_level2_count", "updated_at",
+			}),
+		}).Create(summary).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AffiliateSummaryTargetsForBuyer mengembalikan baris yang terdampak transaksi pembeli:
+// ringkasan referrer langsung (belanja, bonus level 1) dan referrer level 2.
+func AffiliateSummaryTargetsForBuyer(db *gorm.DB, buyerID uint, months ...time.Time) ([]AffiliateSummaryTarget, error) {
+	var buyer models.User
+	if err := db.Unscoped().Select("id", "referred_by").First(&buyer, buyerID).Error; err != nil {
+		return nil, err
+	}
+	if buyer.ReferredBy == nil {
+		return nil, nil
+	}
+
+	var referrer models.User
+	if err := db.Unscoped().Select("id", "referred_by").First(&referrer, *buyer.ReferredBy).Error; err != nil {
+		return nil, err
+	}
+
+	var targets []AffiliateSummaryTarget
+	for _, month := range months {
+		targets = append(targets, AffiliateSummaryTarget{UserID: referrer.ID, ReferralUserID: buyer.ID, Month: month})
+		if referrer.ReferredBy != nil {
+			targets = append(targets, AffiliateSummaryTarget{UserID: *referrer.ReferredBy, ReferralUserID: referrer.ID, Month: month})
+		}
+	}
+	return targets, nil
+}
+
+// AffiliateSummaryTargetsForBonuses mengembalikan baris yang terdampak perubahan status bonus
+func AffiliateSummaryTargetsForBonuses(db *gorm.DB, bonuses []models.AfiliasiBonus) ([]AffiliateSummaryTarget, error) {
+	months := make(map[uint][]time.Time)
+	for _, bonus := range bonuses {
+		months[bonus.ReferralUserId] = append(months[bonus.ReferralUserId], bonus.BonusReceivedAt)
+	}
+
+	var targets []AffiliateSummaryTarget
+	for buyerID, buyerMonths := range months {
+		buyerTargets, err := AffiliateSummaryTargetsForBuyer(db, buyerID, buyerMonths...)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, buyerTargets...)
+	}
+	return targets, nil
+}
+
+// RefreshAffiliateSummariesForBonuses menghitung ulang ringkasan yang memuat bonus-bonus ini
+func RefreshAffiliateSummariesForBonuses(db *gorm.DB, bonuses ...models.AfiliasiBonus) error {
+	targets, err := AffiliateSummaryTargetsForBonuses(db, bonuses)
+	if err != nil {
+		return err
+	}
+	return RefreshAffiliateSummaries(db, targets)
+}
+
+// RefreshAffiliateSummariesForOrder menghitung ulang ringkasan setelah status pesanan atau bonusnya berubah
+func RefreshAffiliateSummariesForOrder(db *gorm.DB, pesanan *models.Pesanan) error {
+	months := []time.Time{pesanan.CreatedAt}
+
+	var bonuses []models.AfiliasiBonus
+	if err := db.Select("referral_user_id", "bonus_received_at").
+		Where("pesanan_id = ?", pesanan.ID).
+		Find(&bonuses).Error; err != nil {
+		return err
+	}
+	for _, bonus := range bonuses {
+		months = append(months, bonus.BonusReceivedAt)
+	}
+
+	targets, err := AffiliateSummaryTargetsForBuyer(db, pesanan.UserId, months...)
+	if err != nil {
+		return err
+	}
+	return RefreshAffiliateSummaries(db, targets)
+}
+
+// RebuildAffiliateSummaries membangun ulang seluruh ringkasan untuk satu bulan
+func RebuildAffiliateSummaries(db *gorm.DB, month time.Time) (int, error) {
+	month = SummaryMonth(month)
+
+	summaries, err := computeAffiliateSummaries(db, month, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([]models.AffiliateSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		rows = append(rows, *summary)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("month = ?", month).Delete(&models.AffiliateSummary{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+const (
+	SettingBonusConversionMultiplier = "bonusConversionMultiplier"
+	DefaultBonusConversionMultiplier = 1.0
+)
+
+var (
+	ErrNoConvertibleBonus  = errors.New("no claimed bonus available for conversion")
+	ErrConversionTooSmall  = errors.New("bonus amount is too small to convert into points")
+	ErrBonusNotConvertible = errors.New("some bonuses are not claimed, already paid out or converted")
+)
+
+// BonusConversionQuote adalah hasil perhitungan konversi sebelum disimpan
+type BonusConversionQuote struct {
+	Bonuses     []models.AfiliasiBonus `json:"-"`
+	BonusIDs    []uint                 `json:"bonusIds"`
+	BonusAmount float64                `json:"bonusAmount"`
+	PointRateID *uint                  `json:"pointRateId"`
+	PointRate   int                    `json:"pointRate"`
+	Multiplier  float64                `json:"multiplier"`
+	Points      int                    `json:"points"`
+}
+
+// GetBonusConversionMultiplier membaca pengali bonus konversi, mis. 1.1 = tambahan 10% poin
+func GetBonusConversionMultiplier(db *gorm.DB) float64 {
+	var setting models.Setting
+	if err := db.Where("key = ?", SettingBonusConversionMultiplier).First(&setting).Error; err == nil {
+		if v, err := strconv.ParseFloat(setting.Value, 64); err == nil && v > 0 {
+			return v
+		}
+	}
+	return DefaultBonusConversionMultiplier
+}
+
+// QuoteBonusConversion menghitung poin yang didapat dari bonus claimed milik user.
+// bonusIDs kosong berarti semua bonus claimed yang belum masuk payout batch.
+// Gunakan lock=true di dalam transaksi konversi agar bonus tidak ikut diproses paralel.
+func QuoteBonusConversion(db *gorm.DB, userID uint, bonusIDs []uint, lock bool) (*BonusConversionQuote, error) {
+	query := db.Where("user_id = ? AND status = ? AND payout_batch_id IS NULL", userID, models.BonusClaimed)
+	if len(bonusIDs) > 0 {
+		query = query.Where("id IN ?", bonusIDs)
+	}
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var bonuses []models.AfiliasiBonus
+	if err := query.Order("id ASC").Find(&bonuses).Error; err != nil {
+		return nil, err
+	}
+	if len(bonuses) == 0 {
+		return nil, ErrNoConvertibleBonus
+	}
+	if len(bonusIDs) > 0 && len(bonuses) != len(uniqueIDs(bonusIDs)) {
+		return nil, ErrBonusNotConvertible
+	}
+
+	rate, err := GetCurrentPointRate(db)
+	if err != nil {
+		return nil, err
+	}
+	if rate.Rate <= 0 {
+		return nil, ErrPointRateNotFound
+	}
+
+	quote := &BonusConversionQuote{
+		Bonuses:    bonuses,
+		PointRate:  rate.Rate,
+		Multiplier: GetBonusConversionMultiplier(db),
+	}
+	if rate.ID != 0 {
+		quote.PointRateID = &rate.ID
+	}
+	for _, bonus := range bonuses {
+		quote.BonusIDs = append(quote.BonusIDs, bonus.ID)
+		quote.BonusAmount += bonus.BonusAmount
+	}
+
+	// Sisa pecahan poin dibulatkan ke bawah
+	quote.Points = int(math.Floor(quote.BonusAmount * quote.Multiplier / float64(quote.PointRate)))
+	if quote.Points < 1 {
+		return nil, ErrConversionTooSmall
+	}
+
+	return quote, nil
+}
+
+// ConvertBonusesToPoints menukar bonus claimed menjadi poin secara atomik:
+// bonus ditandai converted, poin dikreditkan, dan keduanya merujuk ke BonusConversion yang sama.
+func ConvertBonusesToPoints(tx *gorm.DB, userID uint, bonusIDs []uint, idempotencyKey string) (*models.BonusConversion, error) {
+	quote, err := QuoteBonusConversion(tx, userID, bonusIDs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	conversion := models.BonusConversion{
+		ConversionID:   "BC" + strings.ToUpper(strings.Replace(uuid.New().String(), "-", "", -1)[:10]),
+		IdempotencyKey: idempotencyKey,
+		UserID:         userID,
+		BonusAmount:    quote.BonusAmount,
+		PointRateID:    quote.PointRateID,
+		PointRate:      quote.PointRate,
+		Multiplier:     quote.Multiplier,
+		Points:         quote.Points,
+		BonusCount:     len(quote.Bonuses),
+	}
+	if conversion.IdempotencyKey == "" {
+		conversion.IdempotencyKey = conversion.ConversionID
+	}
+	if err := tx.Create(&conversion).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := tx.Model(&models.AfiliasiBonus{}).
+		Where("id IN ?", quote.BonusIDs).
+		Updates(map[string]interface{}{
+			"status":              models.BonusConverted,
+			"converted_at":        now,
+			"bonus_conversion_id": conversion.ID,
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	if _, err := CreditPoints(tx, userID, conversion.Points, models.PointSourceBonusConvert, conversion.ConversionID); err != nil {
+		return nil, err
+	}
+
+	if err := RefreshAffiliateSummariesForBonuses(tx, quote.Bonuses...); err != nil {
+		return nil, err
+	}
+
+	for i := range quote.Bonuses {
+		quote.Bonuses[i].Status = models.BonusConverted
+		quote.Bonuses[i].ConvertedAt = &now
+		quote.Bonuses[i].BonusConversionID = &conversion.ID
+	}
+	conversion.Bonuses = quote.Bonuses
+	return &conversion, nil
+}
+
+func uniqueIDs(ids []uint) map[uint]bool {
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	return unique
+}
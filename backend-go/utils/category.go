@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryCycle    = errors.New("category cannot be moved under itself or its descendants")
+)
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CategoryNode adalah satu kategori pada tree beserta jumlah produknya (termasuk sub kategori)
+type CategoryNode struct {
+	ID           uint           `json:"id"`
+	ParentID     *uint          `json:"parentId"`
+	Name         string         `json:"name"`
+	Slug         string         `json:"slug"`
+	Icon         string         `json:"icon"`
+	DisplayOrder int            `json:"displayOrder"`
+	IsActive     bool           `json:"isActive"`
+	ProductCount int64          `json:"productCount"`
+	Children     []CategoryNode `json:"children"`
+}
+
+// Slugify mengubah nama kategori menjadi slug, mis. "Buah Import" menjadi "buah-import"
+func Slugify(name string) string {
+	slug := slugInvalidChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+	return strings.Trim(slug, "-")
+}
+
+// UniqueCategorySlug menambahkan akhiran angka jika slug sudah dipakai kategori lain
+func UniqueCategorySlug(db *gorm.DB, name string, excludeID uint) (string, error) {
+	base := Slugify(name)
+	if base == "" {
+		base = "kategori"
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := db.Model(&models.Category{}).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// FindCategory mencari kategori berdasarkan slug atau nama (tidak case-sensitive)
+func FindCategory(db *gorm.DB, value string) (*models.Category, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, ErrCategoryNotFound
+	}
+
+	var category models.Category
+	err := db.Where("slug = ? OR LOWER(name) = LOWER(?)", Slugify(value), value).
+		Order("id ASC").First(&category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// CategoryDescendantIDs mengembalikan ID kategori beserta seluruh sub kategorinya
+func CategoryDescendantIDs(db *gorm.DB, categoryID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Raw(`WITH RECURSIVE tree AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION
+		SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+	) SELECT id FROM tree`, categoryID).Scan(&ids).Error
+	return ids, err
+}
+
+// ValidateCategoryParent memastikan parent ada dan tidak membuat siklus
+func ValidateCategoryParent(db *gorm.DB, categoryID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+
+	var parent models.Category
+	if err := db.First(&parent, *parentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+
+	if categoryID == 0 {
+		return nil
+	}
+	descendants, err := CategoryDescendantIDs(db, categoryID)
+	if err != nil {
+		return err
+	}
+	for _, id := range descendants {
+		if id == *parentID {
+			return ErrCategoryCycle
+		}
+	}
+	return nil
+}
+
+// BuildCategoryTree menyusun tree kategori. Jumlah produk parent mencakup produk sub kategorinya.
+// Jika activeOnly, kategori nonaktif beserta sub kategorinya tidak ditampilkan.
+func BuildCategoryTree(db *gorm.DB, activeOnly bool) ([]CategoryNode, error) {
+	var categories []models.Category
+	if err := db.Order("display_order ASC, name ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	type productCount struct {
+		CategoryID uint
+		Total      int64
+	}
+	var counts []productCount
+	if err := db.Model(&models.Product{}).
+		Select("category_id, COUNT(*) AS total").
+		Where("category_id IS NOT NULL").
+		Group("category_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	countByCategory := make(map[uint]int64, len(counts))
+	for _, count := range counts {
+		countByCategory[count.CategoryID] = count.Total
+	}
+
+	childrenOf := make(map[uint][]models.Category)
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		childrenOf[*category.ParentID] = append(childrenOf[*category.ParentID], category)
+	}
+
+	var build func(categories []models.Category, depth int) []CategoryNode
+	build = func(categories []models.Category, depth int) []CategoryNode {
+		nodes := make([]CategoryNode, 0, len(categories))
+		for _, category := range categories {
+			if activeOnly && !category.IsActive {
+				continue
+			}
+			node := CategoryNode{
+				ID:           category.ID,
+				ParentID:     category.ParentID,
+				Name:         category.Name,
+				Slug:         category.Slug,
+				Icon:         category.Icon,
+				DisplayOrder: category.DisplayOrder,
+				IsActive:     category.IsActive,
+				ProductCount: countByCategory[category.ID],
+				Children:     []CategoryNode{},
+			}
+			// Batas kedalaman mencegah rekursi tak berujung jika data parent rusak
+			if depth < 10 {
+				node.Children = build(childrenOf[category.ID], depth+1)
+			}
+			for _, child := range node.Children {
+				node.ProductCount += child.ProductCount
+			}
+			nodes = append(nodes, node)
+		}
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return nodes[i].DisplayOrder < nodes[j].DisplayOrder
+		})
+		return nodes
+	}
+
+	return build(roots, 0), nil
+}
+
+// MigrateProductCategories membuat kategori dari teks Kategori produk lama dan mengisi CategoryID.
+// Teks yang hanya berbeda huruf besar/kecil atau spasi digabung ke satu kategori. Aman dijalankan berulang kali.
+func MigrateProductCategories(db *gorm.DB) (int, int64, error) {
+	var names []string
+	if err := db.Model(&models.Product{}).
+		Where("category_id IS NULL AND TRIM(kategori) <> ''").
+		Distinct("kategori").
+		Pluck("kategori", &names).Error; err != nil {
+		return 0, 0, err
+	}
+
+	created := 0
+	var updated int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range names {
+			category, err := FindCategory(tx, name)
+			if errors.Is(err, ErrCategoryNotFound) {
+				category = &models.Category{Name: strings.TrimSpace(name), Slug: Slugify(name), IsActive: true}
+				if category.Slug == "" {
+					continue
+				}
+				if err := tx.Create(category).Error; err != nil {
+					return err
+				}
+				created++
+			} else if err != nil {
+				return err
+			}
+
+			result := tx.Model(&models.Product{}).
+				Where("category_id IS NULL AND kategori = ?", name).
+				Updates(map[string]interface{}{"category_id": category.ID, "kategori": category.Name})
+			if result.Error != nil {
+				return result.Error
+			}
+			updated += result.RowsAffected
+		}
+		return nil
+	})
+	return created, updated, err
+}
+
+// CategoryFilterIDs mengubah parameter filter kategori (ID, slug, atau nama) menjadi ID kategori
+// beserta sub kategorinya. Kategori yang tidak ditemukan menghasilkan slice kosong.
+func CategoryFilterIDs(db *gorm.DB, value string) ([]uint, error) {
+	if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return CategoryDescendantIDs(db, uint(id))
+	}
+
+	category, err := FindCategory(db, value)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return []uint{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return CategoryDescendantIDs(db, category.ID)
+}
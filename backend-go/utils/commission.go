@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+const (
+	SettingBonusExpiryReminderDays = "bonusExpiryReminderDays"
+	// Penanda migrasi status bonus afiliasi lama sudah dijalankan
+	SettingLegacyAffiliateBonusesMigrated = "legacyAffiliateBonusesMigrated"
+)
+
+// GetBonusExpiryReminderDays membaca ambang hari pengingat bonus afiliasi yang akan kedaluwarsa
+func GetBonusExpiryReminderDays(db *gorm.DB) []int {
+	var setting models.Setting
+	if err := db.Where("key = ?", SettingBonusExpiryReminderDays).First(&setting).Error; err == nil {
+		if days := ParseReminderDays(setting.Value); len(days) > 0 {
+			return days
+		}
+	}
+	return []int{7, 1}
+}
+
+// DefaultCommissionRule adalah aturan lama yang dipakai selama admin belum membuat versi aturan:
+// 2 level (10% dan 5%) dari base tetap Rp 200.000, minimal pesanan Rp 200.000,
+// kedaluwarsa 1 bulan dan batas klaim Rp 500.000.
+func DefaultCommissionRule() *models.CommissionRule {
+	return &models.CommissionRule{
+		BaseType:     models.CommissionBaseFixed,
+		BaseAmount:   200000,
+		Threshold:    200000,
+		ExpiryMonths: 1,
+		LifetimeCap:  500000,
+		Levels: []models.CommissionRuleLevel{
+			{Level: 1, Percentage: 10},
+			{Level: 2, Percentage: 5},
+		},
+	}
+}
+
+// GetActiveCommissionRule mengembalikan versi aturan komisi yang sedang aktif
+func GetActiveCommissionRule(db *gorm.DB) (*models.CommissionRule, error) {
+	var rule models.CommissionRule
+	err := db.Preload("Levels", func(db *gorm.DB) *gorm.DB {
+		return db.Order("level ASC")
+	}).Where("is_active = ?", true).First(&rule).Error
+	if err == nil {
+		return &rule, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultCommissionRule(), nil
+	}
+	return nil, err
+}
+
+// CommissionExpiry menghitung tanggal kedaluwarsa bonus sesuai aturan
+func CommissionExpiry(rule *models.CommissionRule, from time.Time) time.Time {
+	return from.AddDate(0, rule.ExpiryMonths, rule.ExpiryDays)
+}
+
+// CommissionRuleForBonus memuat versi aturan komisi yang tercatat pada bonus, atau aturan default
+// untuk bonus lama tanpa versi aturan. cache boleh nil.
+func CommissionRuleForBonus(tx *gorm.DB, bonus *models.AfiliasiBonus, cache map[uint]*models.CommissionRule) (*models.CommissionRule, error) {
+	if bonus.CommissionRuleID == nil {
+		return DefaultCommissionRule(), nil
+	}
+	if rule, ok := cache[*bonus.CommissionRuleID]; ok {
+		return rule, nil
+	}
+
+	var rule models.CommissionRule
+	if err := tx.First(&rule, *bonus.CommissionRuleID).Error; err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		cache[*bonus.CommissionRuleID] = &rule
+	}
+	return &rule, nil
+}
+
+// CommissionBonusAmount menghitung bonus satu level untuk nilai pesanan (Rp), sebelum batas per pesanan
+func CommissionBonusAmount(rule *models.CommissionRule, percentage, orderValue float64) float64 {
+	base := rule.BaseAmount
+	if rule.BaseType == models.CommissionBaseOrder {
+		base = orderValue * rule.BasePercentage / 100
+	}
+
+	return math.Round(base * percentage / 100)
+}
+
+// CreateAffiliateBonuses membuat bonus afiliasi provisional untuk rantai referrer pembeli sesuai
+// aturan aktif. Bonus baru bisa diklaim setelah pesanan delivered. orderValue dalam Rupiah.
+// MaxBonusPerOrder membatasi total bonus semua level; level terdekat dengan pembeli didahulukan.
+func CreateAffiliateBonuses(tx *gorm.DB, rule *models.CommissionRule, buyer *models.User, pesananID uint, orderValue float64) error {
+	if orderValue < rule.Threshold {
+		return nil
+	}
+
+	var ruleID *uint
+	if rule.ID != 0 {
+		ruleID = &rule.ID
+	}
+
+	now := time.Now()
+	remaining := rule.MaxBonusPerOrder
+	referrerID := buyer.ReferredBy
+	for _, level := range rule.Levels {
+		if referrerID == nil {
+			break
+		}
+
+		var referrer models.User
+		if err := tx.First(&referrer, *referrerID).Error; err != nil {
+			break
+		}
+
+		amount := CommissionBonusAmount(rule, level.Percentage, orderValue)
+		if rule.MaxBonusPerOrder > 0 {
+			amount = math.Min(amount, remaining)
+			remaining -= amount
+		}
+		if amount > 0 {
+			bonus := models.AfiliasiBonus{
+				UserId:           referrer.ID,
+				ReferralUserId:   buyer.ID,
+				PesananId:        pesananID,
+				BonusAmount:      amount,
+				BonusLevel:       level.Level,
+				ExpiryDate:       CommissionExpiry(rule, now),
+				BonusReceivedAt:  now,
+				Status:           models.BonusAwaitingDelivery,
+				CommissionRuleID: ruleID,
+			}
+
+			// Bonus dengan indikasi self-referral ditahan untuk review admin setelah delivered
+			signals, err := DetectBonusFraud(tx, buyer, &referrer)
+			if err != nil {
+				return err
+			}
+			bonus.HoldReason = FraudHoldReason(signals)
+
+			if err := tx.Create(&bonus).Error; err != nil {
+				return err
+			}
+
+			if len(signals) > 0 {
+				for i := range signals {
+					signals[i].BonusID = &bonus.ID
+				}
+				if err := tx.Create(&signals).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// Pindah ke level berikutnya (referrer dari referrer saat ini)
+		referrerID = referrer.ReferredBy
+	}
+
+	return nil
+}
+
+// ConfirmAffiliateBonuses membuat bonus provisional sebuah pesanan bisa diklaim setelah pesanan delivered.
+// Masa berlaku bonus dihitung ulang sejak tanggal pengiriman. Bonus yang terindikasi fraud menjadi on_hold.
+func ConfirmAffiliateBonuses(tx *gorm.DB, pesananID uint) (int, error) {
+	var bonuses []models.AfiliasiBonus
+	if err := tx.Where("pesanan_id = ? AND status = ?", pesananID, models.BonusAwaitingDelivery).
+		Find(&bonuses).Error; err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	rules := make(map[uint]*models.CommissionRule)
+	for _, bonus := range bonuses {
+		rule, err := CommissionRuleForBonus(tx, &bonus, rules)
+		if err != nil {
+			return 0, err
+		}
+
+		status := models.BonusPending
+		if bonus.HoldReason != "" {
+			status = models.BonusOnHold
+		}
+
+		if err := tx.Model(&bonus).Updates(map[string]interface{}{
+			"status":            status,
+			"bonus_received_at": now,
+			"expiry_date":       CommissionExpiry(rule, now),
+		}).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return len(bonuses), nil
+}
+
+// VoidAffiliateBonuses membatalkan bonus yang belum diklaim saat pesanan dibatalkan atau dihapus.
+// Bonus yang sudah diklaim atau ditransfer tidak diubah.
+func VoidAffiliateBonuses(tx *gorm.DB, pesananID uint) (int64, error) {
+	result := tx.Model(&models.AfiliasiBonus{}).
+		Where("pesanan_id = ? AND status IN ?", pesananID,
+			[]models.AfiliasiBonusStatus{models.BonusAwaitingDelivery, models.BonusPending, models.BonusOnHold, models.BonusExpired}).
+		Updates(map[string]interface{}{
+			"status":    models.BonusVoided,
+			"voided_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
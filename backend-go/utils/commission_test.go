@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"backend-go/models"
+)
+
+func TestCommissionBonusAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       models.CommissionRule
+		percentage float64
+		orderValue float64
+		want       float64
+	}{
+		{
+			name:       "nominal tetap",
+			rule:       models.CommissionRule{BaseType: models.CommissionBaseFixed, BaseAmount: 10000},
+			percentage: 10,
+			orderValue: 250000,
+			want:       1000,
+		},
+		{
+			name:       "nominal tetap tidak tergantung nilai pesanan",
+			rule:       models.CommissionRule{BaseType: models.CommissionBaseFixed, BaseAmount: 10000},
+			percentage: 10,
+			orderValue: 50000,
+			want:       1000,
+		},
+		{
+			name:       "persentase nilai pesanan",
+			rule:       models.CommissionRule{BaseType: models.CommissionBaseOrder, BasePercentage: 10},
+			percentage: 50,
+			orderValue: 100000,
+			want:       5000,
+		},
+		{
+			name:       "dibulatkan ke rupiah terdekat",
+			rule:       models.CommissionRule{BaseType: models.CommissionBaseOrder, BasePercentage: 2.5},
+			percentage: 33,
+			orderValue: 12345,
+			want:       102,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommissionBonusAmount(&tt.rule, tt.percentage, tt.orderValue); got != tt.want {
+				t.Errorf("CommissionBonusAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateAffiliateBonusesCapsTotalPerOrder(t *testing.T) {
+	levels := []models.CommissionRuleLevel{
+		{Level: 1, Percentage: 50},
+		{Level: 2, Percentage: 30},
+		{Level: 3, Percentage: 20},
+	}
+
+	tests := []struct {
+		name       string
+		maxBonus   float64
+		threshold  float64
+		chain      int // Jumlah referrer di atas pembeli
+		orderValue float64
+		want       []float64
+	}{
+		{name: "tanpa batas", chain: 3, orderValue: 100000, want: []float64{5000, 3000, 2000}},
+		{name: "batas memotong level terjauh", maxBonus: 7000, chain: 3, orderValue: 100000, want: []float64{5000, 2000}},
+		{name: "batas lebih kecil dari level pertama", maxBonus: 4000, chain: 3, orderValue: 100000, want: []float64{4000}},
+		{name: "batas tidak tercapai", maxBonus: 20000, chain: 3, orderValue: 100000, want: []float64{5000, 3000, 2000}},
+		{name: "rantai referral lebih pendek dari level", chain: 2, orderValue: 100000, want: []float64{5000, 3000}},
+		{name: "di bawah nilai minimal pesanan", threshold: 150000, chain: 3, orderValue: 100000, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, &models.User{}, &models.AfiliasiBonus{}, &models.ReferralFraudSignal{},
+				&models.BankAccount{}, &models.Address{}, &models.DetailsUser{})
+
+			// Rantai referral: referrer[chain-1] -> ... -> referrer[0] -> pembeli
+			var referredBy *uint
+			for i := tt.chain; i >= 0; i-- {
+				user := models.User{
+					Email:        fmt.Sprintf("user%d@example.com", i),
+					Password:     "secret",
+					RoleID:       2,
+					ReferralCode: fmt.Sprintf("REF%d", i),
+					ReferredBy:   referredBy,
+				}
+				if err := db.Create(&user).Error; err != nil {
+					t.Fatal(err)
+				}
+				referredBy = &user.ID
+			}
+
+			var buyer models.User
+			if err := db.Where("referral_code = ?", "REF0").First(&buyer).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			rule := &models.CommissionRule{
+				BaseType:         models.CommissionBaseOrder,
+				BasePercentage:   10,
+				Threshold:        tt.threshold,
+				MaxBonusPerOrder: tt.maxBonus,
+				Levels:           levels,
+			}
+			if err := CreateAffiliateBonuses(db, rule, &buyer, 1, tt.orderValue); err != nil {
+				t.Fatal(err)
+			}
+
+			var bonuses []models.AfiliasiBonus
+			if err := db.Order("bonus_level ASC").Find(&bonuses).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(bonuses) != len(tt.want) {
+				t.Fatalf("got %d bonuses, want %d", len(bonuses), len(tt.want))
+			}
+			for i, bonus := range bonuses {
+				if bonus.BonusLevel != i+1 || bonus.BonusAmount != tt.want[i] {
+					t.Errorf("bonus %d = {level: %d, amount: %v}, want {%d, %v}", i, bonus.BonusLevel, bonus.BonusAmount, i+1, tt.want[i])
+				}
+				if bonus.Status != models.BonusAwaitingDelivery {
+					t.Errorf("bonus %d status = %s, want %s", i, bonus.Status, models.BonusAwaitingDelivery)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+var ErrFlashSaleNotFound = errors.New("flash sale not found")
+
+// IsFlashSaleRunning mengecek apakah flash sale sedang berjalan dan kuotanya masih ada
+func IsFlashSaleRunning(sale *models.FlashSale, now time.Time) bool {
+	return !now.Before(sale.StartAt) && now.Before(sale.EndAt) && sale.SoldQuantity < sale.Quota
+}
+
+// CountFlashSalePurchases menghitung unit promo yang sudah dibeli user (pesanan batal tidak dihitung)
+func CountFlashSalePurchases(db *gorm.DB, flashSaleID, userID uint) (int, error) {
+	var total int64
+	err := db.Model(&models.FlashSalePurchase{}).
+		Where("flash_sale_id = ? AND user_id = ? AND released_at IS NULL", flashSaleID, userID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&total).Error
+	return int(total), err
+}
+
+// ActiveFlashSales mengembalikan flash sale yang sedang berjalan per ProductItemID.
+// Jika ada lebih dari satu untuk varian yang sama, harga termurah yang dipakai.
+func ActiveFlashSales(db *gorm.DB, productItemIDs []uint) (map[uint]models.FlashSale, error) {
+	sales := make(map[uint]models.FlashSale)
+	if len(productItemIDs) == 0 {
+		return sales, nil
+	}
+
+	now := time.Now()
+	var rows []models.FlashSale
+	if err := db.Where("product_item_id IN ? AND is_active = ? AND start_at <= ? AND end_at > ? AND sold_quantity < quota",
+		productItemIDs, true, now, now).
+		Order("harga_rp ASC, id ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, sale := range rows {
+		if _, ok := sales[sale.ProductItemID]; !ok {
+			sales[sale.ProductItemID] = sale
+		}
+	}
+	return sales, nil
+}
+
+// AttachFlashSales mengisi ProductItem.FlashSale pada produk yang variannya sedang flash sale.
+// Jika userID diisi, sisa jatah pembelian user ikut dihitung.
+func AttachFlashSales(db *gorm.DB, products []models.Product, userID uint) error {
+	var ids []uint
+	for _, product := range products {
+		for _, item := range product.ProductItems {
+			ids = append(ids, item.ID)
+		}
+	}
+
+	sales, err := ActiveFlashSales(db, ids)
+	if err != nil || len(sales) == 0 {
+		return err
+	}
+
+	for i := range products {
+		for j := range products[i].ProductItems {
+			item := &products[i].ProductItems[j]
+			sale, ok := sales[item.ID]
+			if !ok {
+				continue
+			}
+			offer := &models.FlashSaleOffer{
+				FlashSaleID: sale.ID,
+				Name:        sale.Name,
+				HargaRp:     sale.HargaRp,
+				HargaPoin:   sale.HargaPoin,
+				Remaining:   sale.Quota - sale.SoldQuantity,
+				MaxPerUser:  sale.MaxPerUser,
+				EndAt:       sale.EndAt,
+			}
+			if sale.MaxPerUser > 0 && userID != 0 {
+				used, err := CountFlashSalePurchases(db, sale.ID, userID)
+				if err != nil {
+					return err
+				}
+				remaining := sale.MaxPerUser - used
+				if remaining < 0 {
+					remaining = 0
+				}
+				offer.UserRemaining = &remaining
+			}
+			item.FlashSale = offer
+		}
+	}
+	return nil
+}
+
+// ClaimFlashSale menentukan harga satuan item pesanan di server: harga flash sale jika sale sedang
+// berjalan dan kuota serta batas per user masih cukup, selain itu harga normal varian (HargaPoin
+// untuk pesanan poin, HargaRp untuk lainnya). Row flash sale dikunci sehingga kuota dan batas per
+// user tidak terlewati oleh checkout paralel. Purchase bernilai nil jika item dibeli dengan harga normal.
+func ClaimFlashSale(tx *gorm.DB, productItem *models.ProductItem, userID, pesananID uint, quantity int, payWithPoin bool) (int, *models.FlashSalePurchase, error) {
+	regularPrice := productItem.HargaRp
+	if payWithPoin {
+		regularPrice = productItem.HargaPoin
+	}
+
+	sales, err := ActiveFlashSales(tx, []uint{productItem.ID})
+	if err != nil {
+		return 0, nil, err
+	}
+	candidate, ok := sales[productItem.ID]
+	if !ok {
+		return regularPrice, nil, nil
+	}
+
+	var sale models.FlashSale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, candidate.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return regularPrice, nil, nil
+		}
+		return 0, nil, err
+	}
+	promoPrice := sale.HargaRp
+	if payWithPoin {
+		promoPrice = sale.HargaPoin
+	}
+	if promoPrice >= regularPrice || !sale.IsActive || !IsFlashSaleRunning(&sale, time.Now()) || sale.Quota-sale.SoldQuantity < quantity {
+		return regularPrice, nil, nil
+	}
+
+	if sale.MaxPerUser > 0 {
+		used, err := CountFlashSalePurchases(tx, sale.ID, userID)
+		if err != nil {
+			return 0, nil, err
+		}
+		if used+quantity > sale.MaxPerUser {
+			return regularPrice, nil, nil
+		}
+	}
+
+	sold := sale.SoldQuantity + quantity
+	if err := tx.Model(&sale).Updates(map[string]interface{}{
+		"sold_quantity": sold,
+		"is_active":     sold < sale.Quota, // Langsung nonaktif begitu kuota habis
+	}).Error; err != nil {
+		return 0, nil, err
+	}
+
+	purchase := models.FlashSalePurchase{
+		FlashSaleID: sale.ID,
+		UserID:      userID,
+		PesananID:   pesananID,
+		Quantity:    quantity,
+	}
+	if err := tx.Create(&purchase).Error; err != nil {
+		return 0, nil, err
+	}
+	return promoPrice, &purchase, nil
+}
+
+// ReleaseFlashSalePurchases mengembalikan kuota flash sale dari pesanan yang dibatalkan.
+// Aman dipanggil berulang karena pembelian yang sudah dilepas diabaikan.
+func ReleaseFlashSalePurchases(tx *gorm.DB, pesananID uint) error {
+	var purchases []models.FlashSalePurchase
+	if err := tx.Where("pesanan_id = ? AND released_at IS NULL", pesananID).Find(&purchases).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, purchase := range purchases {
+		var sale models.FlashSale
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, purchase.FlashSaleID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+
+		sold := sale.SoldQuantity - purchase.Quantity
+		if sold < 0 {
+			sold = 0
+		}
+		sale.SoldQuantity = sold
+		if err := tx.Model(&sale).Updates(map[string]interface{}{
+			"sold_quantity": sold,
+			"is_active":     IsFlashSaleRunning(&sale, now),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&purchase).Update("released_at", now).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncFlashSales mengaktifkan flash sale yang sudah mulai dan menonaktifkan yang sudah
+// berakhir atau kuotanya habis.
+func SyncFlashSales(db *gorm.DB) (activated int, deactivated int, err error) {
+	now := time.Now()
+
+	result := db.Model(&models.FlashSale{}).
+		Where("is_active = ? AND (start_at > ? OR end_at <= ? OR sold_quantity >= quota)", true, now, now).
+		Update("is_active", false)
+	if result.Error != nil {
+		return 0, 0, fmt.Errorf("deactivate flash sales: %w", result.Error)
+	}
+	deactivated = int(result.RowsAffected)
+
+	result = db.Model(&models.FlashSale{}).
+		Where("is_active = ? AND start_at <= ? AND end_at > ? AND sold_quantity < quota", false, now, now).
+		Update("is_active", true)
+	if result.Error != nil {
+		return 0, deactivated, fmt.Errorf("activate flash sales: %w", result.Error)
+	}
+	return int(result.RowsAffected), deactivated, nil
+}
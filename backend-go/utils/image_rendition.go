@@ -0,0 +1,351 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chai2010/webp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+type ImageSize string
+
+const (
+	ImageSizeThumb    ImageSize = "thumb"
+	ImageSizeMedium   ImageSize = "medium"
+	ImageSizeLarge    ImageSize = "large"
+	ImageSizeOriginal ImageSize = ""
+
+	// RenditionDir adalah sub folder uploads tempat hasil resize disimpan
+	RenditionDir = "renditions"
+
+	jpegQuality = 85
+	webpQuality = 80
+)
+
+// Sisi terpanjang (px) tiap ukuran rendition
+var imageSizeDimensions = map[ImageSize]int{
+	ImageSizeThumb:  200,
+	ImageSizeMedium: 600,
+	ImageSizeLarge:  1200,
+}
+
+// ImageSizes adalah semua ukuran rendition yang dibuat saat upload
+var ImageSizes = []ImageSize{ImageSizeThumb, ImageSizeMedium, ImageSizeLarge}
+
+// ParseImageSize membaca parameter ?size=; nilai tidak dikenal berarti ukuran asli
+func ParseImageSize(value string) ImageSize {
+	size := ImageSize(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := imageSizeDimensions[size]; ok {
+		return size
+	}
+	return ImageSizeOriginal
+}
+
+// RenditionName menghasilkan nama file rendition, mis. 123_ab_thumb.webp.
+// Gambar PNG/GIF memakai PNG agar transparansi tetap terjaga, selain itu JPEG.
+func RenditionName(filename string, size ImageSize, asWebP bool) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	if size != ImageSizeOriginal {
+		base += "_" + string(size)
+	}
+
+	switch {
+	case asWebP:
+		return base + ".webp"
+	case ext == ".png" || ext == ".gif":
+		return base + ".png"
+	default:
+		return base + ".jpg"
+	}
+}
+
+// ResolveImagePath memilih file terbaik yang tersedia untuk ukuran dan format yang diminta,
+// dengan fallback ke rendition non-WebP lalu file asli. Path relatif terhadap UploadDir.
+func ResolveImagePath(filename string, size ImageSize, acceptWebP bool) string {
+	filename = filepath.Base(filename)
+	var candidates []string
+	if acceptWebP {
+		candidates = append(candidates, filepath.Join(RenditionDir, RenditionName(filename, size, true)))
+	}
+	if size != ImageSizeOriginal {
+		candidates = append(candidates, filepath.Join(RenditionDir, RenditionName(filename, size, false)))
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(filepath.Join(UploadDir, candidate)); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return filename
+}
+
+// StripImageMetadata menulis ulang JPEG/PNG sehingga metadata EXIF (lokasi GPS, info kamera) terbuang.
+// Orientasi Exif diterapkan lebih dulu agar foto tidak terputar. GIF dan WebP tidak ditulis ulang.
+func StripImageMetadata(path string) error {
+	img, format, err := decodeImageFile(path)
+	if err != nil {
+		return err
+	}
+	if format != "jpeg" && format != "png" {
+		return nil
+	}
+	return writeImage(path, img, format)
+}
+
+// GenerateImageRenditions membuat versi thumb/medium/large (format asli dan WebP) serta WebP ukuran asli
+func GenerateImageRenditions(path string) error {
+	img, _, err := decodeImageFile(path)
+	if err != nil {
+		return err
+	}
+	return generateImageRenditions(path, img)
+}
+
+// GenerateMissingRenditions membuat rendition untuk upload lama yang belum punya rendition
+func GenerateMissingRenditions(filename string) (bool, error) {
+	filename = filepath.Base(filename)
+	thumb := filepath.Join(UploadDir, RenditionDir, RenditionName(filename, ImageSizeThumb, false))
+	if _, err := os.Stat(thumb); err == nil {
+		return false, nil
+	}
+
+	path := filepath.Join(UploadDir, filename)
+	img, _, err := decodeImageFile(path)
+	if err != nil {
+		return false, err
+	}
+	return true, generateImageRenditions(path, img)
+}
+
+// RemoveImageRenditions menghapus semua rendition milik satu file upload
+func RemoveImageRenditions(filename string) {
+	filename = filepath.Base(filename)
+	sizes := append([]ImageSize{ImageSizeOriginal}, ImageSizes...)
+	for _, size := range sizes {
+		os.Remove(filepath.Join(UploadDir, RenditionDir, RenditionName(filename, size, true)))
+		os.Remove(filepath.Join(UploadDir, RenditionDir, RenditionName(filename, size, false)))
+	}
+}
+
+func generateImageRenditions(path string, img image.Image) error {
+	dir := filepath.Join(filepath.Dir(path), RenditionDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	filename := filepath.Base(path)
+	format := "jpeg"
+	if strings.HasSuffix(RenditionName(filename, ImageSizeThumb, false), ".png") {
+		format = "png"
+	}
+
+	// Versi WebP ukuran asli untuk klien yang mendukung WebP
+	if err := writeImage(filepath.Join(dir, RenditionName(filename, ImageSizeOriginal, true)), img, "webp"); err != nil {
+		return err
+	}
+
+	for _, size := range ImageSizes {
+		resized := resizeImage(img, imageSizeDimensions[size])
+		if err := writeImage(filepath.Join(dir, RenditionName(filename, size, false)), resized, format); err != nil {
+			return err
+		}
+		if err := writeImage(filepath.Join(dir, RenditionName(filename, size, true)), resized, "webp"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resizeImage memperkecil gambar agar sisi terpanjangnya maxSide; gambar kecil tidak diperbesar
+func resizeImage(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return img
+	}
+
+	if width >= height {
+		height = height * maxSide / width
+		width = maxSide
+	} else {
+		width = width * maxSide / height
+		height = maxSide
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+func writeImage(path string, img image.Image, format string) error {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "webp":
+		err = webp.Encode(&buf, img, &webp.Options{Quality: webpQuality})
+	case "png":
+		err = png.Encode(&buf, img)
+	default:
+		err = jpeg.Encode(&buf, flattenImage(img), &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return err
+	}
+
+	// Tulis ke file sementara lalu rename agar file tidak pernah terbaca setengah jadi
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// flattenImage menaruh gambar transparan di atas latar putih sebelum disimpan sebagai JPEG
+func flattenImage(img image.Image) image.Image {
+	if _, ok := img.(*image.YCbCr); ok {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+func decodeImageFile(path string) (image.Image, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var img image.Image
+	var format string
+	if bytes.HasPrefix(data, []byte("GIF8")) {
+		img, err = gif.Decode(bytes.NewReader(data))
+		format = "gif"
+	} else {
+		img, format, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if format == "jpeg" {
+		img = applyExifOrientation(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// jpegOrientation membaca tag Orientation (0x0112) dari segmen APP1 Exif. 1 berarti normal.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if marker == 0xDA || length < 2 || pos+2+length > len(data) {
+			return 1 // Start of scan: tidak ada Exif sebelum data gambar
+		}
+
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyExifOrientation memutar/membalik gambar sesuai orientasi Exif karena metadata akan dibuang
+func applyExifOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	swap := orientation >= 5
+	dstWidth, dstHeight := width, height
+	if swap {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Flip horizontal
+				dx, dy = width-1-x, y
+			case 3: // Rotate 180
+				dx, dy = width-1-x, height-1-y
+			case 4: // Flip vertical
+				dx, dy = x, height-1-y
+			case 5: // Transpose
+				dx, dy = y, x
+			case 6: // Rotate 90 CW
+				dx, dy = height-1-y, x
+			case 7: // Transverse
+				dx, dy = height-1-y, width-1-x
+			case 8: // Rotate 90 CCW
+				dx, dy = y, width-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+var (
+	ErrInsufficientStock       = errors.New("insufficient stock")
+	ErrStockItemNotFound       = errors.New("product item not found")
+	ErrStockReasonRequired     = errors.New("reason is required for stock adjustments")
+	ErrInvalidStockQuantity    = errors.New("invalid stock quantity")
+	ErrInvalidStockAdjustment  = errors.New("adjustment type must be adjustment, receiving or spoilage")
+	ErrInvalidReorderThreshold = errors.New("reorder threshold must not be negative")
+)
+
+// StockChange adalah satu perubahan stok yang akan dicatat sebagai StockMovement
+type StockChange struct {
+	ProductItemID uint
+	Quantity      int // Positif = masuk, negatif = keluar
+	Type          models.StockMovementType
+	Reason        string
+	ReferenceType string
+	ReferenceID   *uint
+	ActorID       *uint
+}
+
+// RecordStockMovement mengubah stok varian dan mencatat pergerakannya dalam satu transaksi.
+// Baris ProductItem dikunci agar checkout yang bersamaan tidak membuat stok minus.
+func RecordStockMovement(tx *gorm.DB, change StockChange) (*models.StockMovement, error) {
+	if change.Quantity == 0 {
+		return nil, nil
+	}
+
+	var item models.ProductItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, change.ProductItemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStockItemNotFound
+		}
+		return nil, err
+	}
+
+	stockAfter := item.Stok + change.Quantity
+	if stockAfter < 0 {
+		return nil, fmt.Errorf("%w: available %d", ErrInsufficientStock, item.Stok)
+	}
+
+	if err := tx.Model(&models.ProductItem{}).Where("id = ?", item.ID).Update("stok", stockAfter).Error; err != nil {
+		return nil, err
+	}
+
+	movement := models.StockMovement{
+		ProductItemID: item.ID,
+		Type:          change.Type,
+		Quantity:      change.Quantity,
+		StockBefore:   item.Stok,
+		StockAfter:    stockAfter,
+		Reason:        strings.TrimSpace(change.Reason),
+		ReferenceType: change.ReferenceType,
+		ReferenceID:   change.ReferenceID,
+		ActorID:       change.ActorID,
+	}
+	if err := tx.Create(&movement).Error; err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// RecordSale mengurangi stok untuk item pesanan yang baru dibuat
+func RecordSale(tx *gorm.DB, productItemID uint, quantity int, pesananID uint, userID uint) (*models.StockMovement, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidStockQuantity
+	}
+	return RecordStockMovement(tx, StockChange{
+		ProductItemID: productItemID,
+		Quantity:      -quantity,
+		Type:          models.StockSale,
+		ReferenceType: models.StockReferenceOrder,
+		ReferenceID:   &pesananID,
+		ActorID:       &userID,
+	})
+}
+
+// RecordInitialStock mencatat stok awal varian baru. Stok sudah tersimpan saat varian dibuat,
+// jadi hanya pergerakannya yang dicatat.
+func RecordInitialStock(tx *gorm.DB, item *models.ProductItem, actorID *uint) error {
+	if item.Stok == 0 {
+		return nil
+	}
+	productID := item.ProductID
+	return tx.Create(&models.StockMovement{
+		ProductItemID: item.ID,
+		Type:          models.StockInitial,
+		Quantity:      item.Stok,
+		StockBefore:   0,
+		StockAfter:    item.Stok,
+		Reason:        "Stok awal",
+		ReferenceType: models.StockReferenceProduct,
+		ReferenceID:   &productID,
+		ActorID:       actorID,
+	}).Error
+}
+
+// AdjustStock mencatat perubahan stok manual oleh admin. Quantity untuk receiving dan spoilage
+// selalu positif (arah ditentukan jenisnya), sedangkan adjustment memakai selisih bertanda.
+func AdjustStock(tx *gorm.DB, productItemID uint, movementType models.StockMovementType, quantity int, reason string, actorID *uint) (*models.StockMovement, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrStockReasonRequired
+	}
+
+	switch movementType {
+	case models.StockReceiving:
+		if quantity <= 0 {
+			return nil, ErrInvalidStockQuantity
+		}
+	case models.StockSpoilage:
+		if quantity <= 0 {
+			return nil, ErrInvalidStockQuantity
+		}
+		quantity = -quantity
+	case models.StockAdjustment:
+		if quantity == 0 {
+			return nil, ErrInvalidStockQuantity
+		}
+	default:
+		return nil, ErrInvalidStockAdjustment
+	}
+
+	return RecordStockMovement(tx, StockChange{
+		ProductItemID: productItemID,
+		Quantity:      quantity,
+		Type:          movementType,
+		Reason:        reason,
+		ActorID:       actorID,
+	})
+}
+
+// SetStockLevel menyetel stok ke nilai absolut (stock opname) dan mencatat selisihnya sebagai adjustment
+func SetStockLevel(tx *gorm.DB, productItemID uint, stock int, reason string, actorID *uint) (*models.StockMovement, error) {
+	if stock < 0 {
+		return nil, ErrInvalidStockQuantity
+	}
+
+	var item models.ProductItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, productItemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStockItemNotFound
+		}
+		return nil, err
+	}
+	if item.Stok == stock {
+		return nil, nil
+	}
+	return AdjustStock(tx, productItemID, models.StockAdjustment, stock-item.Stok, reason, actorID)
+}
+
+// OrderHoldsStock melaporkan apakah pesanan dengan status ini masih menahan stok, yaitu belum
+// dikirim ke pelanggan. Pesanan delivered/completed sudah diterima pelanggan, dan pesanan
+// cancelled sudah dikembalikan stoknya saat dibatalkan.
+func OrderHoldsStock(status models.PesananStatus) bool {
+	return status == models.PesananPending
+}
+
+// RestockCancelledOrder mengembalikan stok item pesanan yang dibatalkan. Aman dipanggil
+// berulang kali: pesanan yang sudah pernah di-restock dilewati.
+func RestockCancelledOrder(tx *gorm.DB, pesananID uint, actorID *uint) ([]models.StockMovement, error) {
+	return RestockOrder(tx, pesananID, "Pesanan dibatalkan", actorID)
+}
+
+// RestockOrder mengembalikan stok semua item pesanan dengan alasan yang diberikan, mis. saat
+// pesanan dibatalkan atau dihapus. Harus dipanggil sebelum OrderItem dihapus.
+func RestockOrder(tx *gorm.DB, pesananID uint, reason string, actorID *uint) ([]models.StockMovement, error) {
+	var restocked int64
+	if err := tx.Model(&models.StockMovement{}).
+		Where("type = ? AND reference_type = ? AND reference_id = ?", models.StockRestock, models.StockReferenceOrder, pesananID).
+		Count(&restocked).Error; err != nil {
+		return nil, err
+	}
+	if restocked > 0 {
+		return nil, nil
+	}
+
+	var items []models.OrderItem
+	if err := tx.Where("pesanan_id = ?", pesananID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var movements []models.StockMovement
+	for _, item := range items {
+		if item.Jumlah <= 0 {
+			continue
+		}
+		movement, err := RecordStockMovement(tx, StockChange{
+			ProductItemID: item.ProductItemID,
+			Quantity:      item.Jumlah,
+			Type:          models.StockRestock,
+			Reason:        reason,
+			ReferenceType: models.StockReferenceOrder,
+			ReferenceID:   &pesananID,
+			ActorID:       actorID,
+		})
+		if errors.Is(err, ErrStockItemNotFound) {
+			// Varian sudah dihapus, tidak ada stok yang bisa dikembalikan
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, *movement)
+	}
+	return movements, nil
+}
+
+// NotifyLowStock mengirim alert Telegram untuk varian yang stoknya baru saja turun di bawah
+// batas reorder. Dipanggil setelah commit agar alert tidak terkirim untuk transaksi yang batal.
+func NotifyLowStock(db *gorm.DB, movements ...models.StockMovement) {
+	var itemIDs []uint
+	for _, movement := range movements {
+		if movement.Quantity < 0 {
+			itemIDs = append(itemIDs, movement.ProductItemID)
+		}
+	}
+	if len(itemIDs) == 0 {
+		return
+	}
+
+	var items []models.ProductItem
+	if err := db.Preload("Product").
+		Where("id IN ? AND reorder_threshold > 0", itemIDs).
+		Find(&items).Error; err != nil {
+		log.Printf("Gagal memuat varian untuk alert stok: %v", err)
+		return
+	}
+
+	byID := make(map[uint]models.ProductItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	var lowItems []models.ProductItem
+	alerted := make(map[uint]bool)
+	for _, movement := range movements {
+		item, ok := byID[movement.ProductItemID]
+		if !ok || alerted[item.ID] {
+			continue
+		}
+		// Alert hanya saat melewati batas, bukan setiap penjualan di bawah batas
+		if movement.StockBefore >= item.ReorderThreshold && movement.StockAfter < item.ReorderThreshold {
+			alerted[item.ID] = true
+			item.Stok = movement.StockAfter
+			lowItems = append(lowItems, item)
+		}
+	}
+	if len(lowItems) == 0 {
+		return
+	}
+
+	SendTelegramNotification(FormatTelegramLowStockMessage(lowItems))
+}
+
+// FormatTelegramLowStockMessage menyusun pesan alert stok menipis
+func FormatTelegramLowStockMessage(items []models.ProductItem) string {
+	var sb strings.Builder
+
+	sb.WriteString("⚠️ <b>STOK MENIPIS</b>\n")
+	sb.WriteString("──────────────────\n")
+	for i, item := range items {
+		prefix := "├"
+		if i == len(items)-1 {
+			prefix = "╰"
+		}
+		name := fmt.Sprintf("Varian #%d", item.ID)
+		if item.Product != nil {
+			name = item.Product.NameProduk
+		}
+		sb.WriteString(fmt.Sprintf("%s %s (%d %s)\n", prefix, html.EscapeString(name), item.Jumlah, html.EscapeString(item.Satuan)))
+		sb.WriteString(fmt.Sprintf("│   ╰ Sisa %d • Batas %d\n", item.Stok, item.ReorderThreshold))
+	}
+	sb.WriteString("──────────────────\n")
+	sb.WriteString("Segera lakukan restock.")
+
+	return sb.String()
+}
+
+// SetReorderThreshold mengubah batas reorder sebuah varian
+func SetReorderThreshold(db *gorm.DB, productItemID uint, threshold int) (*models.ProductItem, error) {
+	if threshold < 0 {
+		return nil, ErrInvalidReorderThreshold
+	}
+
+	var item models.ProductItem
+	if err := db.First(&item, productItemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStockItemNotFound
+		}
+		return nil, err
+	}
+	if err := db.Model(&item).Update("reorder_threshold", threshold).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"testing"
+
+	"backend-go/models"
+)
+
+func TestRestockOrderIsIdempotent(t *testing.T) {
+	db := newTestDB(t, &models.ProductItem{}, &models.OrderItem{}, &models.StockMovement{})
+
+	items := []models.ProductItem{
+		{ProductID: 1, Stok: 5, HargaRp: 10000, Jumlah: 1, Satuan: "kg"},
+		{ProductID: 1, Stok: 0, HargaRp: 5000, Jumlah: 500, Satuan: "gr"},
+	}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	const pesananID = 7
+	orderItems := []models.OrderItem{
+		{PesananID: pesananID, ProductItemID: items[0].ID, NamaProduk: "Bayam", Jumlah: 3, Satuan: "kg"},
+		{PesananID: pesananID, ProductItemID: items[1].ID, NamaProduk: "Bayam", Jumlah: 2, Satuan: "gr"},
+		{PesananID: pesananID, ProductItemID: 999, NamaProduk: "Varian dihapus", Jumlah: 1, Satuan: "kg"},
+	}
+	if err := db.Create(&orderItems).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		wantMovements int
+	}{
+		{name: "restock pertama", wantMovements: 2},
+		{name: "restock ulang dilewati", wantMovements: 0},
+	}
+
+	for _, tt := range tests {
+		movements, err := RestockOrder(db, pesananID, "Pesanan dihapus", nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(movements) != tt.wantMovements {
+			t.Errorf("%s: got %d movements, want %d", tt.name, len(movements), tt.wantMovements)
+		}
+	}
+
+	for i, want := range []int{8, 2} {
+		var item models.ProductItem
+		if err := db.First(&item, items[i].ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if item.Stok != want {
+			t.Errorf("item %d stok = %d, want %d", i, item.Stok, want)
+		}
+	}
+}
+
+func TestOrderHoldsStock(t *testing.T) {
+	tests := []struct {
+		status models.PesananStatus
+		want   bool
+	}{
+		{models.PesananPending, true},
+		{models.PesananCompleted, false},
+		{models.PesananDelivered, false},
+		{models.PesananCancelled, false},
+	}
+
+	for _, tt := range tests {
+		if got := OrderHoldsStock(tt.status); got != tt.want {
+			t.Errorf("OrderHoldsStock(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
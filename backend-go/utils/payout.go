@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+var (
+	ErrNoBonusesToPay      = errors.New("no claimed bonuses with a bank account to pay out")
+	ErrPayoutBatchNotDraft = errors.New("payout batch is not in draft status")
+)
+
+// PayoutSkippedUser adalah affiliate dengan bonus claimed yang tidak masuk batch karena belum punya rekening
+type PayoutSkippedUser struct {
+	UserID     uint    `json:"userId"`
+	Email      string  `json:"email"`
+	Amount     float64 `json:"amount"`
+	BonusCount int     `json:"bonusCount"`
+}
+
+// CreatePayoutBatch mengunci semua bonus claimed yang belum masuk batch (opsional dibatasi
+// periode claimed_at) lalu mengelompokkannya per user beserta rekening banknya.
+func CreatePayoutBatch(tx *gorm.DB, periodStart, periodEnd *time.Time, createdBy *uint) (*models.PayoutBatch, []PayoutSkippedUser, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status = ? AND payout_batch_id IS NULL", models.BonusClaimed)
+	if periodStart != nil {
+		query = query.Where("claimed_at >= ?", *periodStart)
+	}
+	if periodEnd != nil {
+		query = query.Where("claimed_at < ?", *periodEnd)
+	}
+
+	var bonuses []models.AfiliasiBonus
+	if err := query.Order("user_id ASC, id ASC").Find(&bonuses).Error; err != nil {
+		return nil, nil, err
+	}
+
+	bonusIDs := make(map[uint][]uint)
+	amounts := make(map[uint]float64)
+	var userIDs []uint
+	for _, bonus := range bonuses {
+		if _, ok := bonusIDs[bonus.UserId]; !ok {
+			userIDs = append(userIDs, bonus.UserId)
+		}
+		bonusIDs[bonus.UserId] = append(bonusIDs[bonus.UserId], bonus.ID)
+		amounts[bonus.UserId] += bonus.BonusAmount
+	}
+
+	var users []models.User
+	if len(userIDs) > 0 {
+		if err := tx.Preload("BankAccount").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	usersByID := make(map[uint]*models.User, len(users))
+	for i := range users {
+		usersByID[users[i].ID] = &users[i]
+	}
+
+	batch := models.PayoutBatch{
+		BatchNumber: "PO" + time.Now().Format("20060102") + strings.ToUpper(strings.Replace(uuid.New().String(), "-", "", -1)[:6]),
+		Status:      models.PayoutDraft,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		CreatedBy:   createdBy,
+	}
+
+	var skipped []PayoutSkippedUser
+	var includedBonusIDs []uint
+	for _, userID := range userIDs {
+		user := usersByID[userID]
+		if user == nil || user.BankAccount == nil {
+			skipped = append(skipped, PayoutSkippedUser{
+				UserID:     userID,
+				Email:      userEmail(user),
+				Amount:     amounts[userID],
+				BonusCount: len(bonusIDs[userID]),
+			})
+			continue
+		}
+
+		batch.Items = append(batch.Items, models.PayoutBatchItem{
+			UserID:        userID,
+			BankAccountID: user.BankAccount.ID,
+			AccountHolder: user.BankAccount.AccountHolder,
+			BankName:      user.BankAccount.BankName,
+			AccountNumber: user.BankAccount.AccountNumber,
+			Amount:        amounts[userID],
+			BonusCount:    len(bonusIDs[userID]),
+		})
+		batch.TotalAmount += amounts[userID]
+		batch.TotalBonuses += len(bonusIDs[userID])
+		includedBonusIDs = append(includedBonusIDs, bonusIDs[userID]...)
+	}
+	batch.TotalUsers = len(batch.Items)
+
+	if len(batch.Items) == 0 {
+		return nil, skipped, ErrNoBonusesToPay
+	}
+
+	if err := tx.Create(&batch).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Model(&models.AfiliasiBonus{}).
+		Where("id IN ?", includedBonusIDs).
+		Update("payout_batch_id", batch.ID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &batch, skipped, nil
+}
+
+// MarkPayoutBatchPaid menandai batch sudah ditransfer: semua bonus di dalamnya menjadi transferred
+func MarkPayoutBatchPaid(tx *gorm.DB, batchID uint, paidBy *uint) (*models.PayoutBatch, error) {
+	var batch models.PayoutBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, batchID).Error; err != nil {
+		return nil, err
+	}
+	if batch.Status != models.PayoutDraft {
+		return nil, ErrPayoutBatchNotDraft
+	}
+
+	var bonuses []models.AfiliasiBonus
+	if err := tx.Select("id", "referral_user_id", "bonus_received_at").
+		Where("payout_batch_id = ? AND status = ?", batch.ID, models.BonusClaimed).
+		Find(&bonuses).Error; err != nil {
+		return nil, err
+	}
+	summaryTargets, err := AffiliateSummaryTargetsForBonuses(tx, bonuses)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := tx.Model(&models.AfiliasiBonus{}).
+		Where("payout_batch_id = ? AND status = ?", batch.ID, models.BonusClaimed).
+		Updates(map[string]interface{}{
+			"status":         models.BonusTransferred,
+			"transferred_at": now,
+		}).Error; err != nil {
+		return nil, err
+	}
+	if err := RefreshAffiliateSummaries(tx, summaryTargets); err != nil {
+		return nil, err
+	}
+
+	batch.Status = models.PayoutPaid
+	batch.PaidAt = &now
+	batch.PaidBy = paidBy
+	if err := tx.Save(&batch).Error; err != nil {
+		return nil, err
+	}
+
+	// FCM token dibutuhkan untuk notifikasi ke setiap affiliate
+	if err := tx.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "email", "fcm_token")
+	}).Where("batch_id = ?", batch.ID).Find(&batch.Items).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// CancelPayoutBatch membatalkan batch draft dan melepas bonusnya agar bisa masuk batch berikutnya
+func CancelPayoutBatch(tx *gorm.DB, batchID uint) (*models.PayoutBatch, error) {
+	var batch models.PayoutBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, batchID).Error; err != nil {
+		return nil, err
+	}
+	if batch.Status != models.PayoutDraft {
+		return nil, ErrPayoutBatchNotDraft
+	}
+
+	if err := tx.Model(&models.AfiliasiBonus{}).
+		Where("payout_batch_id = ? AND status = ?", batch.ID, models.BonusClaimed).
+		Update("payout_batch_id", nil).Error; err != nil {
+		return nil, err
+	}
+
+	batch.Status = models.PayoutCancelled
+	if err := tx.Save(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// RenderPayoutBatchCSV membuat file transfer massal dengan layout yang umum dipakai
+// upload bulk transfer internet banking bisnis (BCA/Mandiri/BNI):
+// No, No Rekening, Nama Penerima, Nama Bank, Nominal, Berita, Email.
+// Nominal ditulis dalam Rupiah tanpa desimal.
+func RenderPayoutBatchCSV(batch *models.PayoutBatch) ([]byte, error) {
+	items := make([]models.PayoutBatchItem, len(batch.Items))
+	copy(items, batch.Items)
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"No", "No Rekening", "Nama Penerima", "Nama Bank", "Nominal", "Berita", "Email"},
+	}
+	for i, item := range items {
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			sanitizeAccountNumber(item.AccountNumber),
+			item.AccountHolder,
+			item.BankName,
+			strconv.FormatFloat(math.Round(item.Amount), 'f', 0, 64),
+			"Bonus " + batch.BatchNumber,
+			userEmail(item.User),
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PayoutBatchFileName nama file CSV untuk diunggah ke internet banking
+func PayoutBatchFileName(batch *models.PayoutBatch) string {
+	return fmt.Sprintf("payout-%s.csv", batch.BatchNumber)
+}
+
+func sanitizeAccountNumber(accountNumber string) string {
+	return strings.NewReplacer(" ", "", "-", "", ".", "").Replace(accountNumber)
+}
+
+func userEmail(user *models.User) string {
+	if user == nil {
+		return ""
+	}
+	return user.Email
+}
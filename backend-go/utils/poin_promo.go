@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+var (
+	ErrPromoNotAvailable = errors.New("promo is not available")
+	ErrPromoLimitReached = errors.New("promo purchase limit reached")
+)
+
+// IsPromoRunning mengecek apakah waktu sekarang berada dalam periode promo
+func IsPromoRunning(promo *models.PoinPromo, now time.Time) bool {
+	return !now.Before(promo.StartAt) && now.Before(promo.EndAt)
+}
+
+// CountPromoPurchases menghitung berapa kali user sudah top up memakai promo
+func CountPromoPurchases(db *gorm.DB, promoID, userID uint) (int, error) {
+	var count int64
+	err := db.Model(&models.TopUpPoin{}).
+		Where("promo_id = ? AND user_id = ? AND status = ?", promoID, userID, "success").
+		Count(&count).Error
+	return int(count), err
+}
+
+// ClaimPoinPromo memvalidasi promo untuk top up user dengan row lock pada promo,
+// sehingga batas pembelian per user tidak terlewati oleh request paralel.
+func ClaimPoinPromo(tx *gorm.DB, promoID, userID uint, points int) (*models.PoinPromo, error) {
+	var promo models.PoinPromo
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Poin").
+		First(&promo, promoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromoNotAvailable
+		}
+		return nil, err
+	}
+
+	if !IsPromoRunning(&promo, time.Now()) || promo.Poin == nil || promo.Poin.Poin != points {
+		return nil, ErrPromoNotAvailable
+	}
+
+	if promo.MaxPerUser > 0 {
+		used, err := CountPromoPurchases(tx, promo.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= promo.MaxPerUser {
+			return nil, ErrPromoLimitReached
+		}
+	}
+
+	return &promo, nil
+}
+
+// SyncPoinPromos mengaktifkan promo yang sudah mulai dan menonaktifkan promo yang
+// sudah berakhir. Diskon paket poin ikut dipasang/dilepas sesuai status promo.
+func SyncPoinPromos(db *gorm.DB) (activated int, deactivated int, err error) {
+	now := time.Now()
+
+	var toActivate []models.PoinPromo
+	if err := db.Where("is_active = ? AND start_at <= ? AND end_at > ?", false, now, now).
+		Find(&toActivate).Error; err != nil {
+		return 0, 0, err
+	}
+
+	var toDeactivate []models.PoinPromo
+	if err := db.Where("is_active = ? AND (start_at > ? OR end_at <= ?)", true, now, now).
+		Find(&toDeactivate).Error; err != nil {
+		return 0, 0, err
+	}
+
+	// Promo dinonaktifkan lebih dulu agar diskon promo pengganti pada paket yang sama tidak terhapus
+	for _, promo := range toDeactivate {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return setPoinPromoActive(tx, &promo, false)
+		}); err != nil {
+			return activated, deactivated, fmt.Errorf("deactivate promo %d: %w", promo.ID, err)
+		}
+		deactivated++
+	}
+
+	for _, promo := range toActivate {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return setPoinPromoActive(tx, &promo, true)
+		}); err != nil {
+			return activated, deactivated, fmt.Errorf("activate promo %d: %w", promo.ID, err)
+		}
+		activated++
+	}
+
+	return activated, deactivated, nil
+}
+
+func setPoinPromoActive(tx *gorm.DB, promo *models.PoinPromo, active bool) error {
+	if active {
+		if err := applyPoinPromoDiscount(tx, promo); err != nil {
+			return err
+		}
+	} else if err := restorePoinPromoDiscount(tx, promo); err != nil {
+		return err
+	}
+	return tx.Model(promo).Update("is_active", active).Error
+}
+
+// ReapplyPoinPromo memasang ulang diskon promo yang sedang aktif setelah promo diedit,
+// sehingga persentase baru berlaku dan diskon yang sudah tidak dipakai dilepas.
+func ReapplyPoinPromo(tx *gorm.DB, promo *models.PoinPromo) error {
+	if !promo.IsActive {
+		return nil
+	}
+	if err := restorePoinPromoDiscount(tx, promo); err != nil {
+		return err
+	}
+	return applyPoinPromoDiscount(tx, promo)
+}
+
+// applyPoinPromoDiscount memasang diskon promo pada paket poin dan menyimpan diskon serta
+// promo product ID yang dipasang admin sebelumnya agar bisa dipulihkan.
+func applyPoinPromoDiscount(tx *gorm.DB, promo *models.PoinPromo) error {
+	if promo.DiscountPercentage <= 0 || promo.DiscountApplied {
+		return nil
+	}
+
+	var poin models.Poin
+	if err := tx.Preload("Discount").First(&poin, promo.PoinID).Error; err != nil {
+		return err
+	}
+
+	promo.DiscountApplied = true
+	promo.PreviousPromoProductID = poin.PromoProductID
+	promo.PreviousDiscountPercentage = nil
+	if poin.Discount != nil {
+		percentage := poin.Discount.Percentage
+		promo.PreviousDiscountPercentage = &percentage
+	}
+	if err := tx.Model(promo).Updates(map[string]interface{}{
+		"discount_applied":             true,
+		"previous_promo_product_id":    promo.PreviousPromoProductID,
+		"previous_discount_percentage": promo.PreviousDiscountPercentage,
+	}).Error; err != nil {
+		return err
+	}
+
+	promoProductID := fmt.Sprintf("points_%.0f_%d", promo.DiscountPercentage, poin.Poin)
+	return setPoinDiscount(tx, &poin, promoProductID, &promo.DiscountPercentage)
+}
+
+// restorePoinPromoDiscount mengembalikan diskon paket ke kondisi sebelum promo diaktifkan.
+// Yang dilepas ditentukan dari apa yang dipasang saat aktivasi, bukan dari persentase promo saat ini.
+func restorePoinPromoDiscount(tx *gorm.DB, promo *models.PoinPromo) error {
+	if !promo.DiscountApplied {
+		// Promo aktif dari sebelum diskon sebelumnya disimpan: lepas diskon seperti perilaku lama
+		if !promo.IsActive || promo.DiscountPercentage <= 0 {
+			return nil
+		}
+		promo.PreviousPromoProductID = ""
+		promo.PreviousDiscountPercentage = nil
+	}
+
+	var poin models.Poin
+	if err := tx.Preload("Discount").First(&poin, promo.PoinID).Error; err != nil {
+		return err
+	}
+	if err := setPoinDiscount(tx, &poin, promo.PreviousPromoProductID, promo.PreviousDiscountPercentage); err != nil {
+		return err
+	}
+
+	promo.DiscountApplied = false
+	promo.PreviousPromoProductID = ""
+	promo.PreviousDiscountPercentage = nil
+	return tx.Model(promo).Updates(map[string]interface{}{
+		"discount_applied":             false,
+		"previous_promo_product_id":    "",
+		"previous_discount_percentage": nil,
+	}).Error
+}
+
+// setPoinDiscount memasang promo product ID dan diskon paket; percentage nil menghapus diskon
+func setPoinDiscount(tx *gorm.DB, poin *models.Poin, promoProductID string, percentage *float64) error {
+	if err := tx.Model(poin).Update("promo_product_id", promoProductID).Error; err != nil {
+		return err
+	}
+
+	if percentage == nil {
+		if poin.Discount != nil {
+			return tx.Delete(poin.Discount).Error
+		}
+		return nil
+	}
+
+	if poin.Discount != nil {
+		poin.Discount.Percentage = *percentage
+		return tx.Save(poin.Discount).Error
+	}
+
+	discount := models.Discount{
+		Percentage: *percentage,
+		PoinID:     poin.ID,
+	}
+	return tx.Create(&discount).Error
+}
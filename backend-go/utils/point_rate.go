@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+const SettingHargaPoin = "hargaPoin"
+
+var ErrPointRateNotFound = errors.New("point rate not found")
+
+// RepricePreview berisi harga lama dan baru satu product item untuk dry-run repricing
+type RepricePreview struct {
+	ProductItemID uint   `json:"productItemId"`
+	ProductID     uint   `json:"productId"`
+	NameProduk    string `json:"nameProduk"`
+	Jumlah        int    `json:"jumlah"`
+	Satuan        string `json:"satuan"`
+	HargaPoin     int    `json:"hargaPoin"`
+	OldHargaRp    int    `json:"oldHargaRp"`
+	NewHargaRp    int    `json:"newHargaRp"`
+}
+
+// GetCurrentPointRate mengembalikan versi nilai poin yang terakhir diberlakukan.
+// Jika belum ada riwayat, nilai diambil dari setting hargaPoin lama dengan ID 0.
+func GetCurrentPointRate(db *gorm.DB) (*models.PointRate, error) {
+	var rate models.PointRate
+	err := db.Where("applied_at IS NOT NULL").
+		Order("applied_at DESC, id DESC").
+		First(&rate).Error
+	if err == nil {
+		return &rate, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var setting models.Setting
+	if err := db.Where("key = ?", SettingHargaPoin).First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPointRateNotFound
+		}
+		return nil, err
+	}
+
+	value, err := strconv.Atoi(setting.Value)
+	if err != nil || value <= 0 {
+		return nil, ErrPointRateNotFound
+	}
+
+	return &models.PointRate{Rate: value}, nil
+}
+
+// PreviewPointRate menghitung harga Rupiah baru setiap product item tanpa menyimpan apa pun
+func PreviewPointRate(db *gorm.DB, rate int) ([]RepricePreview, error) {
+	var items []models.ProductItem
+	if err := db.Preload("Product").
+		Where("harga_poin <> 0").
+		Order("product_id ASC, id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	previews := make([]RepricePreview, len(items))
+	for i, item := range items {
+		preview := RepricePreview{
+			ProductItemID: item.ID,
+			ProductID:     item.ProductID,
+			Jumlah:        item.Jumlah,
+			Satuan:        item.Satuan,
+			HargaPoin:     item.HargaPoin,
+			OldHargaRp:    item.HargaRp,
+			NewHargaRp:    item.HargaPoin * rate,
+		}
+		if item.Product != nil {
+			preview.NameProduk = item.Product.NameProduk
+		}
+		previews[i] = preview
+	}
+
+	return previews, nil
+}
+
+// ApplyPointRate memberlakukan versi nilai poin: memperbarui setting hargaPoin dan
+// menghitung ulang hargaRp semua product item dalam satu statement. Harus dipanggil
+// di dalam transaksi agar setting, harga, dan status versi berubah bersamaan.
+// Mengembalikan jumlah product item yang diperbarui.
+func ApplyPointRate(tx *gorm.DB, rateID uint) (int64, error) {
+	var rate models.PointRate
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND applied_at IS NULL", rateID).
+		First(&rate).Error; err != nil {
+		return 0, err
+	}
+
+	setting := models.Setting{Key: SettingHargaPoin, Value: strconv.Itoa(rate.Rate)}
+	if err := tx.Save(&setting).Error; err != nil {
+		return 0, err
+	}
+
+	// Riwayat harga dicatat sebelum harga dihitung ulang, hanya untuk varian yang harganya berubah
+	if err := tx.Exec(`INSERT INTO price_histories
+		(product_item_id, old_harga_rp, old_harga_poin, harga_rp, harga_poin, source, reference_id, created_at)
+		SELECT id, harga_rp, harga_poin, harga_poin * ?, harga_poin, ?, ?, NOW()
+		FROM product_items WHERE harga_poin <> 0 AND harga_rp <> harga_poin * ?`,
+		rate.Rate, models.PriceSourcePointRate, rate.ID, rate.Rate).Error; err != nil {
+		return 0, err
+	}
+
+	result := tx.Model(&models.ProductItem{}).
+		Where("harga_poin <> 0").
+		Update("harga_rp", gorm.Expr("harga_poin * ?", rate.Rate))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if err := tx.Model(&rate).Update("applied_at", time.Now()).Error; err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,297 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+const (
+	SettingPoinExpiryMonths       = "poinExpiryMonths"
+	SettingPoinExpiryReminderDays = "poinExpiryReminderDays"
+	SettingPoinTransferDailyLimit = "poinTransferDailyLimit"
+	SettingPoinStatementAutoEmail = "poinStatementAutoEmail"
+
+	DefaultPoinTransferDailyLimit = 1000
+)
+
+var ErrInsufficientPoints = errors.New("insufficient points")
+
+// GetPointExpiryConfig membaca aturan kedaluwarsa poin dari tabel setting.
+// months = 0 berarti poin tidak pernah kedaluwarsa.
+func GetPointExpiryConfig(db *gorm.DB) (int, []int) {
+	months := 0
+	reminderDays := []int{30, 7}
+
+	var setting models.Setting
+	if err := db.Where("key = ?", SettingPoinExpiryMonths).First(&setting).Error; err == nil {
+		if v, err := strconv.Atoi(setting.Value); err == nil && v > 0 {
+			months = v
+		}
+	}
+
+	if err := db.Where("key = ?", SettingPoinExpiryReminderDays).First(&setting).Error; err == nil {
+		days := ParseReminderDays(setting.Value)
+		if len(days) > 0 {
+			reminderDays = days
+		}
+	}
+
+	return months, reminderDays
+}
+
+// GetPointTransferDailyLimit membaca batas total poin yang boleh ditransfer user per hari
+func GetPointTransferDailyLimit(db *gorm.DB) int {
+	var setting models.Setting
+	if err := db.Where("key = ?", SettingPoinTransferDailyLimit).First(&setting).Error; err == nil {
+		if v, err := strconv.Atoi(setting.Value); err == nil && v >= 0 {
+			return v
+		}
+	}
+	return DefaultPoinTransferDailyLimit
+}
+
+// ParseReminderDays mengubah "30,7" menjadi []int{30, 7}
+func ParseReminderDays(value string) []int {
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			continue
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+// LockUserPoints mengambil saldo poin user dengan row lock, dan membuatnya jika belum ada
+func LockUserPoints(tx *gorm.DB, userID uint) (*models.UserPoints, error) {
+	var userPoints models.UserPoints
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&userPoints).Error
+	if err == nil {
+		return &userPoints, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	userPoints = models.UserPoints{UserID: userID, Points: 0}
+	if err := tx.Create(&userPoints).Error; err != nil {
+		return nil, err
+	}
+	return &userPoints, nil
+}
+
+// CreditPoints menambah saldo poin user dan mencatatnya sebagai lot baru
+func CreditPoints(tx *gorm.DB, userID uint, points int, source models.PointSource, reference string) (*models.UserPoints, error) {
+	userPoints, err := LockUserPoints(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if points <= 0 {
+		return userPoints, nil
+	}
+
+	now := time.Now()
+	lot := models.PointLot{
+		UserID:     userID,
+		Source:     source,
+		Reference:  reference,
+		Points:     points,
+		Remaining:  points,
+		CreditedAt: now,
+	}
+
+	months, _ := GetPointExpiryConfig(tx)
+	if months > 0 {
+		expiresAt := now.AddDate(0, months, 0)
+		lot.ExpiresAt = &expiresAt
+	}
+
+	if err := tx.Create(&lot).Error; err != nil {
+		return nil, err
+	}
+
+	userPoints.Points += points
+	if err := tx.Save(userPoints).Error; err != nil {
+		return nil, err
+	}
+
+	if err := recordPointHistory(tx, userID, points, userPoints.Points, source, reference); err != nil {
+		return nil, err
+	}
+
+	return userPoints, nil
+}
+
+// DebitPoints mengurangi saldo poin user dan memakai lot yang paling cepat kedaluwarsa terlebih dahulu
+func DebitPoints(tx *gorm.DB, userID uint, points int, source models.PointSource, reference string) (*models.UserPoints, error) {
+	userPoints, err := LockUserPoints(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if points <= 0 {
+		return userPoints, nil
+	}
+	if userPoints.Points < points {
+		return nil, ErrInsufficientPoints
+	}
+
+	if err := consumePointLots(tx, userID, userPoints.Points, points); err != nil {
+		return nil, err
+	}
+
+	userPoints.Points -= points
+	if err := tx.Save(userPoints).Error; err != nil {
+		return nil, err
+	}
+
+	if err := recordPointHistory(tx, userID, -points, userPoints.Points, source, reference); err != nil {
+		return nil, err
+	}
+
+	return userPoints, nil
+}
+
+// RefundOrderPoints mengembalikan poin yang dipakai pesanan yang dibatalkan sebagai mutasi refund.
+// Jumlahnya diambil dari debit pesanan di point_history, sehingga pesanan COD tidak terpengaruh.
+// Aman dipanggil berulang kali: pesanan yang sudah di-refund dilewati.
+func RefundOrderPoints(tx *gorm.DB, pesanan *models.Pesanan) (int, error) {
+	var spent int64
+	if err := tx.Model(&models.PointHistory{}).
+		Where("user_id = ? AND source = ? AND reference = ?", pesanan.UserId, models.PointSourceOrder, pesanan.OrderId).
+		Select("COALESCE(-SUM(points), 0)").
+		Scan(&spent).Error; err != nil {
+		return 0, err
+	}
+	if spent <= 0 {
+		return 0, nil
+	}
+
+	var refunded int64
+	if err := tx.Model(&models.PointHistory{}).
+		Where("user_id = ? AND source = ? AND reference = ?", pesanan.UserId, models.PointSourceRefund, pesanan.OrderId).
+		Count(&refunded).Error; err != nil {
+		return 0, err
+	}
+	if refunded > 0 {
+		return 0, nil
+	}
+
+	if _, err := CreditPoints(tx, pesanan.UserId, int(spent), models.PointSourceRefund, pesanan.OrderId); err != nil {
+		return 0, err
+	}
+	return int(spent), nil
+}
+
+// recordPointHistory mencatat mutasi poin ke buku besar point_history
+func recordPointHistory(tx *gorm.DB, userID uint, points, balanceAfter int, source models.PointSource, reference string) error {
+	history := models.PointHistory{
+		UserID:       userID,
+		Source:       source,
+		Reference:    reference,
+		Points:       points,
+		BalanceAfter: balanceAfter,
+	}
+	return tx.Create(&history).Error
+}
+
+// consumePointLots memakai lot secara FIFO. Saldo lama yang belum tercatat
+// sebagai lot (sebelum fitur kedaluwarsa ada) dianggap paling tua dan dipakai lebih dulu.
+func consumePointLots(tx *gorm.DB, userID uint, balance, points int) error {
+	var lots []models.PointLot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND remaining > 0 AND expired_at IS NULL", userID).
+		Order("expires_at ASC NULLS LAST, credited_at ASC, id ASC").
+		Find(&lots).Error; err != nil {
+		return err
+	}
+
+	tracked := 0
+	for _, lot := range lots {
+		tracked += lot.Remaining
+	}
+
+	untracked := balance - tracked
+	if untracked < 0 {
+		untracked = 0
+	}
+
+	toConsume := points - untracked
+	for i := range lots {
+		if toConsume <= 0 {
+			break
+		}
+
+		used := lots[i].Remaining
+		if used > toConsume {
+			used = toConsume
+		}
+
+		lots[i].Remaining -= used
+		toConsume -= used
+
+		if err := tx.Model(&lots[i]).Update("remaining", lots[i].Remaining).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExpirePointLot menghanguskan sisa poin pada lot dan mengurangi saldo user.
+// Mengembalikan jumlah poin yang benar-benar dikurangi dari saldo.
+func ExpirePointLot(tx *gorm.DB, lotID uint) (int, error) {
+	var owner models.PointLot
+	if err := tx.Select("id", "user_id").Where("id = ?", lotID).First(&owner).Error; err != nil {
+		return 0, err
+	}
+
+	// Kunci saldo user lebih dulu lalu lot, urutan yang sama dengan DebitPoints, agar tidak deadlock
+	// dengan checkout yang berjalan bersamaan
+	userPoints, err := LockUserPoints(tx, owner.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	var lot models.PointLot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND expired_at IS NULL", lotID).
+		First(&lot).Error; err != nil {
+		return 0, err
+	}
+
+	expired := lot.Remaining
+	if expired > userPoints.Points {
+		expired = userPoints.Points
+	}
+
+	now := time.Now()
+	if err := tx.Model(&lot).Updates(map[string]interface{}{
+		"remaining":  0,
+		"expired_at": now,
+	}).Error; err != nil {
+		return 0, err
+	}
+
+	if expired > 0 {
+		userPoints.Points -= expired
+		if err := tx.Save(userPoints).Error; err != nil {
+			return 0, err
+		}
+
+		if err := recordPointHistory(tx, lot.UserID, -expired, userPoints.Points, models.PointSourceExpiry, strconv.Itoa(int(lot.ID))); err != nil {
+			return 0, err
+		}
+	}
+
+	return expired, nil
+}
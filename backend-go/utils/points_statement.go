@@ -0,0 +1,414 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+const (
+	StatementTopUp      = "topup"
+	StatementBonus      = "bonus"
+	StatementSpending   = "spending"
+	StatementRefund     = "refund"
+	StatementAdjustment = "adjustment"
+	StatementTransfer   = "transfer"
+	StatementExpiry     = "expiry"
+	StatementOther      = "other"
+)
+
+// PointStatementEntry adalah satu baris mutasi pada laporan poin bulanan
+type PointStatementEntry struct {
+	Date        time.Time `json:"date"`
+	Category    string    `json:"category"`
+	Description string    `json:"description"`
+	Reference   string    `json:"reference"`
+	Points      int       `json:"points"`
+}
+
+// PointStatement adalah laporan mutasi poin seorang user untuk satu bulan
+type PointStatement struct {
+	UserID         uint                  `json:"userId"`
+	Name           string                `json:"name"`
+	Email          string                `json:"email"`
+	PeriodStart    time.Time             `json:"periodStart"`
+	PeriodEnd      time.Time             `json:"periodEnd"`
+	OpeningBalance int                   `json:"openingBalance"`
+	Totals         map[string]int        `json:"totals"`
+	ClosingBalance int                   `json:"closingBalance"`
+	Entries        []PointStatementEntry `json:"entries"`
+}
+
+// ParseStatementMonth mengubah "2006-01" menjadi awal bulan; kosong berarti bulan lalu
+func ParseStatementMonth(value string) (time.Time, error) {
+	if value == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0), nil
+	}
+	month, err := time.ParseInLocation("2006-01", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, expected YYYY-MM", value)
+	}
+	return month, nil
+}
+
+// BuildPointStatement menyusun laporan poin bulanan dari point_history, buku besar yang ditulis
+// setiap kali saldo berubah (top up, pesanan, refund, penyesuaian admin, transfer, kedaluwarsa).
+// Periode sebelum buku besar user dimulai diambil dari TopUpPoin dan Pesanan berbayar poin.
+// Saldo penutup dihitung mundur dari saldo saat ini, lalu saldo pembuka = saldo penutup - total
+// mutasi bulan tersebut.
+func BuildPointStatement(db *gorm.DB, userID uint, month time.Time) (*PointStatement, error) {
+	var user models.User
+	if err := db.Preload("Details").Preload("Points").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	periodStart := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	periodEnd := periodStart.AddDate(0, 1, 0)
+
+	ledgerStart, err := pointLedgerStart(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := pointStatementEntries(db, userID, periodStart, periodEnd, ledgerStart)
+	if err != nil {
+		return nil, err
+	}
+
+	currentBalance := 0
+	if user.Points != nil {
+		currentBalance = user.Points.Points
+	}
+
+	closingBalance := currentBalance
+	if periodEnd.Before(time.Now()) {
+		later, err := pointStatementEntries(db, userID, periodEnd, time.Now(), ledgerStart)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range later {
+			closingBalance -= e.Points
+		}
+	}
+
+	totals := map[string]int{
+		StatementTopUp:      0,
+		StatementBonus:      0,
+		StatementSpending:   0,
+		StatementRefund:     0,
+		StatementAdjustment: 0,
+		StatementTransfer:   0,
+		StatementExpiry:     0,
+		StatementOther:      0,
+	}
+	net := 0
+	for _, e := range entries {
+		totals[e.Category] += e.Points
+		net += e.Points
+	}
+
+	statement := &PointStatement{
+		UserID:         user.ID,
+		Email:          user.Email,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodEnd.Add(-time.Second),
+		OpeningBalance: closingBalance - net,
+		Totals:         totals,
+		ClosingBalance: closingBalance,
+		Entries:        entries,
+	}
+	if user.Details != nil {
+		statement.Name = user.Details.Fullname
+	}
+
+	return statement, nil
+}
+
+// pointLedgerStart mengembalikan waktu mutasi point_history pertama user, atau nil jika belum ada
+func pointLedgerStart(db *gorm.DB, userID uint) (*time.Time, error) {
+	var history models.PointHistory
+	err := db.Select("created_at").Where("user_id = ?", userID).Order("created_at ASC, id ASC").First(&history).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &history.CreatedAt, nil
+}
+
+func pointStatementEntries(db *gorm.DB, userID uint, from, to time.Time, ledgerStart *time.Time) ([]PointStatementEntry, error) {
+	var entries []PointStatementEntry
+
+	// Sebelum buku besar dimulai, mutasi diambil dari tabel sumbernya
+	legacyTo := to
+	if ledgerStart != nil && ledgerStart.Before(legacyTo) {
+		legacyTo = *ledgerStart
+	}
+	if from.Before(legacyTo) {
+		legacy, err := legacyPointStatementEntries(db, userID, from, legacyTo)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, legacy...)
+	}
+
+	var histories []models.PointHistory
+	if err := db.Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Order("created_at ASC, id ASC").
+		Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	for _, h := range histories {
+		entries = append(entries, PointStatementEntry{
+			Date:        h.CreatedAt,
+			Category:    statementCategory(h.Source),
+			Description: statementDescription(h.Source, h.Reference),
+			Reference:   h.Reference,
+			Points:      h.Points,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+	return entries, nil
+}
+
+// legacyPointStatementEntries mengambil top up dari TopUpPoin dan pemakaian dari Pesanan berbayar
+// poin untuk periode sebelum point_history ada
+func legacyPointStatementEntries(db *gorm.DB, userID uint, from, to time.Time) ([]PointStatementEntry, error) {
+	var entries []PointStatementEntry
+
+	var topUps []models.TopUpPoin
+	if err := db.Where("user_id = ? AND status = ? AND created_at >= ? AND created_at < ?", userID, "success", from, to).
+		Find(&topUps).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range topUps {
+		entries = append(entries, PointStatementEntry{
+			Date:        t.CreatedAt,
+			Category:    StatementTopUp,
+			Description: statementDescription(models.PointSourceTopUp, t.TopupID),
+			Reference:   t.TopupID,
+			Points:      t.Points,
+		})
+		if t.BonusPoints > 0 {
+			entries = append(entries, PointStatementEntry{
+				Date:        t.CreatedAt,
+				Category:    StatementBonus,
+				Description: statementDescription(models.PointSourcePromoBonus, t.TopupID),
+				Reference:   t.TopupID,
+				Points:      t.BonusPoints,
+			})
+		}
+	}
+
+	// Pesanan berbayar poin ditandai dengan harga_poin terisi (pesanan COD tidak mengisinya)
+	var orders []models.Pesanan
+	if err := db.Where("user_id = ? AND harga_poin > 0 AND created_at >= ? AND created_at < ?", userID, from, to).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		entries = append(entries, PointStatementEntry{
+			Date:        o.CreatedAt,
+			Category:    StatementSpending,
+			Description: statementDescription(models.PointSourceOrder, o.OrderId),
+			Reference:   o.OrderId,
+			Points:      -o.TotalBayar,
+		})
+	}
+	return entries, nil
+}
+
+func statementCategory(source models.PointSource) string {
+	switch source {
+	case models.PointSourceTopUp:
+		return StatementTopUp
+	case models.PointSourcePromoBonus:
+		return StatementBonus
+	case models.PointSourceOrder:
+		return StatementSpending
+	case models.PointSourceRefund:
+		return StatementRefund
+	case models.PointSourceAdjustment:
+		return StatementAdjustment
+	case models.PointSourceTransferIn, models.PointSourceTransferOut:
+		return StatementTransfer
+	case models.PointSourceExpiry:
+		return StatementExpiry
+	case models.PointSourceBonusConvert, models.PointSourceReferral:
+		return StatementBonus
+	default:
+		return StatementOther
+	}
+}
+
+func statementDescription(source models.PointSource, reference string) string {
+	switch source {
+	case models.PointSourceTopUp:
+		return "Top up poin"
+	case models.PointSourcePromoBonus:
+		return "Bonus promo top up"
+	case models.PointSourceOrder:
+		return "Pesanan " + reference
+	case models.PointSourceRefund:
+		return "Pengembalian poin pesanan " + reference
+	case models.PointSourceAdjustment:
+		return "Penyesuaian oleh admin"
+	case models.PointSourceTransferIn:
+		return "Transfer masuk"
+	case models.PointSourceTransferOut:
+		return "Transfer keluar"
+	case models.PointSourceExpiry:
+		return "Poin kedaluwarsa"
+	case models.PointSourceBonusConvert:
+		return "Konversi bonus afiliasi"
+	case models.PointSourceReferral:
+		return "Hadiah referral"
+	default:
+		return string(source)
+	}
+}
+
+// StatementFileName menghasilkan nama file laporan, mis. statement-poin-2026-09.pdf
+func StatementFileName(statement *PointStatement, ext string) string {
+	return "statement-poin-" + statement.PeriodStart.Format("2006-01") + "." + ext
+}
+
+// RenderPointStatementCSV menulis laporan poin sebagai CSV
+func RenderPointStatementCSV(statement *PointStatement) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"Nama", statement.Name},
+		{"Email", statement.Email},
+		{"Periode", statement.PeriodStart.Format("01-2006")},
+		{"Saldo Awal", strconv.Itoa(statement.OpeningBalance)},
+		{},
+		{"Tanggal", "Kategori", "Keterangan", "Referensi", "Poin"},
+	}
+	for _, e := range statement.Entries {
+		rows = append(rows, []string{
+			e.Date.Format("2006-01-02 15:04"),
+			e.Category,
+			e.Description,
+			e.Reference,
+			strconv.Itoa(e.Points),
+		})
+	}
+	rows = append(rows,
+		[]string{},
+		[]string{"Top Up", strconv.Itoa(statement.Totals[StatementTopUp])},
+		[]string{"Bonus", strconv.Itoa(statement.Totals[StatementBonus])},
+		[]string{"Pemakaian", strconv.Itoa(statement.Totals[StatementSpending])},
+		[]string{"Refund", strconv.Itoa(statement.Totals[StatementRefund])},
+		[]string{"Penyesuaian", strconv.Itoa(statement.Totals[StatementAdjustment])},
+		[]string{"Transfer", strconv.Itoa(statement.Totals[StatementTransfer])},
+		[]string{"Kedaluwarsa", strconv.Itoa(statement.Totals[StatementExpiry])},
+		[]string{"Lainnya", strconv.Itoa(statement.Totals[StatementOther])},
+		[]string{"Saldo Akhir", strconv.Itoa(statement.ClosingBalance)},
+	)
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPointStatementPDF menulis laporan poin sebagai PDF satu kolom tabel
+func RenderPointStatementPDF(statement *PointStatement) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	// Font bawaan fpdf memakai cp1252, teks UTF-8 (nama, keterangan) diterjemahkan lebih dulu
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle("Statement Poin "+statement.PeriodStart.Format("01-2006"), true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "Laporan Poin Bulanan", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, "Nama: "+tr(statement.Name), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Email: "+tr(statement.Email), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Periode: "+statement.PeriodStart.Format("02-01-2006")+" s/d "+statement.PeriodEnd.Format("02-01-2006"), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 7, "Saldo Awal: "+strconv.Itoa(statement.OpeningBalance)+" poin", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	widths := []float64{32, 28, 62, 43, 25}
+	headers := []string{"Tanggal", "Kategori", "Keterangan", "Referensi", "Poin"}
+	pdf.SetFillColor(230, 230, 230)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	if len(statement.Entries) == 0 {
+		pdf.CellFormat(190, 7, "Tidak ada mutasi pada periode ini", "1", 1, "C", false, 0, "")
+	}
+	for _, e := range statement.Entries {
+		pdf.CellFormat(widths[0], 6, e.Date.Format("02-01-2006 15:04"), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, e.Category, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, tr(e.Description), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, tr(e.Reference), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[4], 6, strconv.Itoa(e.Points), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	summary := []struct {
+		label string
+		value int
+	}{
+		{"Top Up", statement.Totals[StatementTopUp]},
+		{"Bonus", statement.Totals[StatementBonus]},
+		{"Pemakaian", statement.Totals[StatementSpending]},
+		{"Refund", statement.Totals[StatementRefund]},
+		{"Penyesuaian", statement.Totals[StatementAdjustment]},
+		{"Transfer", statement.Totals[StatementTransfer]},
+		{"Kedaluwarsa", statement.Totals[StatementExpiry]},
+		{"Lainnya", statement.Totals[StatementOther]},
+	}
+	pdf.SetFont("Helvetica", "", 10)
+	for _, s := range summary {
+		pdf.CellFormat(50, 6, s.label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 6, strconv.Itoa(s.value), "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(50, 7, "Saldo Akhir", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 7, strconv.Itoa(statement.ClosingBalance), "T", 1, "R", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPointStatement merender laporan sesuai format ("pdf" atau "csv") beserta content type-nya
+func RenderPointStatement(statement *PointStatement, format string) ([]byte, string, error) {
+	switch format {
+	case "csv":
+		data, err := RenderPointStatementCSV(statement)
+		return data, "text/csv", err
+	case "pdf":
+		data, err := RenderPointStatementPDF(statement)
+		return data, "application/pdf", err
+	default:
+		return nil, "", fmt.Errorf("unsupported format %q", format)
+	}
+}
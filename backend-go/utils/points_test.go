@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"backend-go/models"
+)
+
+func TestDebitPointsConsumesLotsFIFO(t *testing.T) {
+	now := time.Now()
+	in := func(days int) *time.Time {
+		at := now.AddDate(0, 0, days)
+		return &at
+	}
+
+	type lot struct {
+		remaining int
+		expiresAt *time.Time
+	}
+
+	tests := []struct {
+		name          string
+		balance       int
+		lots          []lot
+		debit         int
+		wantRemaining []int
+		wantErr       error
+	}{
+		{
+			name:          "lot yang paling cepat kedaluwarsa dipakai dulu",
+			balance:       60,
+			lots:          []lot{{20, in(30)}, {10, in(10)}, {30, nil}},
+			debit:         15,
+			wantRemaining: []int{15, 0, 30},
+		},
+		{
+			name:          "lot tanpa kedaluwarsa dipakai terakhir",
+			balance:       60,
+			lots:          []lot{{30, nil}, {20, in(30)}, {10, in(10)}},
+			debit:         35,
+			wantRemaining: []int{25, 0, 0},
+		},
+		{
+			name:          "saldo lama tanpa lot dipakai sebelum lot",
+			balance:       50,
+			lots:          []lot{{20, in(10)}, {10, in(30)}},
+			debit:         25,
+			wantRemaining: []int{15, 10},
+		},
+		{
+			name:          "saldo lama menutup seluruh debit",
+			balance:       50,
+			lots:          []lot{{20, in(10)}, {10, in(30)}},
+			debit:         20,
+			wantRemaining: []int{20, 10},
+		},
+		{
+			name:          "seluruh saldo terpakai",
+			balance:       30,
+			lots:          []lot{{20, in(10)}, {10, in(30)}},
+			debit:         30,
+			wantRemaining: []int{0, 0},
+		},
+		{
+			name:          "saldo tidak cukup",
+			balance:       30,
+			lots:          []lot{{20, in(10)}, {10, in(30)}},
+			debit:         31,
+			wantRemaining: []int{20, 10},
+			wantErr:       ErrInsufficientPoints,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, &models.UserPoints{}, &models.PointLot{}, &models.PointHistory{}, &models.Setting{})
+			const userID = 1
+
+			if err := db.Create(&models.UserPoints{UserID: userID, Points: tt.balance}).Error; err != nil {
+				t.Fatal(err)
+			}
+			lotIDs := make([]uint, len(tt.lots))
+			for i, l := range tt.lots {
+				lot := models.PointLot{
+					UserID:     userID,
+					Source:     models.PointSourceTopUp,
+					Points:     l.remaining,
+					Remaining:  l.remaining,
+					CreditedAt: now.Add(time.Duration(i) * time.Minute),
+					ExpiresAt:  l.expiresAt,
+				}
+				if err := db.Create(&lot).Error; err != nil {
+					t.Fatal(err)
+				}
+				lotIDs[i] = lot.ID
+			}
+
+			userPoints, err := DebitPoints(db, userID, tt.debit, models.PointSourceOrder, "ORD-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DebitPoints() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && userPoints.Points != tt.balance-tt.debit {
+				t.Errorf("balance = %d, want %d", userPoints.Points, tt.balance-tt.debit)
+			}
+
+			for i, id := range lotIDs {
+				var lot models.PointLot
+				if err := db.First(&lot, id).Error; err != nil {
+					t.Fatal(err)
+				}
+				if lot.Remaining != tt.wantRemaining[i] {
+					t.Errorf("lot %d remaining = %d, want %d", i, lot.Remaining, tt.wantRemaining[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCreditPointsRecordsLotAndHistory(t *testing.T) {
+	db := newTestDB(t, &models.UserPoints{}, &models.PointLot{}, &models.PointHistory{}, &models.Setting{})
+	if err := db.Create(&models.Setting{Key: SettingPoinExpiryMonths, Value: "6"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	userPoints, err := CreditPoints(db, 1, 40, models.PointSourceTopUp, "TOPUP-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userPoints.Points != 40 {
+		t.Errorf("balance = %d, want 40", userPoints.Points)
+	}
+
+	var lot models.PointLot
+	if err := db.Where("user_id = ?", 1).First(&lot).Error; err != nil {
+		t.Fatal(err)
+	}
+	if lot.Remaining != 40 || lot.ExpiresAt == nil {
+		t.Errorf("lot = {remaining: %d, expiresAt: %v}, want {40, set}", lot.Remaining, lot.ExpiresAt)
+	}
+
+	var history models.PointHistory
+	if err := db.Where("user_id = ?", 1).First(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if history.Points != 40 || history.BalanceAfter != 40 || history.Reference != "TOPUP-1" {
+		t.Errorf("history = %+v", history)
+	}
+}
+
+func TestRefundOrderPointsIsIdempotent(t *testing.T) {
+	tests := []struct {
+		name        string
+		spent       int
+		wantRefunds []int
+		wantBalance int
+	}{
+		{name: "pesanan poin", spent: 40, wantRefunds: []int{40, 0, 0}, wantBalance: 100},
+		{name: "pesanan COD tanpa poin", spent: 0, wantRefunds: []int{0, 0}, wantBalance: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, &models.UserPoints{}, &models.PointLot{}, &models.PointHistory{}, &models.Setting{})
+			pesanan := &models.Pesanan{UserId: 1, OrderId: "ORD-1"}
+
+			if _, err := CreditPoints(db, pesanan.UserId, 100, models.PointSourceTopUp, "TOPUP-1"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := DebitPoints(db, pesanan.UserId, tt.spent, models.PointSourceOrder, pesanan.OrderId); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, want := range tt.wantRefunds {
+				got, err := RefundOrderPoints(db, pesanan)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("refund #%d = %d, want %d", i+1, got, want)
+				}
+			}
+
+			var userPoints models.UserPoints
+			if err := db.Where("user_id = ?", pesanan.UserId).First(&userPoints).Error; err != nil {
+				t.Fatal(err)
+			}
+			if userPoints.Points != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", userPoints.Points, tt.wantBalance)
+			}
+
+			var refunds int64
+			db.Model(&models.PointHistory{}).Where("source = ?", models.PointSourceRefund).Count(&refunds)
+			if wantRows := int64(min(tt.spent, 1)); refunds != wantRows {
+				t.Errorf("refund history rows = %d, want %d", refunds, wantRows)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+// Jam default berlakunya daftar harga jika admin tidak menentukan waktu (besok pagi)
+const DefaultPriceListHour = 6
+
+var (
+	ErrPriceListNotFound = errors.New("price list not found")
+	ErrPriceListApplied  = errors.New("price list has already been applied")
+	ErrPriceListEmpty    = errors.New("price list has no items")
+	ErrInvalidPriceList  = errors.New("price list contains invalid rows")
+)
+
+// PriceListInput adalah harga baru satu varian sebelum disimpan
+type PriceListInput struct {
+	Row           int  `json:"row,omitempty"`
+	ProductItemID uint `json:"productItemId" binding:"required"`
+	HargaRp       int  `json:"hargaRp" binding:"required"`
+}
+
+// PriceChangePreview adalah harga lama dan baru satu varian pada daftar harga
+type PriceChangePreview struct {
+	ProductItemID uint   `json:"productItemId"`
+	ProductID     uint   `json:"productId"`
+	NameProduk    string `json:"nameProduk"`
+	Jumlah        int    `json:"jumlah"`
+	Satuan        string `json:"satuan"`
+	OldHargaRp    int    `json:"oldHargaRp"`
+	NewHargaRp    int    `json:"newHargaRp"`
+	OldHargaPoin  int    `json:"oldHargaPoin"`
+	NewHargaPoin  int    `json:"newHargaPoin"`
+}
+
+// DefaultPriceListEffectiveAt mengembalikan besok pukul DefaultPriceListHour waktu lokal
+func DefaultPriceListEffectiveAt(now time.Time) time.Time {
+	tomorrow := now.AddDate(0, 0, 1)
+	return time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), DefaultPriceListHour, 0, 0, 0, now.Location())
+}
+
+// HargaPoinFor menghitung harga poin dari harga Rupiah dengan nilai poin yang berlaku
+func HargaPoinFor(hargaRp, rate int) int {
+	if rate <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(hargaRp) / float64(rate)))
+}
+
+// ReadPriceListSheet membaca harga baru dari spreadsheet dengan kolom variant_id dan harga_rp.
+// File export katalog bisa langsung diedit lalu diunggah sebagai daftar harga.
+func ReadPriceListSheet(r io.Reader, filename string) ([]PriceListInput, []ImportError, error) {
+	records, columns, err := readSheet(r, filename, []string{"variant_id", "harga_rp"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var inputs []PriceListInput
+	var rowErrors []ImportError
+	for i, record := range records {
+		row := i + 2
+		variantID := sheetValue(record, columns, "variant_id")
+		hargaRp := sheetValue(record, columns, "harga_rp")
+		if variantID == "" && hargaRp == "" {
+			continue
+		}
+
+		id, err := strconv.ParseUint(variantID, 10, 64)
+		if err != nil || id == 0 {
+			rowErrors = append(rowErrors, ImportError{Row: row, Field: "variant_id", Message: "variant_id must be a product item ID"})
+			continue
+		}
+		price, err := parseSheetNumber(hargaRp)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportError{Row: row, Field: "harga_rp", Message: "harga_rp must be a positive number"})
+			continue
+		}
+		inputs = append(inputs, PriceListInput{Row: row, ProductItemID: uint(id), HargaRp: int(math.Round(price))})
+	}
+	return inputs, rowErrors, nil
+}
+
+// ValidatePriceListInputs memeriksa varian ada, harga positif, dan tidak ada varian ganda
+func ValidatePriceListInputs(db *gorm.DB, inputs []PriceListInput) ([]ImportError, error) {
+	rowErrors := []ImportError{}
+	if len(inputs) == 0 {
+		return rowErrors, ErrPriceListEmpty
+	}
+
+	ids := make([]uint, len(inputs))
+	for i, input := range inputs {
+		ids[i] = input.ProductItemID
+	}
+	var existing []uint
+	if err := db.Model(&models.ProductItem{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+
+	seen := make(map[uint]int)
+	for i, input := range inputs {
+		row := input.Row
+		if row == 0 {
+			row = i + 1
+		}
+		switch {
+		case !found[input.ProductItemID]:
+			rowErrors = append(rowErrors, ImportError{Row: row, Field: "variant_id", Message: "product item not found"})
+		case input.HargaRp <= 0:
+			rowErrors = append(rowErrors, ImportError{Row: row, Field: "harga_rp", Message: "harga_rp must be a positive number"})
+		default:
+			if previous, ok := seen[input.ProductItemID]; ok {
+				rowErrors = append(rowErrors, ImportError{Row: row, Field: "variant_id", Message: fmt.Sprintf("product item already listed at row %d", previous)})
+				continue
+			}
+			seen[input.ProductItemID] = row
+		}
+	}
+	return rowErrors, nil
+}
+
+// CreatePriceList menyimpan daftar harga beserta itemnya. Input harus sudah divalidasi.
+func CreatePriceList(tx *gorm.DB, name string, effectiveAt time.Time, inputs []PriceListInput, createdBy *uint) (*models.PriceList, error) {
+	priceList := models.PriceList{
+		Name:        name,
+		EffectiveAt: effectiveAt,
+		CreatedBy:   createdBy,
+	}
+	for _, input := range inputs {
+		priceList.Items = append(priceList.Items, models.PriceListItem{
+			ProductItemID: input.ProductItemID,
+			HargaRp:       input.HargaRp,
+		})
+	}
+	if err := tx.Create(&priceList).Error; err != nil {
+		return nil, err
+	}
+	return &priceList, nil
+}
+
+// PreviewPriceList menghitung perubahan harga dengan nilai poin yang berlaku saat ini.
+// Harga poin final dihitung ulang saat daftar harga diberlakukan.
+func PreviewPriceList(db *gorm.DB, priceListID uint) ([]PriceChangePreview, error) {
+	rate, err := GetCurrentPointRate(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.PriceListItem
+	if err := db.Preload("ProductItem.Product").
+		Where("price_list_id = ?", priceListID).
+		Order("id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	previews := make([]PriceChangePreview, 0, len(items))
+	for _, item := range items {
+		preview := PriceChangePreview{
+			ProductItemID: item.ProductItemID,
+			NewHargaRp:    item.HargaRp,
+			NewHargaPoin:  HargaPoinFor(item.HargaRp, rate.Rate),
+		}
+		if item.ProductItem != nil {
+			preview.ProductID = item.ProductItem.ProductID
+			preview.Jumlah = item.ProductItem.Jumlah
+			preview.Satuan = item.ProductItem.Satuan
+			preview.OldHargaRp = item.ProductItem.HargaRp
+			preview.OldHargaPoin = item.ProductItem.HargaPoin
+			if item.ProductItem.Product != nil {
+				preview.NameProduk = item.ProductItem.Product.NameProduk
+			}
+		}
+		previews = append(previews, preview)
+	}
+	return previews, nil
+}
+
+// ApplyPriceList memberlakukan daftar harga: HargaRp varian diganti dan HargaPoin dihitung
+// dengan nilai poin yang berlaku, lalu perubahan dicatat di riwayat harga. Harus dipanggil
+// di dalam transaksi. Mengembalikan jumlah varian yang harganya berubah.
+func ApplyPriceList(tx *gorm.DB, priceListID uint, appliedBy *uint) (int, error) {
+	var priceList models.PriceList
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&priceList, priceListID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrPriceListNotFound
+		}
+		return 0, err
+	}
+	if priceList.AppliedAt != nil {
+		return 0, ErrPriceListApplied
+	}
+
+	rate, err := GetCurrentPointRate(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var listItems []models.PriceListItem
+	if err := tx.Where("price_list_id = ?", priceList.ID).Order("id ASC").Find(&listItems).Error; err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, listItem := range listItems {
+		var item models.ProductItem
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, listItem.ProductItemID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue // Varian sudah dihapus sejak daftar harga dibuat
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		hargaPoin := HargaPoinFor(listItem.HargaRp, rate.Rate)
+		if item.HargaRp == listItem.HargaRp && item.HargaPoin == hargaPoin {
+			continue
+		}
+		if err := tx.Model(&item).Updates(map[string]interface{}{
+			"harga_rp":   listItem.HargaRp,
+			"harga_poin": hargaPoin,
+		}).Error; err != nil {
+			return 0, err
+		}
+		if err := RecordPriceChange(tx, item.ID, item.HargaRp, item.HargaPoin, listItem.HargaRp, hargaPoin,
+			models.PriceSourcePriceList, &priceList.ID, appliedBy); err != nil {
+			return 0, err
+		}
+		changed++
+	}
+
+	if err := tx.Model(&priceList).Update("applied_at", time.Now()).Error; err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+// RecordPriceChange mencatat riwayat harga satu varian; tidak melakukan apa pun jika harga sama
+func RecordPriceChange(tx *gorm.DB, productItemID uint, oldRp, oldPoin, newRp, newPoin int, source models.PriceChangeSource, referenceID, changedBy *uint) error {
+	if oldRp == newRp && oldPoin == newPoin {
+		return nil
+	}
+	return tx.Create(&models.PriceHistory{
+		ProductItemID: productItemID,
+		OldHargaRp:    oldRp,
+		OldHargaPoin:  oldPoin,
+		HargaRp:       newRp,
+		HargaPoin:     newPoin,
+		Source:        source,
+		ReferenceID:   referenceID,
+		ChangedBy:     changedBy,
+	}).Error
+}
@@ -0,0 +1,326 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+const (
+	UploadDir          = "./uploads"
+	MaxProductImages   = 10
+	orphanUploadMinAge = 24 * time.Hour // File baru mungkin belum tersimpan di database
+	productImageOrder  = "position ASC, id ASC"
+)
+
+// managedUploadName cocok dengan nama file dari middleware upload (GenerateUniqueFilename).
+// Cleanup hanya menyentuh file seperti ini, file lain di folder uploads dibiarkan.
+var managedUploadName = regexp.MustCompile(`^\d+(_[0-9a-f]{16})?(\.[A-Za-z0-9]+)?$`)
+
+var (
+	ErrProductImageNotFound = errors.New("product image not found")
+	ErrProductImageLimit    = errors.New("product image limit reached")
+	ErrInvalidImageOrder    = errors.New("image order must contain every image of the product exactly once")
+	ErrVariantNotFound      = errors.New("product variant not found")
+)
+
+// OrderProductImages dipakai untuk preload galeri sesuai urutan tampilan
+func OrderProductImages(db *gorm.DB) *gorm.DB {
+	return db.Order(productImageOrder)
+}
+
+// ValidateProductItem memastikan varian milik produk yang sama
+func ValidateProductItem(db *gorm.DB, productID uint, itemID *uint) error {
+	if itemID == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.ProductItem{}).Where("id = ? AND product_id = ?", *itemID, productID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrVariantNotFound
+	}
+	return nil
+}
+
+// AddProductImages menambahkan gambar di akhir galeri. Jika produk belum punya gambar utama,
+// gambar pertama yang ditambahkan menjadi gambar utama.
+func AddProductImages(tx *gorm.DB, productID uint, filenames []string, altText string, itemID *uint) ([]models.ProductImage, error) {
+	if err := ValidateProductItem(tx, productID, itemID); err != nil {
+		return nil, err
+	}
+
+	var existing []models.ProductImage
+	if err := tx.Where("product_id = ?", productID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	if len(existing)+len(filenames) > MaxProductImages {
+		return nil, ErrProductImageLimit
+	}
+
+	nextPosition := 0
+	hasPrimary := false
+	for _, image := range existing {
+		if image.Position >= nextPosition {
+			nextPosition = image.Position + 1
+		}
+		hasPrimary = hasPrimary || image.IsPrimary
+	}
+
+	images := make([]models.ProductImage, 0, len(filenames))
+	for i, filename := range filenames {
+		images = append(images, models.ProductImage{
+			ProductID:     productID,
+			ProductItemID: itemID,
+			Filename:      filename,
+			AltText:       altText,
+			Position:      nextPosition + i,
+			IsPrimary:     !hasPrimary && i == 0,
+		})
+	}
+	if len(images) == 0 {
+		return images, nil
+	}
+	if err := tx.Create(&images).Error; err != nil {
+		return nil, err
+	}
+
+	return images, syncProductPrimaryImage(tx, productID)
+}
+
+// SetPrimaryProductImage menjadikan satu gambar sebagai gambar utama produk
+func SetPrimaryProductImage(tx *gorm.DB, productID, imageID uint) error {
+	var image models.ProductImage
+	if err := tx.Where("id = ? AND product_id = ?", imageID, productID).First(&image).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductImageNotFound
+		}
+		return err
+	}
+
+	if err := tx.Model(&models.ProductImage{}).
+		Where("product_id = ? AND id <> ?", productID, imageID).
+		Update("is_primary", false).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&image).Update("is_primary", true).Error; err != nil {
+		return err
+	}
+	return syncProductPrimaryImage(tx, productID)
+}
+
+// ReorderProductImages menyimpan urutan galeri sesuai urutan ID dari admin (drag & drop)
+func ReorderProductImages(tx *gorm.DB, productID uint, imageIDs []uint) error {
+	var ids []uint
+	if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", productID).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	owned := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		owned[id] = true
+	}
+	if len(imageIDs) != len(ids) {
+		return ErrInvalidImageOrder
+	}
+	for _, id := range imageIDs {
+		if !owned[id] {
+			return ErrInvalidImageOrder
+		}
+		delete(owned, id)
+	}
+
+	for position, id := range imageIDs {
+		if err := tx.Model(&models.ProductImage{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteProductImage menghapus gambar dari galeri. Jika gambar utama dihapus,
+// gambar berikutnya sesuai urutan menjadi gambar utama. File dihapus oleh pemanggil setelah commit.
+func DeleteProductImage(tx *gorm.DB, productID, imageID uint) (*models.ProductImage, error) {
+	var image models.ProductImage
+	if err := tx.Where("id = ? AND product_id = ?", imageID, productID).First(&image).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductImageNotFound
+		}
+		return nil, err
+	}
+
+	if err := tx.Delete(&image).Error; err != nil {
+		return nil, err
+	}
+
+	if image.IsPrimary {
+		var next models.ProductImage
+		err := tx.Where("product_id = ?", productID).Order(productImageOrder).First(&next).Error
+		if err == nil {
+			if err := tx.Model(&next).Update("is_primary", true).Error; err != nil {
+				return nil, err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return &image, syncProductPrimaryImage(tx, productID)
+}
+
+// syncProductPrimaryImage menyalin nama file gambar utama ke Product.Image untuk klien lama
+func syncProductPrimaryImage(tx *gorm.DB, productID uint) error {
+	var filename string
+	if err := tx.Model(&models.ProductImage{}).
+		Where("product_id = ? AND is_primary = ?", productID, true).
+		Limit(1).
+		Pluck("filename", &filename).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).Update("image", filename).Error
+}
+
+// RemoveUploads menghapus file upload yang sudah tidak dipakai di database
+func RemoveUploads(db *gorm.DB, filenames ...string) {
+	for _, filename := range filenames {
+		if filename == "" || isUploadReferenced(db, filename) {
+			continue
+		}
+		os.Remove(filepath.Join(UploadDir, filepath.Base(filename)))
+		RemoveImageRenditions(filename)
+	}
+}
+
+func isUploadReferenced(db *gorm.DB, filename string) bool {
+	var count int64
+	if db.Model(&models.ProductImage{}).Where("filename = ?", filename).Count(&count); count > 0 {
+		return true
+	}
+	if db.Model(&models.Product{}).Where("image = ?", filename).Count(&count); count > 0 {
+		return true
+	}
+	if db.Model(&models.Category{}).Where("icon = ?", filename).Count(&count); count > 0 {
+		return true
+	}
+	if db.Model(&models.ReviewPhoto{}).Where("filename = ?", filename).Count(&count); count > 0 {
+		return true
+	}
+	if db.Model(&models.DetailsUser{}).Where("photo_profile = ? OR photo_profile LIKE ?", filename, "%/"+filename).Count(&count); count > 0 {
+		return true
+	}
+	return false
+}
+
+// ReferencedUploads mengembalikan semua nama file upload yang dipakai galeri produk, produk, kategori,
+// foto ulasan, atau foto profil user
+func ReferencedUploads(db *gorm.DB) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	queries := []*gorm.DB{
+		db.Model(&models.ProductImage{}).Select("filename"),
+		db.Model(&models.Product{}).Select("image").Where("image <> ''"),
+		db.Model(&models.Category{}).Select("icon").Where("icon <> ''"),
+		db.Model(&models.ReviewPhoto{}).Select("filename"),
+		db.Model(&models.DetailsUser{}).Select("photo_profile").Where("photo_profile <> ''"),
+	}
+	for _, query := range queries {
+		var names []string
+		if err := query.Scan(&names).Error; err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			// Foto profil bisa tersimpan sebagai path, cukup nama filenya yang dicocokkan
+			referenced[filepath.Base(name)] = true
+		}
+	}
+	return referenced, nil
+}
+
+// CleanupOrphanedUploads menghapus file hasil middleware upload di folder uploads yang tidak lagi
+// dirujuk produk, galeri, kategori, foto ulasan, atau foto profil. File yang lebih baru dari 24 jam
+// dan file yang tidak dibuat middleware upload dilewati.
+func CleanupOrphanedUploads(db *gorm.DB) (int, error) {
+	entries, err := os.ReadDir(UploadDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	referenced, err := ReferencedUploads(db)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	cutoff := time.Now().Add(-orphanUploadMinAge)
+	sources := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		base := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if referenced[entry.Name()] || !managedUploadName.MatchString(entry.Name()) {
+			sources[base] = true
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			sources[base] = true
+			continue
+		}
+		if err := os.Remove(filepath.Join(UploadDir, entry.Name())); err == nil {
+			removed++
+		}
+	}
+
+	// Rendition yang file aslinya sudah tidak ada ikut dihapus
+	renditions, err := os.ReadDir(filepath.Join(UploadDir, RenditionDir))
+	if err != nil && !os.IsNotExist(err) {
+		return removed, err
+	}
+	for _, entry := range renditions {
+		base := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		for _, size := range ImageSizes {
+			base = strings.TrimSuffix(base, "_"+string(size))
+		}
+		if entry.IsDir() || sources[base] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(UploadDir, RenditionDir, entry.Name())); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// MigrateProductImages membuat entri galeri untuk produk lama yang hanya punya Product.Image
+func MigrateProductImages(db *gorm.DB) (int64, error) {
+	result := db.Exec(`INSERT INTO product_images (product_id, filename, position, is_primary, created_at, updated_at)
+		SELECT p.id, p.image, 0, true, NOW(), NOW() FROM products p
+		WHERE p.image <> '' AND NOT EXISTS (SELECT 1 FROM product_images pi WHERE pi.product_id = p.id)`)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteProductGallery menghapus seluruh galeri produk dan mengembalikan nama file yang perlu dibersihkan
+func DeleteProductGallery(tx *gorm.DB, product *models.Product) ([]string, error) {
+	var filenames []string
+	if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", product.ID).Pluck("filename", &filenames).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductImage{}).Error; err != nil {
+		return nil, err
+	}
+	if product.Image != "" {
+		filenames = append(filenames, product.Image)
+	}
+	return filenames, nil
+}
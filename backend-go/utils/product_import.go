@@ -0,0 +1,775 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+const (
+	MaxProductVariants = 10
+	MaxImportRows      = 2000
+
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+
+	productSheetName = "Produk"
+)
+
+// ValidProductUnits adalah satuan varian yang diizinkan
+var ValidProductUnits = map[string]bool{
+	"gram":     true,
+	"kilogram": true,
+	"ikat":     true,
+	"biji":     true,
+	"buah":     true,
+	"pcs":      true,
+}
+
+// Kolom spreadsheet katalog, satu baris per varian. Export memakai urutan yang sama
+// sehingga file hasil export bisa diedit lalu diimport kembali.
+var productSheetColumns = []string{
+	"product_id", "nama_produk", "kategori", "deskripsi",
+	"variant_id", "jumlah", "satuan", "harga_rp", "harga_poin", "stok", "reorder_threshold",
+}
+
+var requiredSheetColumns = []string{"nama_produk", "jumlah", "satuan", "harga_rp"}
+
+var (
+	ErrUnsupportedSheet = errors.New("file must be .csv or .xlsx")
+	ErrEmptySheet       = errors.New("spreadsheet has no data rows")
+	ErrTooManySheetRows = fmt.Errorf("spreadsheet exceeds %d rows", MaxImportRows)
+	ErrInvalidImport    = errors.New("import contains invalid rows")
+)
+
+// ProductSheetRow adalah satu baris data mentah dari spreadsheet
+type ProductSheetRow struct {
+	Row              int
+	ProductID        string
+	NamaProduk       string
+	Kategori         string
+	Deskripsi        string
+	VariantID        string
+	Jumlah           string
+	Satuan           string
+	HargaRp          string
+	Stok             string
+	ReorderThreshold string
+}
+
+// hasVariant bernilai false untuk baris yang hanya berisi data produk
+func (row ProductSheetRow) hasVariant() bool {
+	return row.VariantID != "" || row.Jumlah != "" || row.Satuan != "" || row.HargaRp != ""
+}
+
+// ImportError adalah kesalahan validasi pada satu baris spreadsheet
+type ImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// FieldChange adalah nilai lama dan baru satu kolom pada diff import
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// VariantImportPlan adalah rencana perubahan satu varian
+type VariantImportPlan struct {
+	Row              int                    `json:"row"`
+	Action           string                 `json:"action"`
+	VariantID        uint                   `json:"variantId,omitempty"`
+	Jumlah           int                    `json:"jumlah"`
+	Satuan           string                 `json:"satuan"`
+	HargaRp          int                    `json:"hargaRp"`
+	HargaPoin        int                    `json:"hargaPoin"`
+	Stok             *int                   `json:"stok,omitempty"`             // Kosong = stok tidak diubah
+	ReorderThreshold *int                   `json:"reorderThreshold,omitempty"` // Kosong = batas tidak diubah
+	Changes          map[string]FieldChange `json:"changes,omitempty"`
+}
+
+// ProductImportPlan adalah rencana perubahan satu produk beserta variannya
+type ProductImportPlan struct {
+	Row        int                    `json:"row"`
+	Action     string                 `json:"action"`
+	ProductID  uint                   `json:"productId,omitempty"`
+	NameProduk string                 `json:"nameProduk"`
+	Kategori   string                 `json:"kategori"`
+	CategoryID uint                   `json:"categoryId"`
+	Deskripsi  string                 `json:"deskripsi"`
+	Changes    map[string]FieldChange `json:"changes,omitempty"`
+	Variants   []*VariantImportPlan   `json:"variants"`
+}
+
+type ProductImportSummary struct {
+	Rows              int `json:"rows"`
+	ProductsCreated   int `json:"productsCreated"`
+	ProductsUpdated   int `json:"productsUpdated"`
+	ProductsUnchanged int `json:"productsUnchanged"`
+	VariantsCreated   int `json:"variantsCreated"`
+	VariantsUpdated   int `json:"variantsUpdated"`
+	VariantsUnchanged int `json:"variantsUnchanged"`
+}
+
+// ProductImport adalah hasil dry-run: diff per produk dan laporan kesalahan per baris
+type ProductImport struct {
+	Products []*ProductImportPlan `json:"products"`
+	Errors   []ImportError        `json:"errors"`
+	Summary  ProductImportSummary `json:"summary"`
+}
+
+// Valid bernilai true jika tidak ada baris yang gagal validasi
+func (imp *ProductImport) Valid() bool {
+	return len(imp.Errors) == 0
+}
+
+func (imp *ProductImport) addError(row int, field, message string) {
+	imp.Errors = append(imp.Errors, ImportError{Row: row, Field: field, Message: message})
+}
+
+// readSheet membaca .csv atau sheet pertama .xlsx. Mengembalikan baris data beserta
+// indeks kolom dari header; nama kolom tidak peka huruf besar/kecil dan spasi menjadi "_".
+func readSheet(r io.Reader, filename string, required []string) ([][]string, map[string]int, error) {
+	var records [][]string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		var err error
+		if records, err = reader.ReadAll(); err != nil {
+			return nil, nil, fmt.Errorf("invalid csv: %w", err)
+		}
+	case ".xlsx":
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid xlsx: %w", err)
+		}
+		defer file.Close()
+		if records, err = file.GetRows(file.GetSheetName(0)); err != nil {
+			return nil, nil, fmt.Errorf("invalid xlsx: %w", err)
+		}
+	default:
+		return nil, nil, ErrUnsupportedSheet
+	}
+
+	if len(records) < 2 {
+		return nil, nil, ErrEmptySheet
+	}
+	if len(records)-1 > MaxImportRows {
+		return nil, nil, ErrTooManySheetRows
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))
+		columns[strings.ReplaceAll(name, " ", "_")] = i
+	}
+	for _, column := range required {
+		if _, ok := columns[column]; !ok {
+			return nil, nil, fmt.Errorf("missing column %q", column)
+		}
+	}
+	return records[1:], columns, nil
+}
+
+// sheetValue mengambil nilai kolom pada satu baris, kosong jika kolom tidak ada
+func sheetValue(record []string, columns map[string]int, column string) string {
+	index, ok := columns[column]
+	if !ok || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+// ReadProductSheet membaca spreadsheet katalog (.csv atau .xlsx, sheet pertama).
+// Baris pertama adalah header.
+func ReadProductSheet(r io.Reader, filename string) ([]ProductSheetRow, error) {
+	records, columns, err := readSheet(r, filename, requiredSheetColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []ProductSheetRow
+	for i, record := range records {
+		value := func(column string) string {
+			return sheetValue(record, columns, column)
+		}
+
+		row := ProductSheetRow{
+			Row:              i + 2, // Nomor baris seperti di spreadsheet, header = baris 1
+			ProductID:        value("product_id"),
+			NamaProduk:       value("nama_produk"),
+			Kategori:         value("kategori"),
+			Deskripsi:        value("deskripsi"),
+			VariantID:        value("variant_id"),
+			Jumlah:           value("jumlah"),
+			Satuan:           value("satuan"),
+			HargaRp:          value("harga_rp"),
+			Stok:             value("stok"),
+			ReorderThreshold: value("reorder_threshold"),
+		}
+		if row.ProductID == "" && row.NamaProduk == "" && !row.hasVariant() {
+			continue // Baris kosong
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptySheet
+	}
+	return rows, nil
+}
+
+// productImportPlanner menyimpan katalog yang ada agar validasi tidak query per baris
+type productImportPlanner struct {
+	db         *gorm.DB
+	rate       int
+	imp        *ProductImport
+	byID       map[uint]*models.Product
+	byName     map[string][]*models.Product
+	categories map[string]*models.Category
+	planned    map[uint]int // Produk lama yang sudah direncanakan, beserta barisnya
+}
+
+// PlanProductImport memvalidasi baris spreadsheet terhadap katalog dan menyusun diff
+// tanpa menyimpan apa pun. Produk dicocokkan lewat product_id atau nama, varian lewat
+// variant_id atau pasangan jumlah+satuan.
+func PlanProductImport(db *gorm.DB, rows []ProductSheetRow) (*ProductImport, error) {
+	rate, err := GetCurrentPointRate(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []models.Product
+	if err := db.Preload("ProductItems", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	planner := &productImportPlanner{
+		db:         db,
+		rate:       rate.Rate,
+		imp:        &ProductImport{Products: []*ProductImportPlan{}, Errors: []ImportError{}},
+		byID:       make(map[uint]*models.Product, len(products)),
+		byName:     make(map[string][]*models.Product),
+		categories: make(map[string]*models.Category),
+		planned:    make(map[uint]int),
+	}
+	for i := range products {
+		product := &products[i]
+		planner.byID[product.ID] = product
+		key := strings.ToLower(strings.TrimSpace(product.NameProduk))
+		planner.byName[key] = append(planner.byName[key], product)
+	}
+
+	// Kelompokkan baris per produk dengan urutan kemunculan di spreadsheet
+	var keys []string
+	groups := make(map[string][]ProductSheetRow)
+	for _, row := range rows {
+		key := "name:" + strings.ToLower(row.NamaProduk)
+		if row.ProductID != "" {
+			key = "id:" + row.ProductID
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	for _, key := range keys {
+		if plan := planner.planProduct(groups[key]); plan != nil {
+			planner.imp.Products = append(planner.imp.Products, plan)
+		}
+	}
+
+	summary := &planner.imp.Summary
+	summary.Rows = len(rows)
+	for _, plan := range planner.imp.Products {
+		switch plan.Action {
+		case ImportActionCreate:
+			summary.ProductsCreated++
+		case ImportActionUpdate:
+			summary.ProductsUpdated++
+		default:
+			summary.ProductsUnchanged++
+		}
+		for _, variant := range plan.Variants {
+			switch variant.Action {
+			case ImportActionCreate:
+				summary.VariantsCreated++
+			case ImportActionUpdate:
+				summary.VariantsUpdated++
+			default:
+				summary.VariantsUnchanged++
+			}
+		}
+	}
+
+	return planner.imp, nil
+}
+
+func (p *productImportPlanner) planProduct(rows []ProductSheetRow) *ProductImportPlan {
+	imp := p.imp
+	first := rows[0]
+	errorCount := len(imp.Errors)
+
+	// Data produk diambil dari baris pertama; baris berikutnya boleh kosong atau harus sama
+	for _, row := range rows[1:] {
+		for field, values := range map[string][2]string{
+			"nama_produk": {first.NamaProduk, row.NamaProduk},
+			"kategori":    {first.Kategori, row.Kategori},
+			"deskripsi":   {first.Deskripsi, row.Deskripsi},
+		} {
+			if values[1] != "" && values[1] != values[0] {
+				imp.addError(row.Row, field, fmt.Sprintf("conflicts with row %d for the same product", first.Row))
+			}
+		}
+	}
+
+	// Cari produk yang sudah ada
+	var existing *models.Product
+	if first.ProductID != "" {
+		id, err := strconv.ParseUint(first.ProductID, 10, 64)
+		if err != nil || p.byID[uint(id)] == nil {
+			imp.addError(first.Row, "product_id", "product not found")
+			return nil
+		}
+		existing = p.byID[uint(id)]
+	} else if matches := p.byName[strings.ToLower(first.NamaProduk)]; len(matches) == 1 {
+		existing = matches[0]
+	} else if len(matches) > 1 {
+		imp.addError(first.Row, "product_id", "product name matches several products, fill in product_id")
+		return nil
+	}
+
+	if existing != nil {
+		if row, ok := p.planned[existing.ID]; ok {
+			imp.addError(first.Row, "product_id", fmt.Sprintf("product already listed at row %d", row))
+			return nil
+		}
+		p.planned[existing.ID] = first.Row
+	}
+
+	plan := &ProductImportPlan{
+		Row:        first.Row,
+		Action:     ImportActionCreate,
+		NameProduk: first.NamaProduk,
+		Deskripsi:  first.Deskripsi,
+		Changes:    make(map[string]FieldChange),
+		Variants:   []*VariantImportPlan{},
+	}
+	if existing != nil {
+		plan.Action = ImportActionUnchanged
+		plan.ProductID = existing.ID
+		if plan.NameProduk == "" {
+			plan.NameProduk = existing.NameProduk
+		}
+		if plan.Deskripsi == "" {
+			plan.Deskripsi = existing.Deskripsi
+		}
+	}
+
+	if len(plan.NameProduk) < 3 || len(plan.NameProduk) > 100 {
+		imp.addError(first.Row, "nama_produk", "name must be between 3 and 100 characters")
+	}
+	if plan.Deskripsi == "" {
+		imp.addError(first.Row, "deskripsi", "description is required for new products")
+	}
+
+	// Kategori wajib untuk produk baru; untuk produk lama kosong berarti tidak diubah
+	if first.Kategori != "" {
+		if category := p.findCategory(first.Kategori); category != nil {
+			plan.Kategori = category.Name
+			plan.CategoryID = category.ID
+		} else {
+			imp.addError(first.Row, "kategori", fmt.Sprintf("category %q not found", first.Kategori))
+		}
+	} else if existing != nil && existing.CategoryID != nil {
+		plan.Kategori = existing.Kategori
+		plan.CategoryID = *existing.CategoryID
+	} else {
+		imp.addError(first.Row, "kategori", "category is required")
+	}
+
+	if existing != nil {
+		if plan.NameProduk != existing.NameProduk {
+			plan.Changes["nameProduk"] = FieldChange{existing.NameProduk, plan.NameProduk}
+		}
+		if plan.Deskripsi != existing.Deskripsi {
+			plan.Changes["deskripsi"] = FieldChange{existing.Deskripsi, plan.Deskripsi}
+		}
+		if existing.CategoryID == nil || plan.CategoryID != *existing.CategoryID {
+			plan.Changes["kategori"] = FieldChange{existing.Kategori, plan.Kategori}
+		}
+	}
+
+	p.planVariants(plan, existing, rows)
+
+	if len(imp.Errors) > errorCount {
+		return plan
+	}
+	if plan.Action == ImportActionUnchanged && len(plan.Changes) > 0 {
+		plan.Action = ImportActionUpdate
+	}
+	return plan
+}
+
+func (p *productImportPlanner) planVariants(plan *ProductImportPlan, existing *models.Product, rows []ProductSheetRow) {
+	imp := p.imp
+
+	existingByID := make(map[uint]*models.ProductItem)
+	existingByKey := make(map[string]*models.ProductItem)
+	if existing != nil {
+		for i := range existing.ProductItems {
+			item := &existing.ProductItems[i]
+			existingByID[item.ID] = item
+			existingByKey[variantKey(item.Jumlah, item.Satuan)] = item
+		}
+	}
+
+	seenVariants := make(map[string]int)
+	variantCount := len(existingByID)
+	for _, row := range rows {
+		if !row.hasVariant() {
+			continue
+		}
+
+		variant := &VariantImportPlan{Row: row.Row, Action: ImportActionCreate, Changes: make(map[string]FieldChange)}
+		valid := true
+
+		jumlah, err := strconv.Atoi(row.Jumlah)
+		if err != nil || jumlah <= 0 {
+			imp.addError(row.Row, "jumlah", "jumlah must be a positive whole number")
+			valid = false
+		}
+		variant.Jumlah = jumlah
+
+		variant.Satuan = strings.ToLower(row.Satuan)
+		if !ValidProductUnits[variant.Satuan] {
+			imp.addError(row.Row, "satuan", fmt.Sprintf("invalid unit '%s'. Valid units: gram, kilogram, ikat, biji, buah, pcs", row.Satuan))
+			valid = false
+		}
+
+		hargaRp, err := parseSheetNumber(row.HargaRp)
+		if err != nil || hargaRp <= 0 {
+			imp.addError(row.Row, "harga_rp", "harga_rp must be a positive number")
+			valid = false
+		}
+		variant.HargaRp = int(math.Round(hargaRp))
+		variant.HargaPoin = int(math.Round(hargaRp / float64(p.rate)))
+
+		if row.Stok != "" {
+			stok, err := strconv.Atoi(row.Stok)
+			if err != nil || stok < 0 {
+				imp.addError(row.Row, "stok", "stok must be zero or a positive whole number")
+				valid = false
+			}
+			variant.Stok = &stok
+		}
+		if row.ReorderThreshold != "" {
+			threshold, err := strconv.Atoi(row.ReorderThreshold)
+			if err != nil || threshold < 0 {
+				imp.addError(row.Row, "reorder_threshold", "reorder_threshold must be zero or a positive whole number")
+				valid = false
+			}
+			variant.ReorderThreshold = &threshold
+		}
+		if !valid {
+			continue
+		}
+
+		key := variantKey(variant.Jumlah, variant.Satuan)
+		if previous, ok := seenVariants[key]; ok {
+			imp.addError(row.Row, "satuan", fmt.Sprintf("Duplicate variant: %d %s (row %d)", variant.Jumlah, variant.Satuan, previous))
+			continue
+		}
+		seenVariants[key] = row.Row
+
+		// Cocokkan varian lewat variant_id, atau lewat jumlah+satuan yang sama
+		var item *models.ProductItem
+		if row.VariantID != "" {
+			id, err := strconv.ParseUint(row.VariantID, 10, 64)
+			if err != nil || existingByID[uint(id)] == nil {
+				imp.addError(row.Row, "variant_id", "variant not found for this product")
+				continue
+			}
+			item = existingByID[uint(id)]
+			if other := existingByKey[key]; other != nil && other.ID != item.ID {
+				imp.addError(row.Row, "satuan", fmt.Sprintf("Duplicate variant: %d %s", variant.Jumlah, variant.Satuan))
+				continue
+			}
+		} else {
+			item = existingByKey[key]
+		}
+
+		if item == nil {
+			variantCount++
+			plan.Variants = append(plan.Variants, variant)
+			continue
+		}
+
+		variant.VariantID = item.ID
+		variant.Action = ImportActionUnchanged
+		if variant.Jumlah != item.Jumlah {
+			variant.Changes["jumlah"] = FieldChange{item.Jumlah, variant.Jumlah}
+		}
+		if variant.Satuan != strings.ToLower(item.Satuan) {
+			variant.Changes["satuan"] = FieldChange{item.Satuan, variant.Satuan}
+		}
+		if variant.HargaRp != item.HargaRp {
+			variant.Changes["hargaRp"] = FieldChange{item.HargaRp, variant.HargaRp}
+			variant.Changes["hargaPoin"] = FieldChange{item.HargaPoin, variant.HargaPoin}
+		} else {
+			variant.HargaPoin = item.HargaPoin
+		}
+		if variant.Stok != nil && *variant.Stok != item.Stok {
+			variant.Changes["stok"] = FieldChange{item.Stok, *variant.Stok}
+		}
+		if variant.ReorderThreshold != nil && *variant.ReorderThreshold != item.ReorderThreshold {
+			variant.Changes["reorderThreshold"] = FieldChange{item.ReorderThreshold, *variant.ReorderThreshold}
+		}
+		if len(variant.Changes) > 0 {
+			variant.Action = ImportActionUpdate
+			if plan.Action == ImportActionUnchanged {
+				plan.Action = ImportActionUpdate
+			}
+		}
+		plan.Variants = append(plan.Variants, variant)
+	}
+
+	if existing == nil && variantCount == 0 {
+		imp.addError(plan.Row, "jumlah", "new products need at least one variant")
+	}
+	if variantCount > MaxProductVariants {
+		imp.addError(plan.Row, "variant_id", fmt.Sprintf("Maximum %d variants per product allowed", MaxProductVariants))
+	}
+}
+
+func (p *productImportPlanner) findCategory(value string) *models.Category {
+	key := strings.ToLower(value)
+	if category, ok := p.categories[key]; ok {
+		return category
+	}
+
+	var category *models.Category
+	if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+		var found models.Category
+		if p.db.First(&found, id).Error == nil {
+			category = &found
+		}
+	} else if found, err := FindCategory(p.db, value); err == nil {
+		category = found
+	}
+	p.categories[key] = category
+	return category
+}
+
+func variantKey(jumlah int, satuan string) string {
+	return strconv.Itoa(jumlah) + strings.ToLower(satuan)
+}
+
+// parseSheetNumber menerima angka polos atau dengan awalan "Rp"
+func parseSheetNumber(value string) (float64, error) {
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "Rp"))
+	return strconv.ParseFloat(strings.ReplaceAll(value, " ", ""), 64)
+}
+
+// ApplyProductImport menyimpan hasil PlanProductImport. Harus dipanggil di dalam transaksi
+// dengan plan yang dibuat dari transaksi yang sama. Perubahan stok varian lama dicatat
+// sebagai adjustment dengan alasan yang diberikan.
+func ApplyProductImport(tx *gorm.DB, imp *ProductImport, reason string, actorID *uint) ([]models.StockMovement, error) {
+	if !imp.Valid() {
+		return nil, ErrInvalidImport
+	}
+
+	var movements []models.StockMovement
+	for _, plan := range imp.Products {
+		if plan.Action == ImportActionUnchanged {
+			continue
+		}
+
+		categoryID := plan.CategoryID
+		if plan.Action == ImportActionCreate {
+			product := models.Product{
+				NameProduk: plan.NameProduk,
+				Deskripsi:  plan.Deskripsi,
+				Kategori:   plan.Kategori,
+				CategoryID: &categoryID,
+			}
+			if err := tx.Create(&product).Error; err != nil {
+				return nil, err
+			}
+			plan.ProductID = product.ID
+		} else if len(plan.Changes) > 0 {
+			if err := tx.Model(&models.Product{}).Where("id = ?", plan.ProductID).Updates(map[string]interface{}{
+				"name_produk": plan.NameProduk,
+				"deskripsi":   plan.Deskripsi,
+				"kategori":    plan.Kategori,
+				"category_id": categoryID,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		for _, variant := range plan.Variants {
+			switch variant.Action {
+			case ImportActionCreate:
+				item := models.ProductItem{
+					ProductID: plan.ProductID,
+					HargaPoin: variant.HargaPoin,
+					HargaRp:   variant.HargaRp,
+					Jumlah:    variant.Jumlah,
+					Satuan:    variant.Satuan,
+				}
+				if variant.Stok != nil {
+					item.Stok = *variant.Stok
+				}
+				if variant.ReorderThreshold != nil {
+					item.ReorderThreshold = *variant.ReorderThreshold
+				}
+				if err := tx.Create(&item).Error; err != nil {
+					return nil, err
+				}
+				if err := RecordInitialStock(tx, &item, actorID); err != nil {
+					return nil, err
+				}
+				if err := RecordPriceChange(tx, item.ID, 0, 0, item.HargaRp, item.HargaPoin, models.PriceSourceImport, nil, actorID); err != nil {
+					return nil, err
+				}
+
+			case ImportActionUpdate:
+				updates := map[string]interface{}{
+					"jumlah":     variant.Jumlah,
+					"satuan":     variant.Satuan,
+					"harga_rp":   variant.HargaRp,
+					"harga_poin": variant.HargaPoin,
+					"updated_at": time.Now(),
+				}
+				if variant.ReorderThreshold != nil {
+					updates["reorder_threshold"] = *variant.ReorderThreshold
+				}
+				if err := tx.Model(&models.ProductItem{}).Where("id = ?", variant.VariantID).Updates(updates).Error; err != nil {
+					return nil, err
+				}
+
+				if change, ok := variant.Changes["hargaRp"]; ok {
+					poinChange := variant.Changes["hargaPoin"]
+					if err := RecordPriceChange(tx, variant.VariantID, change.From.(int), poinChange.From.(int),
+						variant.HargaRp, variant.HargaPoin, models.PriceSourceImport, nil, actorID); err != nil {
+						return nil, err
+					}
+				}
+
+				if _, ok := variant.Changes["stok"]; ok {
+					movement, err := SetStockLevel(tx, variant.VariantID, *variant.Stok, reason, actorID)
+					if err != nil {
+						return nil, err
+					}
+					if movement != nil {
+						movements = append(movements, *movement)
+					}
+				}
+			}
+		}
+	}
+
+	return movements, nil
+}
+
+// ExportProductSheet menulis seluruh katalog dalam format yang sama dengan file import
+func ExportProductSheet(db *gorm.DB, format string) ([]byte, error) {
+	var products []models.Product
+	if err := db.Preload("ProductItems", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Order("name_produk ASC, id ASC").Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	rows := [][]interface{}{}
+	for _, product := range products {
+		base := []interface{}{product.ID, product.NameProduk, product.Kategori, product.Deskripsi}
+		if len(product.ProductItems) == 0 {
+			rows = append(rows, append(base, "", "", "", "", "", "", ""))
+			continue
+		}
+		for _, item := range product.ProductItems {
+			row := append(append([]interface{}{}, base...),
+				item.ID, item.Jumlah, item.Satuan, item.HargaRp, item.HargaPoin, item.Stok, item.ReorderThreshold)
+			rows = append(rows, row)
+		}
+	}
+
+	switch format {
+	case "csv":
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if err := writer.Write(productSheetColumns); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			record := make([]string, len(row))
+			for i, value := range row {
+				record[i] = fmt.Sprint(value)
+			}
+			if err := writer.Write(record); err != nil {
+				return nil, err
+			}
+		}
+		writer.Flush()
+		return buf.Bytes(), writer.Error()
+
+	case "xlsx":
+		file := excelize.NewFile()
+		defer file.Close()
+		if err := file.SetSheetName(file.GetSheetName(0), productSheetName); err != nil {
+			return nil, err
+		}
+
+		header := make([]interface{}, len(productSheetColumns))
+		for i, column := range productSheetColumns {
+			header[i] = column
+		}
+		if err := file.SetSheetRow(productSheetName, "A1", &header); err != nil {
+			return nil, err
+		}
+		for i, row := range rows {
+			cell, err := excelize.CoordinatesToCellName(1, i+2)
+			if err != nil {
+				return nil, err
+			}
+			if err := file.SetSheetRow(productSheetName, cell, &row); err != nil {
+				return nil, err
+			}
+		}
+
+		buf, err := file.WriteToBuffer()
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	return nil, ErrUnsupportedSheet
+}
+
+// ProductSheetFileName mengembalikan nama file export katalog
+func ProductSheetFileName(format string) string {
+	return fmt.Sprintf("katalog-produk-%s.%s", time.Now().Format("20060102"), format)
+}
@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+// Batas foto yang bisa dilampirkan pada satu ulasan
+const MaxReviewPhotos = 5
+
+var (
+	ErrReviewNotAllowed    = errors.New("order item is not from a delivered order of this user")
+	ErrReviewAlreadyExists = errors.New("order item has already been reviewed")
+	ErrInvalidRating       = errors.New("rating must be between 1 and 5")
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrInvalidReviewStatus = errors.New("status must be approved or hidden")
+	ErrReviewReplyRequired = errors.New("reply cannot be empty")
+)
+
+// ReviewInput adalah ulasan yang dikirim pembeli dari aplikasi
+type ReviewInput struct {
+	OrderItemID uint
+	Rating      int
+	Comment     string
+	Photos      []string // Nama file hasil UploadFiles
+}
+
+// ReviewableItem adalah item pesanan delivered yang belum diulas user
+type ReviewableItem struct {
+	OrderItemID   uint      `json:"orderItemId"`
+	PesananID     uint      `json:"pesananId"`
+	OrderID       string    `json:"orderId"`
+	ProductID     uint      `json:"productId"`
+	ProductItemID uint      `json:"productItemId"`
+	NamaProduk    string    `json:"namaProduk"`
+	Image         string    `json:"image"`
+	Jumlah        int       `json:"jumlah"`
+	Satuan        string    `json:"satuan"`
+	DeliveredAt   time.Time `json:"deliveredAt"`
+}
+
+// CreateReview menyimpan ulasan untuk item pesanan milik user yang sudah delivered.
+// Ulasan baru berstatus pending sampai disetujui admin.
+func CreateReview(tx *gorm.DB, userID uint, input ReviewInput) (*models.ProductReview, error) {
+	if input.Rating < 1 || input.Rating > 5 {
+		return nil, ErrInvalidRating
+	}
+
+	var orderItem models.OrderItem
+	err := tx.Joins("Pesanan").
+		Preload("ProductItem").
+		Where(`order_items.id = ? AND "Pesanan".user_id = ? AND "Pesanan".status = ?`,
+			input.OrderItemID, userID, models.PesananDelivered).
+		First(&orderItem).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && orderItem.ProductItem == nil) {
+		return nil, ErrReviewNotAllowed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := tx.Model(&models.ProductReview{}).Where("order_item_id = ?", orderItem.ID).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, ErrReviewAlreadyExists
+	}
+
+	review := models.ProductReview{
+		ProductID:     orderItem.ProductItem.ProductID,
+		ProductItemID: orderItem.ProductItemID,
+		OrderItemID:   orderItem.ID,
+		UserID:        userID,
+		Rating:        input.Rating,
+		Comment:       strings.TrimSpace(input.Comment),
+		Status:        models.ReviewPending,
+	}
+	for i, filename := range input.Photos {
+		review.Photos = append(review.Photos, models.ReviewPhoto{Filename: filename, Position: i})
+	}
+	if err := tx.Create(&review).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ReviewableItems mengembalikan item dari pesanan delivered user yang belum diulas
+func ReviewableItems(db *gorm.DB, userID uint) ([]ReviewableItem, error) {
+	items := []ReviewableItem{}
+	err := db.Table("order_items").
+		Select(`order_items.id AS order_item_id, pesanan.id AS pesanan_id, pesanan.order_id,
+			product_items.product_id, order_items.product_item_id, order_items.nama_produk,
+			products.image, order_items.jumlah, order_items.satuan, pesanan.updated_at AS delivered_at`).
+		Joins("JOIN pesanan ON pesanan.id = order_items.pesanan_id").
+		Joins("JOIN product_items ON product_items.id = order_items.product_item_id").
+		Joins("JOIN products ON products.id = product_items.product_id").
+		Joins("LEFT JOIN product_reviews ON product_reviews.order_item_id = order_items.id").
+		Where("pesanan.user_id = ? AND pesanan.status = ? AND product_reviews.id IS NULL", userID, models.PesananDelivered).
+		Order("pesanan.updated_at DESC, order_items.id ASC").
+		Scan(&items).Error
+	return items, err
+}
+
+// ModerateReview menyetujui atau menyembunyikan ulasan lalu menghitung ulang rating produk
+func ModerateReview(tx *gorm.DB, reviewID uint, status models.ReviewStatus, moderatorID *uint) (*models.ProductReview, error) {
+	if status != models.ReviewApproved && status != models.ReviewHidden {
+		return nil, ErrInvalidReviewStatus
+	}
+
+	review, err := lockReview(tx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := tx.Model(review).Updates(map[string]interface{}{
+		"status":       status,
+		"moderated_at": now,
+		"moderated_by": moderatorID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err := RefreshProductRating(tx, review.ProductID); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// ReplyReview menyimpan balasan admin pada ulasan
+func ReplyReview(tx *gorm.DB, reviewID uint, reply string, adminID *uint) (*models.ProductReview, error) {
+	reply = strings.TrimSpace(reply)
+	if reply == "" {
+		return nil, ErrReviewReplyRequired
+	}
+
+	review, err := lockReview(tx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Model(review).Updates(map[string]interface{}{
+		"admin_reply": reply,
+		"replied_at":  time.Now(),
+		"replied_by":  adminID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func lockReview(tx *gorm.DB, reviewID uint) (*models.ProductReview, error) {
+	var review models.ProductReview
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, reviewID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+// RefreshProductRating menghitung ulang rata-rata dan jumlah ulasan yang disetujui pada produk
+func RefreshProductRating(tx *gorm.DB, productID uint) error {
+	var aggregate struct {
+		Average float64
+		Count   int
+	}
+	if err := tx.Model(&models.ProductReview{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("product_id = ? AND status = ?", productID, models.ReviewApproved).
+		Scan(&aggregate).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"rating_average": aggregate.Average,
+		"rating_count":   aggregate.Count,
+	}).Error
+}
+
+// FillReviewerNames mengisi nama pengulas dari detail user tanpa membuka data akun lainnya
+func FillReviewerNames(db *gorm.DB, reviews []models.ProductReview) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+	userIDs := make([]uint, 0, len(reviews))
+	for _, review := range reviews {
+		userIDs = append(userIDs, review.UserID)
+	}
+
+	var details []models.DetailsUser
+	if err := db.Select("user_id", "fullname").Where("user_id IN ?", userIDs).Find(&details).Error; err != nil {
+		return err
+	}
+	names := make(map[uint]string, len(details))
+	for _, detail := range details {
+		names[detail.UserID] = detail.Fullname
+	}
+	for i := range reviews {
+		reviews[i].ReviewerName = names[reviews[i].UserID]
+	}
+	return nil
+}
+
+// ProductSortOrder mengembalikan klausa ORDER BY untuk parameter sort daftar produk aplikasi
+func ProductSortOrder(sort string) string {
+	if sort == "rating" {
+		return "rating_average DESC, rating_count DESC, name_produk ASC"
+	}
+	return "name_produk ASC"
+}
+
+// DeleteProductReviews menghapus ulasan dan foto ulasan produk, mengembalikan nama file foto
+func DeleteProductReviews(tx *gorm.DB, productID uint) ([]string, error) {
+	reviewIDs := tx.Model(&models.ProductReview{}).Select("id").Where("product_id = ?", productID)
+
+	var filenames []string
+	if err := tx.Model(&models.ReviewPhoto{}).Where("review_id IN (?)", reviewIDs).Pluck("filename", &filenames).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("review_id IN (?)", reviewIDs).Delete(&models.ReviewPhoto{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("product_id = ?", productID).Delete(&models.ProductReview{}).Error; err != nil {
+		return nil, err
+	}
+	return filenames, nil
+}
+
+// DeleteUserReviews menghapus ulasan dan foto ulasan milik user lalu menghitung ulang rating
+// produk yang terdampak, mengembalikan nama file foto
+func DeleteUserReviews(tx *gorm.DB, userID uint) ([]string, error) {
+	var productIDs []uint
+	if err := tx.Model(&models.ProductReview{}).Distinct("product_id").
+		Where("user_id = ?", userID).Pluck("product_id", &productIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	reviewIDs := tx.Model(&models.ProductReview{}).Select("id").Where("user_id = ?", userID)
+
+	var filenames []string
+	if err := tx.Model(&models.ReviewPhoto{}).Where("review_id IN (?)", reviewIDs).Pluck("filename", &filenames).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("review_id IN (?)", reviewIDs).Delete(&models.ReviewPhoto{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.ProductReview{}).Error; err != nil {
+		return nil, err
+	}
+
+	for _, productID := range productIDs {
+		if err := RefreshProductRating(tx, productID); err != nil {
+			return nil, err
+		}
+	}
+	return filenames, nil
+}
@@ -0,0 +1,334 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+const (
+	// Bobot dokumen pencarian: nama produk paling penting, lalu kategori, lalu deskripsi
+	productSearchDocument = `(setweight(to_tsvector('simple', coalesce(products.name_produk, '')), 'A') || ` +
+		`setweight(to_tsvector('simple', coalesce(products.kategori, '')), 'B') || ` +
+		`setweight(to_tsvector('simple', coalesce(products.deskripsi, '')), 'C'))`
+
+	// Ambang word_similarity untuk toleransi salah ketik, mis. "bayem" masih cocok dengan "bayam"
+	searchSimilarityThreshold = 0.4
+	autocompleteThreshold     = 0.5
+)
+
+// Kata umum yang tidak membantu pencarian
+var searchStopwords = map[string]bool{
+	"dan": true, "atau": true, "yang": true, "untuk": true, "di": true,
+	"ke": true, "dari": true, "dengan": true, "per": true, "the": true,
+}
+
+// Sinonim awal yang dibuat jika admin belum mengisi kamus
+var defaultSearchSynonyms = map[string]string{
+	"cabai":    "cabe,lombok",
+	"bayam":    "bayem",
+	"tomat":    "tomato",
+	"wortel":   "wortol,carrot",
+	"semangka": "tembikai",
+	"singkong": "ketela",
+	"kentang":  "potato",
+	"timun":    "mentimun,ketimun",
+}
+
+var (
+	searchSynonymCache map[string][]string
+	searchSynonymMu    sync.RWMutex
+)
+
+// SearchFacet adalah jumlah hasil pencarian per kategori
+type SearchFacet struct {
+	CategoryID *uint  `json:"categoryId"`
+	Name       string `json:"name"`
+	Slug       string `json:"slug"`
+	Count      int64  `json:"count"`
+}
+
+// ProductSearchParams adalah parameter pencarian produk
+type ProductSearchParams struct {
+	Query       string
+	CategoryIDs []uint // Kosong berarti semua kategori
+	Offset      int
+	Limit       int
+
+	SortByRating bool // Urutkan berdasarkan rating ulasan, relevansi sebagai pemecah seri
+}
+
+// ProductSearchResult berisi ID produk terurut relevansi beserta total dan facet kategori
+type ProductSearchResult struct {
+	ProductIDs []uint
+	Total      int64
+	Facets     []SearchFacet
+	Terms      []string // Kata setelah normalisasi dan perluasan sinonim
+}
+
+// AutocompleteSuggestion adalah satu saran pada kotak pencarian
+type AutocompleteSuggestion struct {
+	Type  string `json:"type"` // product atau category
+	ID    uint   `json:"id"`
+	Label string `json:"label"`
+	Slug  string `json:"slug,omitempty"`
+	Image string `json:"image,omitempty"`
+	Icon  string `json:"icon,omitempty"`
+}
+
+// NormalizeSearchQuery menyederhanakan query: huruf kecil, tanpa tanda baca, kata ulang
+// ("sayur-sayuran", "buah-buahan") menjadi kata dasar, dan tanpa stopword.
+func NormalizeSearchQuery(query string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if parts := strings.SplitN(word, "-", 2); len(parts) == 2 && parts[0] != "" && strings.HasPrefix(parts[1], parts[0]) {
+			word = parts[0]
+		}
+
+		cleaned := strings.FieldsFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, token := range cleaned {
+			if searchStopwords[token] || seen[token] {
+				continue
+			}
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// ParseSynonymList mengubah "cabe, Lombok" menjadi kata sinonim yang sudah dinormalisasi
+func ParseSynonymList(value string) []string {
+	return NormalizeSearchQuery(strings.ReplaceAll(value, ",", " "))
+}
+
+// InvalidateSearchSynonyms mengosongkan cache sinonim setelah admin mengubah kamus
+func InvalidateSearchSynonyms() {
+	searchSynonymMu.Lock()
+	searchSynonymCache = nil
+	searchSynonymMu.Unlock()
+}
+
+// searchSynonyms memetakan setiap kata ke seluruh kata di grup sinonimnya
+func searchSynonyms(db *gorm.DB) (map[string][]string, error) {
+	searchSynonymMu.RLock()
+	cache := searchSynonymCache
+	searchSynonymMu.RUnlock()
+	if cache != nil {
+		return cache, nil
+	}
+
+	var synonyms []models.SearchSynonym
+	if err := db.Find(&synonyms).Error; err != nil {
+		return nil, err
+	}
+
+	cache = make(map[string][]string)
+	for _, synonym := range synonyms {
+		group := append(NormalizeSearchQuery(synonym.Term), ParseSynonymList(synonym.Synonyms)...)
+		for _, word := range group {
+			cache[word] = appendUnique(cache[word], group...)
+		}
+	}
+
+	searchSynonymMu.Lock()
+	searchSynonymCache = cache
+	searchSynonymMu.Unlock()
+	return cache, nil
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// buildSearchTSQuery membuat tsquery: tiap kata (beserta sinonimnya) digabung OR dan dicocokkan
+// sebagai prefix, antar kata digabung AND. Token hanya berisi huruf/angka sehingga aman.
+func buildSearchTSQuery(tokens []string, synonyms map[string][]string) (string, []string) {
+	var groups []string
+	var terms []string
+	for _, token := range tokens {
+		words := appendUnique([]string{token}, synonyms[token]...)
+		terms = appendUnique(terms, words...)
+
+		parts := make([]string, len(words))
+		for i, word := range words {
+			parts[i] = word + ":*"
+		}
+		groups = append(groups, "("+strings.Join(parts, " | ")+")")
+	}
+	return strings.Join(groups, " & "), terms
+}
+
+// SearchProducts mencari produk dengan full-text search Postgres dan trigram similarity.
+// Hasil diurutkan berdasarkan relevansi; facet kategori dihitung tanpa filter kategori
+// agar pengguna tetap melihat kategori lain yang cocok.
+func SearchProducts(db *gorm.DB, params ProductSearchParams) (*ProductSearchResult, error) {
+	result := &ProductSearchResult{ProductIDs: []uint{}, Facets: []SearchFacet{}}
+
+	tokens := NormalizeSearchQuery(params.Query)
+	if len(tokens) == 0 {
+		return result, nil
+	}
+
+	synonyms, err := searchSynonyms(db)
+	if err != nil {
+		return nil, err
+	}
+	tsquery, terms := buildSearchTSQuery(tokens, synonyms)
+	result.Terms = terms
+
+	args := map[string]interface{}{
+		"tsquery":   tsquery,
+		"text":      strings.Join(tokens, " "),
+		"threshold": searchSimilarityThreshold,
+		"limit":     params.Limit,
+		"offset":    params.Offset,
+	}
+	match := `(` + productSearchDocument + ` @@ to_tsquery('simple', @tsquery)
+		OR word_similarity(@text, lower(products.name_produk)) >= @threshold)`
+
+	filtered := match
+	if params.CategoryIDs != nil {
+		filtered += ` AND products.category_id IN @categories`
+		args["categories"] = params.CategoryIDs
+		if len(params.CategoryIDs) == 0 {
+			return result, nil
+		}
+	}
+
+	if err := db.Raw(`SELECT COUNT(*) FROM products WHERE `+filtered, args).Scan(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	order := `ts_rank(` + productSearchDocument + `, to_tsquery('simple', @tsquery)) * 2
+			+ word_similarity(@text, lower(products.name_produk)) DESC, products.name_produk ASC`
+	if params.SortByRating {
+		order = `products.rating_average DESC, products.rating_count DESC, ` + order
+	}
+	if err := db.Raw(`SELECT products.id FROM products WHERE `+filtered+`
+		ORDER BY `+order+`
+		LIMIT @limit OFFSET @offset`, args).Scan(&result.ProductIDs).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Raw(`SELECT products.category_id, COALESCE(categories.name, 'Lainnya') AS name,
+			COALESCE(categories.slug, '') AS slug, COUNT(*) AS count
+		FROM products LEFT JOIN categories ON categories.id = products.category_id
+		WHERE `+match+`
+		GROUP BY products.category_id, categories.name, categories.slug
+		ORDER BY count DESC, name ASC`, args).Scan(&result.Facets).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// AutocompleteProducts memberi saran nama produk dan kategori untuk query yang sedang diketik
+func AutocompleteProducts(db *gorm.DB, query string, limit int) ([]AutocompleteSuggestion, error) {
+	suggestions := []AutocompleteSuggestion{}
+
+	tokens := NormalizeSearchQuery(query)
+	if len(tokens) == 0 {
+		return suggestions, nil
+	}
+	text := strings.Join(tokens, " ")
+	args := map[string]interface{}{
+		"text":       text,
+		"prefix":     text + "%",
+		"wordPrefix": "% " + text + "%",
+		"threshold":  autocompleteThreshold,
+		"limit":      limit,
+	}
+
+	var categories []models.Category
+	if err := db.Raw(`SELECT * FROM categories
+		WHERE is_active = true AND (lower(name) LIKE @prefix OR word_similarity(@text, lower(name)) >= @threshold)
+		ORDER BY (lower(name) LIKE @prefix) DESC, word_similarity(@text, lower(name)) DESC, name ASC
+		LIMIT 3`, args).Scan(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		suggestions = append(suggestions, AutocompleteSuggestion{
+			Type:  "category",
+			ID:    category.ID,
+			Label: category.Name,
+			Slug:  category.Slug,
+			Icon:  category.Icon,
+		})
+	}
+
+	var products []models.Product
+	if err := db.Raw(`SELECT id, name_produk, image FROM products
+		WHERE lower(name_produk) LIKE @prefix OR lower(name_produk) LIKE @wordPrefix
+			OR word_similarity(@text, lower(name_produk)) >= @threshold
+		ORDER BY (lower(name_produk) LIKE @prefix) DESC, word_similarity(@text, lower(name_produk)) DESC, name_produk ASC
+		LIMIT @limit`, args).Scan(&products).Error; err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		suggestions = append(suggestions, AutocompleteSuggestion{
+			Type:  "product",
+			ID:    product.ID,
+			Label: product.NameProduk,
+			Image: product.Image,
+		})
+	}
+
+	return suggestions, nil
+}
+
+// EnsureSearchIndexes memasang extension pg_trgm dan index pencarian produk. Aman dijalankan berulang kali.
+func EnsureSearchIndexes(db *gorm.DB) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS idx_products_search ON products USING GIN (` + productSearchDocument + `)`,
+		`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (lower(name_produk) gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_categories_name_trgm ON categories USING GIN (lower(name) gin_trgm_ops)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SeedSearchSynonyms mengisi kamus sinonim awal jika masih kosong
+func SeedSearchSynonyms(db *gorm.DB) (int, error) {
+	var count int64
+	if err := db.Model(&models.SearchSynonym{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	synonyms := make([]models.SearchSynonym, 0, len(defaultSearchSynonyms))
+	for term, words := range defaultSearchSynonyms {
+		synonyms = append(synonyms, models.SearchSynonym{Term: term, Synonyms: words})
+	}
+	if err := db.Create(&synonyms).Error; err != nil {
+		return 0, err
+	}
+	InvalidateSearchSynonyms()
+	return len(synonyms), nil
+}
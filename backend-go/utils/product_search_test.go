@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeSearchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"Bayam Hijau", []string{"bayam", "hijau"}},
+		{"  sayur-sayuran  segar ", []string{"sayur", "segar"}},
+		{"buah-buahan dan sayur", []string{"buah", "sayur"}},
+		{"cabe, tomat & bawang!", []string{"cabe", "tomat", "bawang"}},
+		{"tomat Tomat TOMAT", []string{"tomat"}},
+		{"beras 5kg", []string{"beras", "5kg"}},
+		{"anti-gores", []string{"anti", "gores"}},
+		{"yang untuk di", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeSearchQuery(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NormalizeSearchQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	firebase "firebase.google.com/go"
 	"firebase.google.com/go/messaging"
@@ -248,3 +249,251 @@ func sendMessage(msg *messaging.Message) {
 		log.Printf("Failed to send FCM message: %v", err)
 	}
 }
+
+// Notifikasi Poin Akan Kedaluwarsa
+func SendPointExpiryReminderNotification(fcmToken string, points int, expiresAt time.Time, daysLeft int) {
+	if fcmToken == "" {
+		return
+	}
+
+	uuidVal := uuid.New().String()
+
+	title := "Poin Anda Akan Kedaluwarsa ⏳"
+	body := strconv.Itoa(points) + " poin akan kedaluwarsa dalam " + strconv.Itoa(daysLeft) +
+		" hari (" + expiresAt.Format("02-01-2006") + "). Gunakan sebelum hangus!"
+
+	msg := &messaging.Message{
+		Token: fcmToken,
+		Notification: &messaging.Notification{
+			Title: title,
+			Body:  body,
+		},
+		Data: map[string]string{
+			"title":        title,
+			"body":         body,
+			"type":         "points_expiring",
+			"points":       strconv.Itoa(points),
+			"expiresAt":    expiresAt.Format(time.RFC3339),
+			"uuid":         uuidVal,
+			"click_action": "FLUTTER_NOTIFICATION_CLICK",
+		},
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				ChannelID: "points_channel",
+				Sound:     "default",
+				Tag:       uuidVal,
+			},
+		},
+	}
+
+	sendMessage(msg)
+}
+
+// Notifikasi Transfer Poin (dikirim ke pengirim dan penerima)
+func SendPointTransferNotification(fcmToken, transferID string, points int, counterpartName string, incoming bool) {
+	if fcmToken == "" {
+		return
+	}
+
+	uuidVal := uuid.New().String()
+
+	title := "Transfer Poin Berhasil ✅"
+	body := "Anda mengirim " + strconv.Itoa(points) + " poin ke " + counterpartName + "."
+	notifType := "points_transfer_out"
+	if incoming {
+		title = "Anda Menerima Poin 🎁"
+		body = counterpartName + " mengirim " + strconv.Itoa(points) + " poin untuk Anda."
+		notifType = "points_transfer_in"
+	}
+
+	msg := &messaging.Message{
+		Token: fcmToken,
+		Notification: &messaging.Notification{
+			Title: title,
+			Body:  body,
+		},
+		Data: map[string]string{
+			"title":        title,
+			"body":         body,
+			"type":         notifType,
+			"transferId":   transferID,
+			"points":       strconv.Itoa(points),
+			"uuid":         uuidVal,
+			"click_action": "FLUTTER_NOTIFICATION_CLICK",
+		},
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				ChannelID: "points_channel",
+				Sound:     "default",
+				Tag:       uuidVal,
+			},
+		},
+	}
+
+	sendMessage(msg)
+}
+
+// SendAffiliatePayoutNotification memberi tahu affiliate bahwa bonusnya sudah ditransfer ke rekening
+func SendAffiliatePayoutNotification(fcmToken, batchNumber string, amount float64, bonusCount int) {
+	if fcmToken == "" {
+		return
+	}
+
+	uuidVal := uuid.New().String()
+
+	title := "Bonus Afiliasi Ditransfer 💸"
+	body := "Bonus afiliasi sebesar " + formatIDR(amount) + " dari " + strconv.Itoa(bonusCount) + " transaksi sudah ditransfer ke rekening Anda."
+
+	msg := &messaging.Message{
+		Token: fcmToken,
+		Notification: &messaging.Notification{
+			Title: title,
+			Body:  body,
+		},
+		Data: map[string]string{
+			"title":        title,
+			"body":         body,
+			"type":         "affiliate_payout",
+			"batchNumber":  batchNumber,
+			"amount":       strconv.FormatFloat(amount, 'f', 0, 64),
+			"uuid":         uuidVal,
+			"click_action": "FLUTTER_NOTIFICATION_CLICK",
+		},
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				ChannelID: "points_channel",
+				Sound:     "default",
+				Tag:       uuidVal,
+			},
+		},
+	}
+
+	sendMessage(msg)
+}
+
+// SendBonusExpiryReminderNotification mengingatkan affiliate untuk mengklaim bonus yang akan kedaluwarsa
+func SendBonusExpiryReminderNotification(fcmToken string, amount float64, bonusCount int, expiresAt time.Time, daysLeft int) {
+	if fcmToken == "" {
+		return
+	}
+
+	uuidVal := uuid.New().String()
+
+	title := "Bonus Afiliasi Akan Kedaluwarsa ⏳"
+	body := "Bonus " + formatIDR(amount) + " (" + strconv.Itoa(bonusCount) + " transaksi) akan kedaluwarsa dalam " +
+		strconv.Itoa(daysLeft) + " hari (" + expiresAt.Format("02-01-2006") + "). Klaim sekarang!"
+
+	msg := &messaging.Message{
+		Token: fcmToken,
+		Notification: &messaging.Notification{
+			Title: title,
+			Body:  body,
+		},
+		Data: map[string]string{
+			"title":        title,
+			"body":         body,
+			"type":         "affiliate_bonus_expiring",
+			"screen":       "affiliate_claim", // Deep link ke layar klaim bonus
+			"amount":       strconv.FormatFloat(amount, 'f', 0, 64),
+			"expiresAt":    expiresAt.Format(time.RFC3339),
+			"uuid":         uuidVal,
+			"click_action": "FLUTTER_NOTIFICATION_CLICK",
+		},
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				ChannelID: "points_channel",
+				Sound:     "default",
+				Tag:       uuidVal,
+			},
+		},
+	}
+
+	sendMessage(msg)
+}
+
+// SendBonusDigestNotification mengirim ringkasan mingguan bonus afiliasi yang belum diklaim
+func SendBonusDigestNotification(fcmToken string, amount float64, bonusCount int, nearestExpiry time.Time) {
+	if fcmToken == "" {
+		return
+	}
+
+	uuidVal := uuid.New().String()
+
+	title := "Bonus Afiliasi Menunggu Diklaim 💰"
+	body := "Anda punya " + strconv.Itoa(bonusCount) + " bonus senilai " + formatIDR(amount) +
+		" yang belum diklaim. Bonus terdekat kedaluwarsa " + nearestExpiry.Format("02-01-2006") + "."
+
+	msg := &messaging.Message{
+		Token: fcmToken,
+		Notification: &messaging.Notification{
+			Title: title,
+			Body:  body,
+		},
+		Data: map[string]string{
+			"title":        title,
+			"body":         body,
+			"type":         "affiliate_bonus_digest",
+			"screen":       "affiliate_claim",
+			"amount":       strconv.FormatFloat(amount, 'f', 0, 64),
+			"bonusCount":   strconv.Itoa(bonusCount),
+			"uuid":         uuidVal,
+			"click_action": "FLUTTER_NOTIFICATION_CLICK",
+		},
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				ChannelID: "points_channel",
+				Sound:     "default",
+				Tag:       uuidVal,
+			},
+		},
+	}
+
+	sendMessage(msg)
+}
+
+// SendReferralRewardNotification mengirim notifikasi hadiah poin referral
+func SendReferralRewardNotification(fcmToken string, points int, rewardType string) {
+	if fcmToken == "" {
+		return
+	}
+
+	uuidVal := uuid.New().String()
+
+	title := "Hadiah Referral 🎁"
+	body := "Selamat datang! Anda mendapat " + strconv.Itoa(points) + " poin dari kode referral."
+	if rewardType == "first_order" {
+		body = "Teman yang Anda ajak menyelesaikan pesanan pertamanya. Anda mendapat " + strconv.Itoa(points) + " poin."
+	}
+
+	msg := &messaging.Message{
+		Token: fcmToken,
+		Notification: &messaging.Notification{
+			Title: title,
+			Body:  body,
+		},
+		Data: map[string]string{
+			"title":        title,
+			"body":         body,
+			"type":         "referral_reward",
+			"rewardType":   rewardType,
+			"points":       strconv.Itoa(points),
+			"uuid":         uuidVal,
+			"click_action": "FLUTTER_NOTIFICATION_CLICK",
+		},
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				ChannelID: "points_channel",
+				Sound:     "default",
+				Tag:       uuidVal,
+			},
+		},
+	}
+
+	sendMessage(msg)
+}
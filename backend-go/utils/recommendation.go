@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+const (
+	// Riwayat pesanan yang dipakai untuk menghitung produk yang sering dibeli bersama
+	AffinityLookbackDays = 365
+	// Pasangan produk harus muncul bersama minimal sekian pesanan agar tidak kebetulan
+	MinCoPurchaseCount = 2
+	// Produk terkait yang disimpan per produk
+	MaxRelatedProducts = 20
+)
+
+// inStockProduct membatasi rekomendasi ke produk yang masih punya varian dengan stok
+const inStockProduct = `EXISTS (SELECT 1 FROM product_items WHERE product_items.product_id = %s AND product_items.stok > 0)`
+
+func inStock(column string) string {
+	return fmt.Sprintf(inStockProduct, column)
+}
+
+// ComputeProductAffinities menghitung ulang pasangan produk yang sering dibeli bersama dari
+// OrderItem pesanan yang tidak dibatalkan. Skor memakai confidence yang diperkuat lift sehingga
+// produk yang memang populer (mis. bawang) tidak mendominasi semua rekomendasi.
+func ComputeProductAffinities(db *gorm.DB) (int64, error) {
+	args := map[string]interface{}{
+		"cancelled":  models.PesananCancelled,
+		"since":      time.Now().AddDate(0, 0, -AffinityLookbackDays),
+		"minCount":   MinCoPurchaseCount,
+		"maxRelated": MaxRelatedProducts,
+		"now":        time.Now(),
+	}
+
+	var inserted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DELETE FROM product_affinities`).Error; err != nil {
+			return err
+		}
+		result := tx.Exec(`INSERT INTO product_affinities
+			(product_id, related_product_id, co_purchase_count, confidence, lift, score, computed_at)
+		WITH baskets AS (
+			SELECT DISTINCT order_items.pesanan_id, product_items.product_id
+			FROM order_items
+			JOIN pesanan ON pesanan.id = order_items.pesanan_id
+			JOIN product_items ON product_items.id = order_items.product_item_id
+			WHERE pesanan.status <> @cancelled AND pesanan.created_at >= @since
+		),
+		totals AS (
+			SELECT COUNT(DISTINCT pesanan_id)::float AS orders FROM baskets
+		),
+		support AS (
+			SELECT product_id, COUNT(*)::float AS orders FROM baskets GROUP BY product_id
+		),
+		pairs AS (
+			SELECT a.product_id, b.product_id AS related_product_id, COUNT(*) AS co_purchase_count
+			FROM baskets a
+			JOIN baskets b ON b.pesanan_id = a.pesanan_id AND b.product_id <> a.product_id
+			GROUP BY a.product_id, b.product_id
+			HAVING COUNT(*) >= @minCount
+		),
+		scored AS (
+			SELECT pairs.product_id, pairs.related_product_id, pairs.co_purchase_count,
+				pairs.co_purchase_count / sa.orders AS confidence,
+				(pairs.co_purchase_count / sa.orders) / (sb.orders / totals.orders) AS lift
+			FROM pairs
+			JOIN support sa ON sa.product_id = pairs.product_id
+			JOIN support sb ON sb.product_id = pairs.related_product_id
+			CROSS JOIN totals
+		),
+		ranked AS (
+			SELECT scored.*, scored.confidence * LN(1 + scored.lift) AS score,
+				ROW_NUMBER() OVER (PARTITION BY scored.product_id
+					ORDER BY scored.confidence * LN(1 + scored.lift) DESC, scored.co_purchase_count DESC) AS position
+			FROM scored
+		)
+		SELECT product_id, related_product_id, co_purchase_count, confidence, lift, score, @now
+		FROM ranked
+		WHERE position <= @maxRelated`, args)
+		if result.Error != nil {
+			return result.Error
+		}
+		inserted = result.RowsAffected
+		return nil
+	})
+	return inserted, err
+}
+
+// RelatedProductIDs mengembalikan produk yang sering dibeli bersama produk ini. Jika data
+// pembelian belum cukup, sisanya diisi produk sekategori dengan rating terbaik.
+func RelatedProductIDs(db *gorm.DB, productID uint, limit int) ([]uint, error) {
+	ids := []uint{}
+	if err := db.Model(&models.ProductAffinity{}).
+		Where("product_id = ? AND "+inStock("product_affinities.related_product_id"), productID).
+		Order("score DESC").
+		Limit(limit).
+		Pluck("related_product_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) >= limit {
+		return ids, nil
+	}
+
+	exclude := append([]uint{productID}, ids...)
+	var fallback []uint
+	if err := db.Model(&models.Product{}).
+		Where("category_id = (SELECT category_id FROM products WHERE id = ?) AND id NOT IN ? AND "+inStock("products.id"),
+			productID, exclude).
+		Order("rating_average DESC, rating_count DESC, name_produk ASC").
+		Limit(limit-len(ids)).
+		Pluck("id", &fallback).Error; err != nil {
+		return nil, err
+	}
+	return append(ids, fallback...), nil
+}
+
+// CartRecommendationIDs mengembalikan produk yang sering dibeli bersama isi keranjang aktif user.
+// Produk yang difavoritkan user mendapat bobot lebih.
+func CartRecommendationIDs(db *gorm.DB, userID uint, limit int) ([]uint, error) {
+	ids := []uint{}
+	err := db.Raw(`WITH cart_products AS (
+			SELECT DISTINCT product_items.product_id
+			FROM carts JOIN product_items ON product_items.id = carts.product_item_id
+			WHERE carts.user_id = @user AND carts.status = 'active'
+		)
+		SELECT product_affinities.related_product_id
+		FROM product_affinities
+		LEFT JOIN favorites ON favorites.product_id = product_affinities.related_product_id AND favorites.user_id = @user
+		WHERE product_affinities.product_id IN (SELECT product_id FROM cart_products)
+			AND product_affinities.related_product_id NOT IN (SELECT product_id FROM cart_products)
+			AND `+inStock("product_affinities.related_product_id")+`
+		GROUP BY product_affinities.related_product_id
+		ORDER BY SUM(product_affinities.score) * CASE WHEN COUNT(favorites.id) > 0 THEN 1.5 ELSE 1 END DESC
+		LIMIT @limit`, map[string]interface{}{
+		"user":  userID,
+		"limit": limit,
+	}).Scan(&ids).Error
+	return ids, err
+}
+
+// BuyAgainProductIDs mengembalikan produk yang pernah dibeli user (paling sering dan terbaru lebih dulu)
+// ditambah produk favoritnya yang masih tersedia.
+func BuyAgainProductIDs(db *gorm.DB, userID uint, limit int) ([]uint, error) {
+	ids := []uint{}
+	err := db.Raw(`SELECT candidates.product_id
+		FROM (
+			SELECT product_items.product_id, COUNT(DISTINCT pesanan.id) AS orders, 0 AS favorite,
+				MAX(pesanan.created_at) AS last_at
+			FROM order_items
+			JOIN pesanan ON pesanan.id = order_items.pesanan_id
+			JOIN product_items ON product_items.id = order_items.product_item_id
+			WHERE pesanan.user_id = @user AND pesanan.status <> @cancelled
+			GROUP BY product_items.product_id
+			UNION ALL
+			SELECT favorites.product_id, 0 AS orders, 1 AS favorite, favorites.created_at AS last_at
+			FROM favorites
+			WHERE favorites.user_id = @user
+		) candidates
+		WHERE `+inStock("candidates.product_id")+`
+		GROUP BY candidates.product_id
+		ORDER BY SUM(candidates.orders) + MAX(candidates.favorite) DESC, MAX(candidates.last_at) DESC
+		LIMIT @limit`, map[string]interface{}{
+		"user":      userID,
+		"cancelled": models.PesananCancelled,
+		"limit":     limit,
+	}).Scan(&ids).Error
+	return ids, err
+}
+
+// LoadProductsInOrder memuat produk beserta varian dan galerinya dengan urutan sesuai ids
+func LoadProductsInOrder(db *gorm.DB, ids []uint) ([]models.Product, error) {
+	products := []models.Product{}
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	var found []models.Product
+	if err := db.Preload("ProductItems").
+		Preload("Images", OrderProductImages).
+		Where("id IN ?", ids).
+		Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Product, len(found))
+	for _, product := range found {
+		byID[product.ID] = product
+	}
+	for _, id := range ids {
+		if product, ok := byID[id]; ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
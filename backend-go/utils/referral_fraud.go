@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+const (
+	// Registrasi ke-N di bawah referrer yang sama dalam jendela waktu ini dianggap burst
+	ReferralBurstWindow    = time.Hour
+	ReferralBurstThreshold = 5
+)
+
+var nonDigit = regexp.MustCompile(`[^0-9]`)
+
+// DetectRegistrationFraud mencatat sinyal fraud untuk user yang baru mendaftar:
+// perangkat (FCM token) yang sudah dipakai akun lain dan registrasi beruntun di bawah satu referrer.
+func DetectRegistrationFraud(db *gorm.DB, user *models.User) ([]models.ReferralFraudSignal, error) {
+	var signals []models.ReferralFraudSignal
+
+	if user.FCMToken != "" {
+		var others []models.User
+		if err := db.Where("fcm_token = ? AND id <> ?", user.FCMToken, user.ID).Limit(10).Find(&others).Error; err != nil {
+			return nil, err
+		}
+		for _, other := range others {
+			relatedID := other.ID
+			signals = append(signals, models.ReferralFraudSignal{
+				UserID:        user.ID,
+				RelatedUserID: &relatedID,
+				ReferrerID:    user.ReferredBy,
+				Type:          models.FraudSharedDevice,
+				Context:       models.FraudContextRegistration,
+				Detail:        "Device already registered to " + other.Email,
+			})
+		}
+	}
+
+	if user.ReferredBy != nil {
+		var recent int64
+		if err := db.Model(&models.User{}).
+			Where("referred_by = ? AND created_at >= ? AND created_at <= ?",
+				*user.ReferredBy, user.CreatedAt.Add(-ReferralBurstWindow), user.CreatedAt).
+			Count(&recent).Error; err != nil {
+			return nil, err
+		}
+		if recent >= ReferralBurstThreshold {
+			signals = append(signals, models.ReferralFraudSignal{
+				UserID:        user.ID,
+				RelatedUserID: user.ReferredBy,
+				ReferrerID:    user.ReferredBy,
+				Type:          models.FraudBurstRegistration,
+				Context:       models.FraudContextRegistration,
+				Detail:        fmt.Sprintf("%d registrations under the same referrer within %s", recent, ReferralBurstWindow),
+			})
+		}
+	}
+
+	if len(signals) > 0 {
+		if err := db.Create(&signals).Error; err != nil {
+			return nil, err
+		}
+	}
+	return signals, nil
+}
+
+// DetectBonusFraud membandingkan pembeli dengan penerima bonus (referrer di salah satu level).
+// Sinyal burst dari registrasi pembeli ikut diperhitungkan. Sinyal belum disimpan.
+func DetectBonusFraud(tx *gorm.DB, buyer, recipient *models.User) ([]models.ReferralFraudSignal, error) {
+	var signals []models.ReferralFraudSignal
+	recipientID := recipient.ID
+	add := func(signalType models.FraudSignalType, detail string) {
+		signals = append(signals, models.ReferralFraudSignal{
+			UserID:        buyer.ID,
+			RelatedUserID: &recipientID,
+			ReferrerID:    buyer.ReferredBy,
+			Type:          signalType,
+			Context:       models.FraudContextBonus,
+			Detail:        detail,
+		})
+	}
+
+	if buyer.FCMToken != "" && buyer.FCMToken == recipient.FCMToken {
+		add(models.FraudSharedDevice, "Buyer and bonus recipient use the same device")
+	}
+
+	var accounts []models.BankAccount
+	if err := tx.Where("user_id IN ?", []uint{buyer.ID, recipient.ID}).Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	if sharesValue(accounts, buyer.ID, recipient.ID, func(a models.BankAccount) (uint, string) {
+		return a.UserID, nonDigit.ReplaceAllString(a.AccountNumber, "")
+	}) {
+		add(models.FraudSharedBankAccount, "Buyer and bonus recipient share a bank account number")
+	}
+
+	var addresses []models.Address
+	if err := tx.Where("user_id IN ?", []uint{buyer.ID, recipient.ID}).Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	if sharesValue(addresses, buyer.ID, recipient.ID, func(a models.Address) (uint, string) {
+		return a.UserID, normalizeAddress(a.AddressLine1, a.City)
+	}) {
+		add(models.FraudSharedAddress, "Buyer and bonus recipient share a delivery address")
+	}
+
+	var details []models.DetailsUser
+	if err := tx.Where("user_id IN ?", []uint{buyer.ID, recipient.ID}).Find(&details).Error; err != nil {
+		return nil, err
+	}
+	type phoneEntry struct {
+		userID uint
+		phone  string
+	}
+	var phones []phoneEntry
+	for _, d := range details {
+		phones = append(phones, phoneEntry{d.UserID, normalizePhone(d.PhoneNumber)})
+	}
+	for _, a := range addresses {
+		phones = append(phones, phoneEntry{a.UserID, normalizePhone(a.PhoneNumber)})
+	}
+	if sharesValue(phones, buyer.ID, recipient.ID, func(p phoneEntry) (uint, string) {
+		return p.userID, p.phone
+	}) {
+		add(models.FraudSharedPhone, "Buyer and bonus recipient share a phone number")
+	}
+
+	var bursts int64
+	if err := tx.Model(&models.ReferralFraudSignal{}).
+		Where("user_id = ? AND type = ? AND context = ?", buyer.ID, models.FraudBurstRegistration, models.FraudContextRegistration).
+		Count(&bursts).Error; err != nil {
+		return nil, err
+	}
+	if bursts > 0 {
+		add(models.FraudBurstRegistration, "Buyer registered during a burst of referrals")
+	}
+
+	return signals, nil
+}
+
+// FraudHoldReason merangkum jenis sinyal menjadi alasan hold bonus
+func FraudHoldReason(signals []models.ReferralFraudSignal) string {
+	seen := make(map[models.FraudSignalType]bool)
+	var types []string
+	for _, signal := range signals {
+		if !seen[signal.Type] {
+			seen[signal.Type] = true
+			types = append(types, string(signal.Type))
+		}
+	}
+	return strings.Join(types, ",")
+}
+
+// sharesValue mengecek apakah ada nilai (tidak kosong) yang dimiliki kedua user sekaligus
+func sharesValue[T any](items []T, userA, userB uint, key func(T) (uint, string)) bool {
+	owners := make(map[string]uint)
+	for _, item := range items {
+		userID, value := key(item)
+		if value == "" || (userID != userA && userID != userB) {
+			continue
+		}
+		if owner, ok := owners[value]; ok && owner != userID {
+			return true
+		}
+		owners[value] = userID
+	}
+	return false
+}
+
+func normalizeAddress(line, city string) string {
+	line = strings.Join(strings.Fields(strings.ToLower(line)), " ")
+	if line == "" {
+		return ""
+	}
+	return line + "|" + strings.ToLower(strings.TrimSpace(city))
+}
+
+// normalizePhone menyamakan format 08xx, 628xx dan +628xx
+func normalizePhone(phone string) string {
+	phone = nonDigit.ReplaceAllString(phone, "")
+	if strings.HasPrefix(phone, "62") {
+		phone = "0" + phone[2:]
+	}
+	return phone
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend-go/models"
+)
+
+const (
+	SettingReferralSignupRewardPoints     = "referralSignupRewardPoints"
+	SettingReferralFirstOrderRewardPoints = "referralFirstOrderRewardPoints"
+)
+
+// GetReferralRewardConfig membaca poin hadiah referral. 0 berarti hadiah tersebut tidak aktif.
+func GetReferralRewardConfig(db *gorm.DB) (signupPoints int, firstOrderPoints int) {
+	var setting models.Setting
+	if err := db.Where("key = ?", SettingReferralSignupRewardPoints).First(&setting).Error; err == nil {
+		if v, err := strconv.Atoi(setting.Value); err == nil && v > 0 {
+			signupPoints = v
+		}
+	}
+
+	setting = models.Setting{}
+	if err := db.Where("key = ?", SettingReferralFirstOrderRewardPoints).First(&setting).Error; err == nil {
+		if v, err := strconv.Atoi(setting.Value); err == nil && v > 0 {
+			firstOrderPoints = v
+		}
+	}
+
+	return signupPoints, firstOrderPoints
+}
+
+// GrantSignupReward memberi poin ke user hasil referral saat akunnya disetujui.
+// Mengembalikan nil jika user bukan hasil referral, hadiah tidak aktif, atau hadiah sudah pernah diberikan.
+func GrantSignupReward(tx *gorm.DB, user *models.User) (*models.ReferralReward, error) {
+	if user.ReferredBy == nil {
+		return nil, nil
+	}
+
+	points, _ := GetReferralRewardConfig(tx)
+	if points <= 0 {
+		return nil, nil
+	}
+
+	reward := models.ReferralReward{
+		UserID:         user.ID,
+		ReferralUserID: user.ID,
+		Type:           models.ReferralRewardSignup,
+		Points:         points,
+	}
+	return grantReferralReward(tx, &reward)
+}
+
+// GrantFirstOrderReward memberi poin ke referrer saat pesanan pertama user referral-nya delivered.
+// Pesanan delivered lain milik pembeli berarti ini bukan pesanan pertama dan tidak ada hadiah.
+func GrantFirstOrderReward(tx *gorm.DB, pesanan *models.Pesanan) (*models.ReferralReward, error) {
+	_, points := GetReferralRewardConfig(tx)
+	if points <= 0 {
+		return nil, nil
+	}
+
+	var buyer models.User
+	if err := tx.First(&buyer, pesanan.UserId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if buyer.ReferredBy == nil {
+		return nil, nil
+	}
+
+	var previousOrders int64
+	if err := tx.Model(&models.Pesanan{}).
+		Where("user_id = ? AND status = ? AND id <> ?", buyer.ID, models.PesananDelivered, pesanan.ID).
+		Count(&previousOrders).Error; err != nil {
+		return nil, err
+	}
+	if previousOrders > 0 {
+		return nil, nil
+	}
+
+	var referrer models.User
+	if err := tx.First(&referrer, *buyer.ReferredBy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	reward := models.ReferralReward{
+		UserID:         referrer.ID,
+		ReferralUserID: buyer.ID,
+		Type:           models.ReferralRewardFirstOrder,
+		PesananID:      &pesanan.ID,
+		Points:         points,
+	}
+	return grantReferralReward(tx, &reward)
+}
+
+// grantReferralReward menyimpan hadiah lalu mengkredit poin. Unique index (referral_user_id, type)
+// menjadi kunci idempotensi, termasuk untuk request paralel.
+func grantReferralReward(tx *gorm.DB, reward *models.ReferralReward) (*models.ReferralReward, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reward)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	reference := fmt.Sprintf("referral-%s-%d", reward.Type, reward.ReferralUserID)
+	if _, err := CreditPoints(tx, reward.UserID, reward.Points, models.PointSourceReferral, reference); err != nil {
+		return nil, err
+	}
+	return reward, nil
+}
@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend-go/models"
+)
+
+const (
+	DefaultReferralTreeDepth = 3
+	MaxReferralTreeDepth     = 10
+	DefaultReferralTreeLimit = 10
+	MaxReferralTreeLimit     = 100
+)
+
+// ReferralTreeNode adalah satu downline beserta statistiknya relatif terhadap root
+type ReferralTreeNode struct {
+	ID              uint      `json:"id"`
+	Email           string    `json:"email"`
+	Fullname        string    `json:"fullname"`
+	ReferralCode    string    `json:"referralCode"`
+	ReferredBy      uint      `json:"referredBy"` // Parent node di level sebelumnya
+	Level           int       `json:"level"`
+	JoinedAt        time.Time `json:"joinedAt"`
+	IsApproved      bool      `json:"isApproved"`
+	DirectReferrals int64     `json:"directReferrals"`
+	LifetimeSpent   int64     `json:"lifetimeSpent"`  // Total belanja delivered
+	MonthlySpent    int64     `json:"monthlySpent"`   // Belanja delivered bulan ini
+	BonusGenerated  float64   `json:"bonusGenerated"` // Bonus untuk root dari pesanan node ini
+}
+
+// ReferralTreeLevel adalah satu halaman node pada level tertentu
+type ReferralTreeLevel struct {
+	Level     int                `json:"level"`
+	Page      int                `json:"page"`
+	Limit     int                `json:"limit"`
+	TotalPage int                `json:"totalPage"`
+	TotalRows int64              `json:"totalRows"`
+	Nodes     []ReferralTreeNode `json:"nodes"`
+}
+
+// ReferralTreeMember adalah node downline yang ditampilkan ke affiliate di aplikasi. Downline di bawah
+// level 1 tidak dikenal affiliate, jadi nama disamarkan dan email serta nilai belanja tidak dibuka.
+type ReferralTreeMember struct {
+	ID              uint      `json:"id"`
+	Name            string    `json:"name"`
+	ReferredBy      uint      `json:"referredBy"`
+	Level           int       `json:"level"`
+	JoinedAt        time.Time `json:"joinedAt"`
+	IsApproved      bool      `json:"isApproved"`
+	DirectReferrals int64     `json:"directReferrals"`
+	BonusGenerated  float64   `json:"bonusGenerated"`
+}
+
+// ReferralTreeMemberLevel adalah satu halaman ReferralTreeMember pada level tertentu
+type ReferralTreeMemberLevel struct {
+	Level     int                  `json:"level"`
+	Page      int                  `json:"page"`
+	Limit     int                  `json:"limit"`
+	TotalPage int                  `json:"totalPage"`
+	TotalRows int64                `json:"totalRows"`
+	Nodes     []ReferralTreeMember `json:"nodes"`
+}
+
+// MaskReferralTreeLevel mengubah satu level tree ke bentuk yang aman ditampilkan di aplikasi
+func MaskReferralTreeLevel(level *ReferralTreeLevel) ReferralTreeMemberLevel {
+	masked := ReferralTreeMemberLevel{
+		Level:     level.Level,
+		Page:      level.Page,
+		Limit:     level.Limit,
+		TotalPage: level.TotalPage,
+		TotalRows: level.TotalRows,
+		Nodes:     make([]ReferralTreeMember, len(level.Nodes)),
+	}
+	for i, node := range level.Nodes {
+		masked.Nodes[i] = ReferralTreeMember{
+			ID:              node.ID,
+			Name:            MaskName(node.Fullname),
+			ReferredBy:      node.ReferredBy,
+			Level:           node.Level,
+			JoinedAt:        node.JoinedAt,
+			IsApproved:      node.IsApproved,
+			DirectReferrals: node.DirectReferrals,
+			BonusGenerated:  node.BonusGenerated,
+		}
+	}
+	return masked
+}
+
+// referralTreeCTE mengambil seluruh downline root sampai kedalaman tertentu.
+// Batas level sekaligus mencegah loop tak berujung jika data referral membentuk siklus.
+const referralTreeCTE = `WITH RECURSIVE tree AS (
+	SELECT id, referred_by, 1 AS level FROM users WHERE referred_by = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT u.id, u.referred_by, t.level + 1 FROM users u
+	JOIN tree t ON u.referred_by = t.id
+	WHERE t.level < ? AND u.deleted_at IS NULL
+) `
+
+// ClampReferralTreeDepth membatasi kedalaman tree yang diminta
+func ClampReferralTreeDepth(depth int) int {
+	if depth < 1 {
+		return DefaultReferralTreeDepth
+	}
+	if depth > MaxReferralTreeDepth {
+		return MaxReferralTreeDepth
+	}
+	return depth
+}
+
+// ClampReferralTreeLimit membatasi jumlah node per halaman yang diminta
+func ClampReferralTreeLimit(limit int) int {
+	if limit <= 0 || limit > MaxReferralTreeLimit {
+		return DefaultReferralTreeLimit
+	}
+	return limit
+}
+
+// GetReferralTree mengembalikan halaman pertama setiap level downline root sampai depth
+func GetReferralTree(db *gorm.DB, rootID uint, depth, limit int) ([]ReferralTreeLevel, error) {
+	depth = ClampReferralTreeDepth(depth)
+
+	type levelCount struct {
+		Level int
+		Total int64
+	}
+	var counts []levelCount
+	if err := db.Raw(referralTreeCTE+"SELECT level, COUNT(*) AS total FROM tree GROUP BY level ORDER BY level",
+		rootID, depth).Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	levels := make([]ReferralTreeLevel, 0, len(counts))
+	for _, count := range counts {
+		level, err := getReferralTreeLevel(db, rootID, count.Level, 0, limit, count.Total)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, *level)
+	}
+	return levels, nil
+}
+
+// GetReferralTreeLevel mengembalikan satu halaman node downline root pada level tertentu
+func GetReferralTreeLevel(db *gorm.DB, rootID uint, level, page, limit int) (*ReferralTreeLevel, error) {
+	var total int64
+	if err := db.Raw(referralTreeCTE+"SELECT COUNT(*) FROM tree WHERE level = ?",
+		rootID, level, level).Scan(&total).Error; err != nil {
+		return nil, err
+	}
+	return getReferralTreeLevel(db, rootID, level, page, limit, total)
+}
+
+func getReferralTreeLevel(db *gorm.DB, rootID uint, level, page, limit int, total int64) (*ReferralTreeLevel, error) {
+	limit = ClampReferralTreeLimit(limit)
+	if page < 0 {
+		page = 0
+	}
+
+	result := &ReferralTreeLevel{
+		Level:     level,
+		Page:      page,
+		Limit:     limit,
+		TotalPage: (int(total) + limit - 1) / limit,
+		TotalRows: total,
+		Nodes:     []ReferralTreeNode{},
+	}
+	if total == 0 {
+		return result, nil
+	}
+
+	if err := db.Raw(referralTreeCTE+`SELECT u.id, u.email, COALESCE(d.fullname, '') AS fullname, u.referral_code,
+		u.referred_by, t.level, u.created_at AS joined_at, u.is_approved
+		FROM tree t
+		JOIN users u ON u.id = t.id
+		LEFT JOIN details_users d ON d.user_id = u.id
+		WHERE t.level = ?
+		ORDER BY u.created_at ASC, u.id ASC
+		LIMIT ? OFFSET ?`,
+		rootID, level, level, limit, page*limit).Scan(&result.Nodes).Error; err != nil {
+		return nil, err
+	}
+
+	if err := fillReferralNodeStats(db, rootID, result.Nodes); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func fillReferralNodeStats(db *gorm.DB, rootID uint, nodes []ReferralTreeNode) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID
+	}
+
+	now := time.Now()
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	type spendRow struct {
+		UserID   uint
+		Lifetime int64
+		Monthly  int64
+	}
+	var spends []spendRow
+	if err := db.Model(&models.Pesanan{}).
+		Select("user_id, COALESCE(SUM(total_bayar), 0) AS lifetime, COALESCE(SUM(CASE WHEN created_at >= ? THEN total_bayar ELSE 0 END), 0) AS monthly", startOfMonth).
+		Where("user_id IN ? AND status = ?", ids, models.PesananDelivered).
+		Group("user_id").
+		Scan(&spends).Error; err != nil {
+		return err
+	}
+
+	type bonusRow struct {
+		ReferralUserID uint
+		Total          float64
+	}
+	var bonuses []bonusRow
+	if err := db.Model(&models.AfiliasiBonus{}).
+		Select("referral_user_id, COALESCE(SUM(bonus_amount), 0) AS total").
+		Where("user_id = ? AND referral_user_id IN ? AND status NOT IN ?", rootID, ids,
+			[]models.AfiliasiBonusStatus{models.BonusAwaitingDelivery, models.BonusVoided}).
+		Group("referral_user_id").
+		Scan(&bonuses).Error; err != nil {
+		return err
+	}
+
+	type referralRow struct {
+		ReferredBy uint
+		Total      int64
+	}
+	var referrals []referralRow
+	if err := db.Model(&models.User{}).
+		Select("referred_by, COUNT(*) AS total").
+		Where("referred_by IN ?", ids).
+		Group("referred_by").
+		Scan(&referrals).Error; err != nil {
+		return err
+	}
+
+	index := make(map[uint]*ReferralTreeNode, len(nodes))
+	for i := range nodes {
+		index[nodes[i].ID] = &nodes[i]
+	}
+	for _, row := range spends {
+		if node := index[row.UserID]; node != nil {
+			node.LifetimeSpent = row.Lifetime
+			node.MonthlySpent = row.Monthly
+		}
+	}
+	for _, row := range bonuses {
+		if node := index[row.ReferralUserID]; node != nil {
+			node.BonusGenerated = row.Total
+		}
+	}
+	for _, row := range referrals {
+		if node := index[row.ReferredBy]; node != nil {
+			node.DirectReferrals = row.Total
+		}
+	}
+	return nil
+}